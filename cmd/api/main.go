@@ -7,50 +7,170 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/SoulStalker/subscribes_api/internal/cache"
 	"github.com/SoulStalker/subscribes_api/internal/config"
 	"github.com/SoulStalker/subscribes_api/internal/handler"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/metrics"
+	"github.com/SoulStalker/subscribes_api/internal/publisher"
+	"github.com/SoulStalker/subscribes_api/internal/ratelimit"
 	"github.com/SoulStalker/subscribes_api/internal/repository/db"
 	"github.com/SoulStalker/subscribes_api/internal/repository/postgres"
 	"github.com/SoulStalker/subscribes_api/internal/service"
+	"github.com/SoulStalker/subscribes_api/internal/tracing"
 
-	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
 func main() {
 	cfgPath := "./config/config.yaml"
 	cfg := config.MustLoad(cfgPath)
 
-	logger := initLogger(cfg.Log)
+	logger := applogger.New(cfg.Log)
 	defer logger.Sync()
 
-	dbPool, err := initDB(cfg.DB)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Fatal("failed to set up tracing", zap.Error(err))
+	}
+
+	dbPool, err := db.NewPool(cfg.DB)
 	if err != nil {
 		logger.Fatal("Failed to connect to database", zap.Error(err))
 	}
 	defer dbPool.Close()
 
-	if err := db.RunMigrations(cfg.DB.DSN()); err != nil {
+	schemaVersion, err := db.RunMigrations(cfg.DB.DSN())
+	if err != nil {
 		logger.Fatal("failed to run migrations", zap.Error(err))
 	}
 
-	repo := postgres.NewSubscriptionRepository(dbPool, logger)
-	svc := service.NewSubscriptionService(repo, logger)
-	h := handler.NewHandler(svc, logger)
-	r := h.InitRoutes(cfg.Server.Mode)
+	healthSvc := service.NewHealthService(postgres.NewHealthRepository(dbPool, logger), schemaVersion, logger)
+
+	appMetrics := metrics.New()
+	appMetrics.RegisterPool(dbPool)
+	pool := postgres.Instrument(dbPool, appMetrics)
+
+	// кеш отчетов общий для подписок и справочников: их изменения тоже сбрасывают отчеты
+	var reportCache service.ReportCache
+	if cfg.ReportsCache.Size > 0 {
+		reportCache = cache.NewLRU(cfg.ReportsCache.Size)
+	}
+
+	catalogRepo := postgres.NewCatalogRepository(pool, logger)
+	catalogSvc := service.NewCatalogService(catalogRepo, reportCache, logger)
+	catalogHandler := handler.NewCatalogHandler(catalogSvc, logger)
+
+	categoryRepo := postgres.NewCategoryRepository(pool, logger)
+	tagRepo := postgres.NewTagRepository(pool, logger)
+	taxonomyHandler := handler.NewTaxonomyHandler(
+		service.NewCategoryService(categoryRepo, reportCache, logger),
+		service.NewTagService(tagRepo, reportCache, logger),
+		logger,
+	)
+
+	repo := postgres.NewSubscriptionRepository(pool, logger)
+	outbox := postgres.NewOutboxRepository(pool, logger)
+	userRepo := postgres.NewUserRepository(pool, logger)
+	opts := []service.Option{
+		service.WithCatalog(catalogSvc),
+		service.WithOutbox(outbox),
+		service.WithUsers(userRepo),
+	}
+	if cfg.Subscriptions.StrictDuplicates {
+		opts = append(opts, service.WithStrictDuplicates())
+	}
+	if reportCache != nil {
+		opts = append(opts, service.WithReportCache(reportCache, cfg.ReportsCache.TTL, appMetrics))
+	}
+	svc := service.NewSubscriptionService(repo, logger, opts...)
+	appMetrics.RegisterStats(svc)
+
+	userSvc := service.NewUserService(userRepo, svc, logger)
+	userHandler := handler.NewUserHandler(userSvc, logger)
+
+	handlerOpts := []handler.HandlerOption{
+		handler.WithUserTimezones(userSvc),
+		handler.WithReadiness(healthSvc),
+		handler.WithMetrics(appMetrics),
+		handler.WithTrustedProxies(cfg.Server.TrustedProxies),
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = newRateLimiter(cfg.RateLimit, pool, logger)
+		handlerOpts = append(handlerOpts, handler.WithRateLimiter(limiter))
+	}
+
+	h := handler.NewHandler(svc, logger, handlerOpts...)
+
+	calendarRepo := postgres.NewCalendarTokenRepository(pool, logger)
+	calendarSvc := service.NewCalendarService(calendarRepo, repo, logger)
+	calendarHandler := handler.NewCalendarHandler(calendarSvc, logger)
+
+	candidateRepo := postgres.NewCandidateRepository(pool, logger)
+	candidateSvc := service.NewCandidateService(candidateRepo, svc, postgres.NewTransactor(pool), logger)
+	candidateHandler := handler.NewCandidateHandler(candidateSvc, logger)
+
+	budgetRepo := postgres.NewBudgetRepository(pool, logger)
+	budgetSvc := service.NewBudgetService(budgetRepo, svc, logger)
+	budgetHandler := handler.NewBudgetHandler(budgetSvc, logger)
+
+	webhookSvc := service.NewWebhookService(
+		postgres.NewWebhookRepository(pool, logger),
+		&http.Client{Timeout: cfg.Webhooks.Timeout},
+		service.RetryPolicy{
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			BaseDelay:   cfg.Webhooks.BaseDelay,
+			MaxDelay:    cfg.Webhooks.MaxDelay,
+		},
+		logger,
+	)
+	webhookHandler := handler.NewWebhookHandler(webhookSvc, logger)
+
+	streamSvc := service.NewStreamService(outbox, postgres.NewEventListener(dbPool, logger), cfg.Stream.History, cfg.Stream.Buffer, logger)
+	streamHandler := handler.NewStreamHandler(streamSvc, cfg.Stream.Heartbeat, logger)
+
+	r := h.InitRoutes(cfg.Server.Mode, catalogHandler, taxonomyHandler, calendarHandler, candidateHandler, budgetHandler, userHandler, webhookHandler, streamHandler)
 
 	srv := &http.Server{
 		Addr:    ":" + cfg.Server.Port,
 		Handler: r,
 	}
+	// Shutdown ждет завершения запросов, а потоки SSE сами не заканчиваются
+	srv.RegisterOnShutdown(streamSvc.Close)
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go streamSvc.Run(workersCtx)
+	if cfg.Budgets.EvaluateInterval > 0 {
+		go budgetSvc.Run(workersCtx, cfg.Budgets.EvaluateInterval)
+	}
+	if cfg.Webhooks.DispatchInterval > 0 {
+		go webhookSvc.Run(workersCtx, cfg.Webhooks.DispatchInterval)
+	}
+	if cfg.Webhooks.EndingSoonInterval > 0 {
+		go svc.RunEndingSoon(workersCtx, cfg.Webhooks.EndingSoonInterval, cfg.Webhooks.EndingSoonDays)
+	}
+	if cfg.Events.PurgeInterval > 0 && cfg.Events.Retention > 0 {
+		retention := service.NewOutboxRetention(outbox, cfg.Events.Retention, cfg.Webhooks.DispatchInterval > 0, logger)
+		go retention.Run(workersCtx, cfg.Events.PurgeInterval)
+	}
+	if len(cfg.Events.Publishers) > 0 && cfg.Events.RelayInterval > 0 {
+		relay, closeRelay := newEventRelay(cfg.Events, outbox, logger)
+		defer closeRelay()
+		go relay.Run(workersCtx, cfg.Events.RelayInterval)
+	}
+	if limiter != nil && cfg.RateLimit.CleanupInterval > 0 {
+		go limiter.Run(workersCtx, cfg.RateLimit.CleanupInterval)
+	}
 
 	go func() {
 		logger.Info("Starting server", zap.String("port", cfg.Server.Port))
@@ -63,65 +183,97 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	// сначала /readyz начинает отвечать 503, и балансировщик успевает снять трафик
+	healthSvc.StartShutdown()
+	logger.Info("Draining traffic before shutdown", zap.Duration("delay", cfg.Server.DrainDelay))
+	time.Sleep(cfg.Server.DrainDelay)
+
 	logger.Info("Shutting down server...")
+	stopWorkers()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("failed to flush traces", zap.Error(err))
+	}
+
 	logger.Info("Server exited")
 }
 
-func initLogger(cfg config.LogConfig) *zap.Logger {
-	var zapCfg zap.Config
-
-	if cfg.Encoding == "json" {
-		zapCfg = zap.NewProductionConfig()
-	} else {
-		zapCfg = zap.NewDevelopmentConfig()
+// newRateLimiter собирает ограничитель частоты запросов из конфига
+func newRateLimiter(cfg config.RateLimitConfig, pool postgres.PgxPool, logger *zap.Logger) *ratelimit.Limiter {
+	var store ratelimit.Store
+	switch cfg.Backend {
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	case "postgres":
+		store = postgres.NewRateLimitRepository(pool, logger)
+	default:
+		logger.Fatal("unknown rate limit backend", zap.String("backend", cfg.Backend))
 	}
 
-	zapCfg.Level = zap.NewAtomicLevelAt(parseLogLevel(cfg.Level))
+	rules := make([]ratelimit.Rule, 0, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		if !ratelimit.ValidIdentity(route.Identity) {
+			logger.Fatal("unknown rate limit identity", zap.String("prefix", route.Prefix), zap.String("identity", route.Identity))
+		}
+		rules = append(rules, ratelimit.Rule{
+			Prefix:   route.Prefix,
+			Limit:    ratelimit.Limit{Rate: route.Rate, Burst: route.Burst},
+			Identity: route.Identity,
+		})
+	}
 
-	logger, _ := zapCfg.Build()
-	return logger
+	return ratelimit.New(store, ratelimit.Limit{Rate: cfg.Rate, Burst: cfg.Burst}, rules, logger)
 }
 
-func parseLogLevel(level string) zapcore.Level {
-	switch level {
-	case "debug":
-		return zap.DebugLevel
-	case "info":
-		return zap.InfoLevel
-	case "warn":
-		return zap.WarnLevel
-	case "error":
-		return zap.ErrorLevel
-	default:
-		return zap.InfoLevel
-	}
-}
+// defaultPublishTimeout - таймаут HTTP-издателя, если в конфиге он не задан
+const defaultPublishTimeout = 10 * time.Second
 
-func initDB(cfg config.DBConfig) (*pgxpool.Pool, error) {
-	poolConfig, err := pgxpool.ParseConfig(cfg.DSN())
-	if err != nil {
-		return nil, fmt.Errorf("parse db config: %w", err)
-	}
+// newEventRelay собирает релей событий outbox из конфига; close закрывает файлы издателей
+func newEventRelay(cfg config.EventsConfig, outbox *postgres.OutboxRepository, logger *zap.Logger) (*service.EventRelay, func()) {
+	relay := service.NewEventRelay(outbox, cfg.BatchSize, service.RetryPolicy{
+		MaxAttempts: cfg.MaxAttempts,
+		BaseDelay:   cfg.BaseDelay,
+		MaxDelay:    cfg.MaxDelay,
+	}, logger)
 
-	poolConfig.MaxConns = int32(cfg.MaxConnections)
-	poolConfig.MinConns = int32(cfg.MaxIdleConnections)
+	var files []*publisher.File
+	for _, p := range cfg.Publishers {
+		name := p.Name
+		if name == "" {
+			name = p.Type
+		}
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
-	if err != nil {
-		return nil, fmt.Errorf("create pool: %w", err)
+		switch p.Type {
+		case "log":
+			relay.Register(name, publisher.NewLog(logger.Named("events")))
+		case "file":
+			file, err := publisher.NewFile(p.Path)
+			if err != nil {
+				logger.Fatal("failed to open events file", zap.String("publisher", name), zap.Error(err))
+			}
+			files = append(files, file)
+			relay.Register(name, file)
+		case "http":
+			timeout := p.Timeout
+			if timeout <= 0 {
+				timeout = defaultPublishTimeout
+			}
+			relay.Register(name, publisher.NewHTTP(p.URL, p.Headers, &http.Client{Timeout: timeout}))
+		default:
+			logger.Fatal("unknown event publisher type", zap.String("publisher", name), zap.String("type", p.Type))
+		}
 	}
 
-	if err := pool.Ping(context.Background()); err != nil {
-		return nil, fmt.Errorf("ping db: %w", err)
+	return relay, func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
 	}
-
-	return pool, nil
 }
@@ -0,0 +1,125 @@
+// Команды резервного копирования данных:
+//
+//	dataset backup  -config ./config/config.yaml -file backup.ndjson
+//	dataset restore -config ./config/config.yaml -file backup.ndjson
+//
+// Без -file архив пишется в stdout и читается из stdin.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/SoulStalker/subscribes_api/internal/config"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/repository/db"
+	"github.com/SoulStalker/subscribes_api/internal/repository/postgres"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"go.uber.org/zap"
+)
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "backup" && os.Args[1] != "restore") {
+		fmt.Fprintln(os.Stderr, "usage: dataset backup|restore [-config path] [-file path]")
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	cfgPath := flags.String("config", "./config/config.yaml", "path to config file")
+	file := flags.String("file", "", "archive path, stdout/stdin if empty")
+	flags.Parse(os.Args[2:])
+
+	cfg := config.MustLoad(*cfgPath)
+
+	// логи идут в stderr, stdout может быть занят архивом
+	logger := applogger.New(cfg.Log)
+	defer logger.Sync()
+
+	dbPool, err := db.NewPool(cfg.DB)
+	if err != nil {
+		logger.Fatal("Failed to connect to database", zap.Error(err))
+	}
+	defer dbPool.Close()
+
+	if _, err := db.RunMigrations(cfg.DB.DSN()); err != nil {
+		logger.Fatal("failed to run migrations", zap.Error(err))
+	}
+
+	userRepo := postgres.NewUserRepository(dbPool, logger)
+	catalogRepo := postgres.NewCatalogRepository(dbPool, logger)
+	categoryRepo := postgres.NewCategoryRepository(dbPool, logger)
+	subsRepo := postgres.NewSubscriptionRepository(dbPool, logger)
+	tagRepo := postgres.NewTagRepository(dbPool, logger)
+	budgetRepo := postgres.NewBudgetRepository(dbPool, logger)
+	calendarRepo := postgres.NewCalendarTokenRepository(dbPool, logger)
+	candidateRepo := postgres.NewCandidateRepository(dbPool, logger)
+	webhookRepo := postgres.NewWebhookRepository(dbPool, logger)
+
+	svc := service.NewBackupService(postgres.NewTransactor(dbPool), logger,
+		service.NewBackupTable("users", userRepo.Count, userRepo.Stream, userRepo.Restore),
+		service.NewBackupTable("services", catalogRepo.Count, catalogRepo.Stream, catalogRepo.Restore),
+		service.NewBackupTable("categories", categoryRepo.Count, categoryRepo.Stream, categoryRepo.Restore),
+		service.SubscriptionsBackupTable(subsRepo),
+		service.NewBackupTable("tags", tagRepo.CountTags, tagRepo.StreamTags, tagRepo.RestoreTags),
+		service.NewBackupTable("subscription_tags", tagRepo.CountLinks, tagRepo.StreamLinks, tagRepo.RestoreLinks),
+		service.NewBackupTable("budgets", budgetRepo.Count, budgetRepo.Stream, budgetRepo.Restore),
+		service.NewBackupTable("budget_alerts", budgetRepo.CountAlerts, budgetRepo.StreamAlerts, budgetRepo.RestoreAlerts),
+		service.NewBackupTable("calendar_tokens", calendarRepo.CountTokens, calendarRepo.StreamTokens, calendarRepo.RestoreTokens),
+		service.NewBackupTable("subscription_candidates", candidateRepo.Count, candidateRepo.Stream, candidateRepo.Restore),
+		service.NewBackupTable("webhooks", webhookRepo.Count, webhookRepo.Stream, webhookRepo.Restore),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "backup":
+		err = runBackup(ctx, svc, *file)
+	case "restore":
+		err = runRestore(ctx, svc, *file)
+	}
+
+	if err != nil {
+		logger.Fatal(command+" failed", zap.Error(err))
+	}
+	logger.Info(command + " completed")
+}
+
+func runBackup(ctx context.Context, svc *service.BackupService, path string) error {
+	if path == "" {
+		return svc.Backup(ctx, os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := svc.Backup(ctx, f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func runRestore(ctx context.Context, svc *service.BackupService, path string) error {
+	var r io.Reader = os.Stdin
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return svc.Restore(ctx, r)
+}
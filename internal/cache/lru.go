@@ -0,0 +1,123 @@
+// Package cache - хранилища для кеша отчетов. LRU живет в памяти процесса;
+// общий кеш для нескольких экземпляров подключается реализацией того же
+// набора методов поверх внешнего хранилища.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// versionBuckets - число счетчиков версий. Области раскладываются по ним хешем,
+// поэтому память не растет с числом пользователей; две области в одной корзине
+// только сбрасывают отчеты друг друга заодно.
+const versionBuckets = 4096
+
+// LRU хранит не больше capacity значений и вытесняет давно не читанные.
+// Версии областей хранятся отдельно и не вытесняются: иначе сброшенная
+// версия вернула бы к жизни устаревшие значения.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+	versions [versionBuckets]uint64
+	now      func() time.Time
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: max(capacity, 1),
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		now:      time.Now,
+	}
+}
+
+// Get возвращает значение, если оно есть и не истекло
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := el.Value.(*entry)
+	if !e.expires.IsZero() && !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set сохраняет значение на ttl; ttl <= 0 - без срока
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+
+	if el, ok := c.items[key]; ok {
+		el.Value = &entry{key: key, value: value, expires: expires}
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.items[key] = c.order.PushFront(&entry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+// Version - текущая версия области scope, 0 для новой
+func (c *LRU) Version(_ context.Context, scope string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.versions[versionBucket(scope)], nil
+}
+
+// Bump увеличивает версию области, и значения, сохраненные под прежней, больше не читаются
+func (c *LRU) Bump(_ context.Context, scope string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.versions[versionBucket(scope)]++
+	return nil
+}
+
+// Len - число хранимых значений
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+func versionBucket(scope string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(scope))
+	return h.Sum32() % versionBuckets
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_EvictsLeastRecentlyRead(t *testing.T) {
+	c := NewLRU(2)
+	ctx := context.Background()
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+
+	// после чтения "a" старейшим становится "b"
+	_, ok, _ := c.Get(ctx, "a")
+	require.True(t, ok)
+	require.NoError(t, c.Set(ctx, "c", []byte("3"), 0))
+
+	_, ok, _ = c.Get(ctx, "b")
+	assert.False(t, ok)
+	value, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRU_Expires(t *testing.T) {
+	c := NewLRU(10)
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+	assert.Zero(t, c.Len())
+}
+
+func TestLRU_VersionsSurviveEviction(t *testing.T) {
+	c := NewLRU(1)
+	ctx := context.Background()
+
+	require.NoError(t, c.Bump(ctx, "user:1"))
+	require.NoError(t, c.Bump(ctx, "user:1"))
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	require.NoError(t, c.Set(ctx, "b", []byte("2"), 0))
+
+	version, err := c.Version(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), version)
+
+	version, _ = c.Version(ctx, "all")
+	assert.Zero(t, version)
+}
+
+func TestLRU_VersionsBounded(t *testing.T) {
+	c := NewLRU(1)
+	ctx := context.Background()
+
+	// областей больше, чем корзин: каждая версия все равно растет после Bump
+	for i := range 2 * versionBuckets {
+		scope := fmt.Sprintf("user:%d", i)
+		before, err := c.Version(ctx, scope)
+		require.NoError(t, err)
+		require.NoError(t, c.Bump(ctx, scope))
+
+		after, _ := c.Version(ctx, scope)
+		assert.Greater(t, after, before)
+	}
+	assert.Len(t, c.versions, versionBuckets)
+}
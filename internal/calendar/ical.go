@@ -0,0 +1,120 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+const (
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+	maxLineOctets  = 75
+)
+
+// Render пишет iCalendar (RFC 5545) с ежемесячными списаниями и датами окончания подписок.
+// Подписки, по которым больше не будет событий относительно now, пропускаются.
+func Render(w io.Writer, subs []domain.Subscription, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	stamp := now.UTC().Format(dateTimeFormat)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:-//subscribes_api//renewals//EN")
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+	writeLine(bw, "X-WR-CALNAME:Subscription renewals")
+
+	for _, sub := range subs {
+		if _, ok := sub.NextChargeDate(today); !ok && (sub.EndDate == nil || sub.EndDate.Before(today)) {
+			continue
+		}
+
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+sub.ID.String()+"-charge@subscribes_api")
+		writeLine(bw, "DTSTAMP:"+stamp)
+		writeLine(bw, "DTSTART;VALUE=DATE:"+sub.StartDate.Format(dateFormat))
+		writeLine(bw, "RRULE:"+recurrenceRule(&sub))
+		writeLine(bw, "SUMMARY:"+escapeText(fmt.Sprintf("%s: %d", sub.ServiceName, sub.Price*sub.BillingMonths())))
+		writeLine(bw, "DESCRIPTION:"+escapeText(fmt.Sprintf("%s charge for %s, subscription %s", chargeCadence(&sub), sub.ServiceName, sub.ID)))
+		writeLine(bw, "TRANSP:TRANSPARENT")
+		writeLine(bw, "END:VEVENT")
+
+		if sub.EndDate != nil {
+			writeLine(bw, "BEGIN:VEVENT")
+			writeLine(bw, "UID:"+sub.ID.String()+"-end@subscribes_api")
+			writeLine(bw, "DTSTAMP:"+stamp)
+			writeLine(bw, "DTSTART;VALUE=DATE:"+sub.EndDate.Format(dateFormat))
+			writeLine(bw, "SUMMARY:"+escapeText(fmt.Sprintf("%s ends", sub.ServiceName)))
+			writeLine(bw, "TRANSP:TRANSPARENT")
+			writeLine(bw, "END:VEVENT")
+		}
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+	return bw.Flush()
+}
+
+// chargeCadence - период оплаты для описания события
+func chargeCadence(sub *domain.Subscription) string {
+	switch sub.BillingMonths() {
+	case 3:
+		return "Quarterly"
+	case 6:
+		return "Semiannual"
+	case 12:
+		return "Yearly"
+	}
+	return "Monthly"
+}
+
+// recurrenceRule описывает списание раз в период оплаты в день start_date.
+// Для дней после 28-го берется последний подходящий день месяца, как в domain.Subscription.
+func recurrenceRule(sub *domain.Subscription) string {
+	rule := "FREQ=MONTHLY"
+	if months := sub.BillingMonths(); months > 1 {
+		rule += fmt.Sprintf(";INTERVAL=%d", months)
+	}
+
+	if day := sub.StartDate.Day(); day > 28 {
+		days := make([]string, 0, day-27)
+		for d := 28; d <= day; d++ {
+			days = append(days, fmt.Sprint(d))
+		}
+		rule += ";BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+	}
+
+	if last, ok := sub.LastChargeDate(); ok {
+		rule += ";UNTIL=" + last.Format(dateFormat)
+	}
+
+	return rule
+}
+
+func escapeText(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// writeLine пишет строку с переносом длинных строк по 75 октетов (RFC 5545, 3.1)
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		// не разрезаем многобайтовые UTF-8 символы
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
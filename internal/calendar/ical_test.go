@@ -0,0 +1,59 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	now := time.Date(2025, 8, 15, 10, 0, 0, 0, time.UTC)
+
+	active := testutil.FixtureSubscription(testutil.WithServiceName("Yandex Plus, family"),
+		testutil.WithDates(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)))
+	lastDay := testutil.FixtureSubscription(
+		testutil.WithDates(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), time.Time{}))
+	expired := testutil.FixtureSubscription(
+		testutil.WithDates(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)))
+
+	var buf bytes.Buffer
+	err := Render(&buf, []domain.Subscription{*active, *lastDay, *expired}, now)
+	require.NoError(t, err)
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.Equal(t, 3, strings.Count(out, "BEGIN:VEVENT"))
+	assert.Contains(t, out, "RRULE:FREQ=MONTHLY;UNTIL=20251201\r\n")
+	assert.Contains(t, out, "RRULE:FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1\r\n")
+	assert.Contains(t, out, `SUMMARY:Yandex Plus\, family: 500`)
+	assert.Contains(t, out, "UID:"+active.ID.String()+"-end@subscribes_api")
+	assert.NotContains(t, out, expired.ID.String())
+
+	for _, line := range strings.Split(out, "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+	}
+}
+
+func TestRender_BillingPeriod(t *testing.T) {
+	now := time.Date(2025, 8, 15, 10, 0, 0, 0, time.UTC)
+
+	yearly := testutil.FixtureSubscription(testutil.WithPrice(100),
+		testutil.WithDates(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)))
+	yearly.BillingPeriod = domain.BillingYearly
+
+	var buf bytes.Buffer
+	require.NoError(t, Render(&buf, []domain.Subscription{*yearly}, now))
+
+	out := buf.String()
+	// последнее списание - март 2026, ноябрь 2026 уже не начинает новый год оплаты
+	assert.Contains(t, out, "RRULE:FREQ=MONTHLY;INTERVAL=12;UNTIL=20260310\r\n")
+	assert.Contains(t, out, "SUMMARY:Test Service: 1200")
+	assert.Contains(t, out, "DESCRIPTION:Yearly charge")
+}
@@ -4,19 +4,35 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type Config struct {
-	Server ServerConfig `yaml:"server"`
-	DB     DBConfig     `yaml:"database"`
-	Log    LogConfig    `yaml:"log"`
+	Server        ServerConfig        `yaml:"server"`
+	DB            DBConfig            `yaml:"database"`
+	Log           LogConfig           `yaml:"log"`
+	Subscriptions SubscriptionsConfig `yaml:"subscriptions"`
+	Budgets       BudgetsConfig       `yaml:"budgets"`
+	Tracing       TracingConfig       `yaml:"tracing"`
+	RateLimit     RateLimitConfig     `yaml:"rate_limit"`
+	ReportsCache  ReportsCacheConfig  `yaml:"reports_cache"`
+	Webhooks      WebhooksConfig      `yaml:"webhooks"`
+	Events        EventsConfig        `yaml:"events"`
+	Stream        StreamConfig        `yaml:"stream"`
 }
 
 type ServerConfig struct {
 	Port string `yaml:"port" env-default:"8080"`
 	Mode string `yaml:"mode" env-default:"release"`
+	// DrainDelay - сколько после SIGTERM отвечать отказом в /readyz, прежде чем останавливать сервер
+	DrainDelay time.Duration `yaml:"drain_delay" env-default:"5s"`
+	// ShutdownTimeout - сколько ждать завершения текущих запросов
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"5s"`
+	// TrustedProxies - адреса и подсети прокси, от которых принимается X-Forwarded-For;
+	// пусто - IP клиента берется из соединения
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 type DBConfig struct {
@@ -47,6 +63,72 @@ type LogConfig struct {
 	Encoding string `yaml:"encoding"`
 }
 
+// SubscriptionsConfig - правила создания подписок
+type SubscriptionsConfig struct {
+	// StrictDuplicates запрещает пересекающиеся подписки пользователя на один сервис
+	StrictDuplicates bool `yaml:"strict_duplicates" env-default:"false"`
+}
+
+// BudgetsConfig - фоновая проверка бюджетов; 0 отключает ее
+type BudgetsConfig struct {
+	EvaluateInterval time.Duration `yaml:"evaluate_interval" env-default:"1h"`
+}
+
+// TracingConfig - экспорт трейсов OpenTelemetry
+type TracingConfig struct {
+	// Exporter - none, otlp (OTLP/HTTP) или stdout (JSON в stdout или в File)
+	Exporter    string  `yaml:"exporter" env-default:"none"`
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"`
+	Insecure    bool    `yaml:"insecure" env-default:"true"`
+	File        string  `yaml:"file"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"subscriptions-api"`
+}
+
+// RateLimitConfig - ограничение частоты запросов к API. Rate - токенов в секунду,
+// Burst - емкость корзины; для маршрутов без своего правила действуют Rate и Burst верхнего уровня.
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" env-default:"false"`
+	// Backend - memory (один экземпляр) или postgres (общий лимит для всех экземпляров)
+	Backend         string             `yaml:"backend" env-default:"memory"`
+	Rate            float64            `yaml:"rate" env-default:"20"`
+	Burst           int                `yaml:"burst" env-default:"40"`
+	Routes          []RouteLimitConfig `yaml:"routes"`
+	CleanupInterval time.Duration      `yaml:"cleanup_interval" env-default:"1m"`
+}
+
+// RouteLimitConfig - лимит группы маршрутов, шаблон которых начинается с Prefix
+type RouteLimitConfig struct {
+	Prefix string  `yaml:"prefix"`
+	Rate   float64 `yaml:"rate"`
+	Burst  int     `yaml:"burst"`
+	// Identity - по чему различать клиентов группы: api_key, user или ip (по умолчанию);
+	// запрос без ключа или пользователя считается по IP
+	Identity string `yaml:"identity"`
+}
+
+// ReportsCacheConfig - кеш отчетов о стоимости в памяти процесса; Size 0 отключает его
+type ReportsCacheConfig struct {
+	// Size - сколько отчетов хранить, вытесняются давно не запрошенные
+	Size int `yaml:"size" env-default:"1024"`
+	// TTL - срок жизни отчета на случай изменений в обход сервиса подписок
+	TTL time.Duration `yaml:"ttl" env-default:"5m"`
+}
+
+// WebhooksConfig - доставка событий на webhook; DispatchInterval 0 отключает ее
+type WebhooksConfig struct {
+	DispatchInterval time.Duration `yaml:"dispatch_interval" env-default:"5s"`
+	// Timeout - сколько ждать ответа получателя
+	Timeout     time.Duration `yaml:"timeout" env-default:"10s"`
+	MaxAttempts int           `yaml:"max_attempts" env-default:"8"`
+	// BaseDelay удваивается после каждой неудачной попытки, но не больше MaxDelay
+	BaseDelay time.Duration `yaml:"base_delay" env-default:"30s"`
+	MaxDelay  time.Duration `yaml:"max_delay" env-default:"1h"`
+	// EndingSoonDays - за сколько дней до end_date отправлять subscription.ending_soon
+	EndingSoonDays     int           `yaml:"ending_soon_days" env-default:"7"`
+	EndingSoonInterval time.Duration `yaml:"ending_soon_interval" env-default:"1h"`
+}
+
 // MustLoad - загружает конфигурацию из yaml файла
 func MustLoad(configPath string) *Config {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
@@ -57,6 +139,48 @@ func MustLoad(configPath string) *Config {
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
 		log.Fatalf("cannot read config: %v", err)
 	}
+	if cfg.Stream.Heartbeat <= 0 {
+		log.Fatalf("stream.heartbeat must be positive, got %s", cfg.Stream.Heartbeat)
+	}
 
 	return &cfg
 }
+
+// EventsConfig - публикация событий outbox во внешние системы; без Publishers
+// или с RelayInterval 0 она отключена
+type EventsConfig struct {
+	RelayInterval time.Duration     `yaml:"relay_interval" env-default:"2s"`
+	BatchSize     int               `yaml:"batch_size" env-default:"100"`
+	Publishers    []PublisherConfig `yaml:"publishers"`
+	// MaxAttempts - после стольких неудачных публикаций событие получает статус dead;
+	// BaseDelay удваивается после каждой неудачной попытки, но не больше MaxDelay
+	MaxAttempts int           `yaml:"max_attempts" env-default:"10"`
+	BaseDelay   time.Duration `yaml:"base_delay" env-default:"5s"`
+	MaxDelay    time.Duration `yaml:"max_delay" env-default:"10m"`
+	// Retention - сколько хранить обработанные события outbox и журнал их доставок;
+	// PurgeInterval - как часто их удалять, 0 отключает удаление
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// PublisherConfig - издатель событий. Name хранит, что издатель уже опубликовал:
+// переименованный издатель начнет с новых событий.
+type PublisherConfig struct {
+	Name string `yaml:"name"`
+	// Type - log, file (Path, JSON Lines) или http (URL, Headers, Timeout; по умолчанию 10s)
+	Type    string            `yaml:"type"`
+	Path    string            `yaml:"path"`
+	URL     string            `yaml:"url"`
+	Headers map[string]string `yaml:"headers"`
+	Timeout time.Duration     `yaml:"timeout"`
+}
+
+// StreamConfig - поток изменений подписок через SSE
+type StreamConfig struct {
+	// History - сколько последних событий хранить для переподключения с Last-Event-ID
+	History int `yaml:"history" env-default:"1000"`
+	// Buffer - сколько событий может ждать отправки клиенту, после этого он отключается
+	Buffer int `yaml:"buffer" env-default:"64"`
+	// Heartbeat - как часто слать комментарий в простаивающий поток; должен быть больше 0
+	Heartbeat time.Duration `yaml:"heartbeat" env-default:"15s"`
+}
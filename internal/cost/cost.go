@@ -0,0 +1,132 @@
+// Package cost считает стоимость подписки за период без обращения к базе.
+// Режим whole_months обязан совпадать с SQL отчетов в репозитории: это
+// проверяют тесты пакета и интеграционный тест postgres.
+package cost
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+var ErrInvalidMode = errors.New("invalid cost mode")
+
+// Func считает стоимость подписки за период [from, to] включительно по ее
+// ежемесячной цене monthly и возвращает число оплаченных единиц и сумму
+type Func func(sub *domain.Subscription, monthly int, from, to time.Time) (units, amount int)
+
+var modes = map[domain.CostMode]Func{
+	domain.CostModeWholeMonths:   WholeMonths,
+	domain.CostModeDailyProrated: DailyProrated,
+	domain.CostModeChargeEvents:  ChargeEvents,
+}
+
+// For возвращает расчет для режима; пустой режим - whole_months
+func For(mode domain.CostMode) (Func, error) {
+	if mode == "" {
+		mode = domain.CostModeWholeMonths
+	}
+
+	fn, ok := modes[mode]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidMode, mode)
+	}
+	return fn, nil
+}
+
+// ActiveRange - дни действия подписки внутри периода [from, to]; ok=false, если их нет.
+// end_date считается последним днем действия, как в overlapMonthsSQL отчетов.
+func ActiveRange(sub *domain.Subscription, from, to time.Time) (start, end time.Time, ok bool) {
+	start, end = sub.StartDate, to
+	if start.Before(from) {
+		start = from
+	}
+	if sub.EndDate != nil && sub.EndDate.Before(end) {
+		end = *sub.EndDate
+	}
+	return start, end, !end.Before(start)
+}
+
+// PaidRange - оплаченные дни подписки внутри периода [from, to]; ok=false, если их нет.
+// Месяц end_date оплачен целиком, поэтому подписка действует до конца этого месяца.
+func PaidRange(sub *domain.Subscription, from, to time.Time) (start, end time.Time, ok bool) {
+	start, end = sub.StartDate, to
+	if start.Before(from) {
+		start = from
+	}
+	if sub.EndDate != nil {
+		if last := monthStart(*sub.EndDate).AddDate(0, 1, -1); last.Before(end) {
+			end = last
+		}
+	}
+	return start, end, !end.Before(start)
+}
+
+// BillableMonths - число месяцев от start до end, как EXTRACT(YEAR/MONTH FROM AGE(end, start)) + 1
+// в SQL: полные месяцы между датами плюс неполный последний месяц
+func BillableMonths(start, end time.Time) int {
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+	if end.Day() < start.Day() {
+		months--
+	}
+	return months + 1
+}
+
+// WholeMonths оплачивает каждый месяц между началом и концом действия в периоде целиком
+func WholeMonths(sub *domain.Subscription, monthly int, from, to time.Time) (int, int) {
+	start, end, ok := ActiveRange(sub, from, to)
+	if !ok {
+		return 0, 0
+	}
+
+	months := BillableMonths(start, end)
+	return months, monthly * months
+}
+
+// DailyProrated оплачивает каждый календарный месяц пропорционально оплаченным
+// дням подписки в нем (см. PaidRange); доля месяца округляется до целого
+func DailyProrated(sub *domain.Subscription, monthly int, from, to time.Time) (int, int) {
+	start, end, ok := PaidRange(sub, from, to)
+	if !ok {
+		return 0, 0
+	}
+
+	months, amount := 0, 0
+	for month := monthStart(start); !month.After(end); month = month.AddDate(0, 1, 0) {
+		first, last := month, month.AddDate(0, 1, -1)
+		daysInMonth := last.Day()
+		if first.Before(start) {
+			first = start
+		}
+		if last.After(end) {
+			last = end
+		}
+
+		months++
+		amount += Prorate(monthly, daysBetween(first, last)+1, daysInMonth)
+	}
+
+	return months, amount
+}
+
+// Prorate - доля цены месяца за days из daysInMonth дней, округленная до целого
+func Prorate(monthly, days, daysInMonth int) int {
+	return (2*monthly*days + daysInMonth) / (2 * daysInMonth)
+}
+
+// ChargeEvents оплачивает списания, даты которых попали в период; списание
+// стоит месячную цену за весь период оплаты подписки
+func ChargeEvents(sub *domain.Subscription, monthly int, from, to time.Time) (int, int) {
+	charges := len(sub.ChargeDates(from, to.AddDate(0, 0, 1)))
+	return charges, monthly * sub.BillingMonths() * charges
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
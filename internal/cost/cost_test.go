@@ -0,0 +1,354 @@
+package cost
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModes(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	midMonth := testutil.FixtureSubscription(
+		testutil.WithDates(time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC), time.Time{}))
+	cancelled := testutil.FixtureSubscription(
+		testutil.WithDates(time.Date(2024, 11, 30, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC)))
+	future := testutil.FixtureSubscription(
+		testutil.WithDates(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), time.Time{}))
+
+	tests := []struct {
+		name    string
+		mode    domain.CostMode
+		sub     *domain.Subscription
+		monthly int
+		units   int
+		amount  int
+	}{
+		{"whole months from mid-month start", domain.CostModeWholeMonths, midMonth, 3100, 3, 9300},
+		{"daily from mid-month start", domain.CostModeDailyProrated, midMonth, 3100, 3, 7800},
+		{"charges from mid-month start", domain.CostModeChargeEvents, midMonth, 3100, 3, 9300},
+		{"whole months until cancellation", domain.CostModeWholeMonths, cancelled, 2800, 2, 5600},
+		{"daily until cancellation", domain.CostModeDailyProrated, cancelled, 2800, 2, 5600},
+		{"charges until cancellation", domain.CostModeChargeEvents, cancelled, 2800, 2, 5600},
+		{"whole months after period", domain.CostModeWholeMonths, future, 500, 0, 0},
+		{"daily after period", domain.CostModeDailyProrated, future, 500, 0, 0},
+		{"charges after period", domain.CostModeChargeEvents, future, 500, 0, 0},
+		{"default mode", "", midMonth, 3100, 3, 9300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := For(tt.mode)
+			require.NoError(t, err)
+
+			units, amount := fn(tt.sub, tt.monthly, from, to)
+
+			assert.Equal(t, tt.units, units)
+			assert.Equal(t, tt.amount, amount)
+		})
+	}
+}
+
+func TestFor_UnknownMode(t *testing.T) {
+	_, err := For("yearly")
+	assert.ErrorIs(t, err, ErrInvalidMode)
+}
+
+func TestBillableMonths(t *testing.T) {
+	tests := []struct {
+		start, end string
+		months     int
+	}{
+		{"2025-01-01", "2025-01-31", 1},
+		{"2025-01-16", "2025-03-31", 3},
+		{"2025-01-31", "2025-02-28", 1},
+		{"2025-01-31", "2025-03-01", 2},
+		{"2024-02-29", "2025-02-28", 12},
+		{"2024-12-15", "2025-01-14", 1},
+		{"2024-12-15", "2025-01-15", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start+".."+tt.end, func(t *testing.T) {
+			start, _ := time.Parse("2006-01-02", tt.start)
+			end, _ := time.Parse("2006-01-02", tt.end)
+			assert.Equal(t, tt.months, BillableMonths(start, end))
+		})
+	}
+}
+
+// scenario - случайная подписка и период отчета для property-тестов
+type scenario struct {
+	Sub     domain.Subscription
+	Monthly int
+	From    time.Time
+	To      time.Time
+}
+
+var epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newScenario(startDay, length, fromDay, periodLength, monthly int, hasEnd bool) scenario {
+	start := epoch.AddDate(0, 0, startDay)
+	sub := domain.Subscription{StartDate: start}
+	if hasEnd {
+		end := start.AddDate(0, 0, length)
+		sub.EndDate = &end
+	}
+
+	from := epoch.AddDate(0, 0, fromDay)
+	return scenario{Sub: sub, Monthly: monthly, From: from, To: from.AddDate(0, 0, periodLength)}
+}
+
+func (scenario) Generate(r *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(newScenario(r.Intn(3650), r.Intn(1500), r.Intn(3650), r.Intn(1500), r.Intn(100000), r.Intn(3) > 0))
+}
+
+var quickConfig = &quick.Config{MaxCount: 5000}
+
+// sqlSelected повторяет условие выборки costScope: start_date <= $2 AND (end_date IS NULL OR end_date >= $1)
+func sqlSelected(s scenario) bool {
+	return !s.Sub.StartDate.After(s.To) && (s.Sub.EndDate == nil || !s.Sub.EndDate.Before(s.From))
+}
+
+// sqlSelectedRecalculated повторяет выборку costScope для режимов, которые пересчитываются
+// в Go: end_date >= DATE_TRUNC('month', $1)
+func sqlSelectedRecalculated(s scenario) bool {
+	return !s.Sub.StartDate.After(s.To) && (s.Sub.EndDate == nil || !s.Sub.EndDate.Before(monthStart(s.From)))
+}
+
+// activeOn - модель по дням: оплачена ли подписка в день d периода; месяц end_date оплачен целиком
+func activeOn(s scenario, d time.Time) bool {
+	return !d.Before(s.Sub.StartDate) && (s.Sub.EndDate == nil || !monthStart(d).After(*s.Sub.EndDate))
+}
+
+// chargedOn - модель по дням: есть ли списание в день d. Списание ежемесячно в день
+// start_date (в коротком месяце - в последний день) до месяца end_date включительно.
+func chargedOn(s scenario, d time.Time) bool {
+	if d.Before(s.Sub.StartDate) {
+		return false
+	}
+	if end := s.Sub.EndDate; end != nil && (d.Year() > end.Year() || d.Year() == end.Year() && d.Month() > end.Month()) {
+		return false
+	}
+
+	day := s.Sub.StartDate.Day()
+	if last := daysIn(d); day > last {
+		day = last
+	}
+	return d.Day() == day
+}
+
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// dailyModel проходит период по дням и возвращает затронутые месяцы и точную сумму без округления
+func dailyModel(s scenario) (int, float64) {
+	months := map[time.Time]bool{}
+	exact := 0.0
+	for d := s.From; !d.After(s.To); d = d.AddDate(0, 0, 1) {
+		if activeOn(s, d) {
+			months[monthStart(d)] = true
+			exact += float64(s.Monthly) / float64(daysIn(d))
+		}
+	}
+	return len(months), exact
+}
+
+func chargesModel(s scenario) int {
+	charges := 0
+	for d := s.From; !d.After(s.To); d = d.AddDate(0, 0, 1) {
+		if chargedOn(s, d) {
+			charges++
+		}
+	}
+	return charges
+}
+
+// TestWholeMonths_SelectedLikeSQL проверяет выборку строк: месяцы есть ровно у подписок,
+// которые выбирает costScope. Число месяцев сверяется с overlapMonthsSQL на живой базе
+// (TestOverlapMonthsSQL_MatchesBillableMonths в repository/postgres).
+func TestWholeMonths_SelectedLikeSQL(t *testing.T) {
+	property := func(s scenario) bool {
+		units, amount := WholeMonths(&s.Sub, s.Monthly, s.From, s.To)
+		if !sqlSelected(s) {
+			return units == 0 && amount == 0
+		}
+		return units >= 1 && amount == s.Monthly*units
+	}
+
+	require.NoError(t, quick.Check(property, quickConfig))
+}
+
+func TestDailyProrated_MatchesDayModel(t *testing.T) {
+	property := func(s scenario) bool {
+		units, amount := DailyProrated(&s.Sub, s.Monthly, s.From, s.To)
+		months, exact := dailyModel(s)
+
+		// каждый месяц округляется отдельно, не больше чем на половину
+		return units == months && math.Abs(float64(amount)-exact) <= 0.5*float64(months)+1e-6
+	}
+
+	require.NoError(t, quick.Check(property, quickConfig))
+}
+
+func TestRecalculatedModes_CoveredBySQLSelection(t *testing.T) {
+	property := func(s scenario) bool {
+		// строка, которую база не выбрала, не может ничего стоить
+		if sqlSelectedRecalculated(s) {
+			return true
+		}
+		chargeUnits, _ := ChargeEvents(&s.Sub, s.Monthly, s.From, s.To)
+		dailyUnits, _ := DailyProrated(&s.Sub, s.Monthly, s.From, s.To)
+		return chargeUnits == 0 && dailyUnits == 0
+	}
+
+	require.NoError(t, quick.Check(property, quickConfig))
+}
+
+func TestModes_MonthGranularDates(t *testing.T) {
+	// в API даты месячные: "01-2025".."03-2025" - это 1 января и 1 марта, март оплачен
+	sub := testutil.FixtureSubscription(
+		testutil.WithDates(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)))
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	for _, mode := range []domain.CostMode{domain.CostModeWholeMonths, domain.CostModeDailyProrated, domain.CostModeChargeEvents} {
+		fn, err := For(mode)
+		require.NoError(t, err)
+
+		units, amount := fn(sub, 3100, from, to)
+		assert.Equal(t, 3, units, mode)
+		assert.Equal(t, 9300, amount, mode)
+	}
+
+	property := func(first, count uint8, monthly uint16) bool {
+		start := epoch.AddDate(0, int(first), 0)
+		end := start.AddDate(0, int(count%36), 0)
+		sub := domain.Subscription{StartDate: start, EndDate: &end}
+		from, to := start.AddDate(0, -1, 0), end.AddDate(0, 2, 0)
+
+		_, whole := WholeMonths(&sub, int(monthly), from, to)
+		_, daily := DailyProrated(&sub, int(monthly), from, to)
+		_, charges := ChargeEvents(&sub, int(monthly), from, to)
+		return whole == daily && whole == charges && whole == int(monthly)*(int(count%36)+1)
+	}
+
+	require.NoError(t, quick.Check(property, quickConfig))
+}
+
+func TestModes_EndMonthBeforePeriodStart(t *testing.T) {
+	// end_date 1 марта раньше начала периода 10 марта, но март оплачен
+	sub := testutil.FixtureSubscription(
+		testutil.WithDates(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)))
+	from := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	s := scenario{Sub: *sub, Monthly: 3100, From: from, To: to}
+
+	require.True(t, sqlSelectedRecalculated(s))
+
+	units, amount := ChargeEvents(sub, 3100, from, to)
+	assert.Equal(t, 1, units)
+	assert.Equal(t, 3100, amount)
+
+	_, amount = DailyProrated(sub, 3100, from, to)
+	assert.Equal(t, 2200, amount)
+}
+
+func TestDailyProrated_EqualsWholeMonthsForFullMonths(t *testing.T) {
+	property := func(first, count uint8, monthly uint16, openEnded bool) bool {
+		// подписка на целые календарные месяцы стоит одинаково в обоих режимах
+		start := epoch.AddDate(0, int(first), 0)
+		end := start.AddDate(0, int(count%36)+1, -1)
+		sub := domain.Subscription{StartDate: start, EndDate: &end}
+		to := end
+		if openEnded {
+			sub.EndDate = nil
+		}
+
+		dailyUnits, daily := DailyProrated(&sub, int(monthly), start.AddDate(0, 0, -10), to)
+		wholeUnits, whole := WholeMonths(&sub, int(monthly), start.AddDate(0, 0, -10), to)
+		return dailyUnits == wholeUnits && daily == whole && wholeUnits == int(count%36)+1
+	}
+
+	require.NoError(t, quick.Check(property, quickConfig))
+}
+
+func TestDailyProrated_SplitsAtMonthBoundary(t *testing.T) {
+	property := func(s scenario, cut uint8) bool {
+		// разрез по первому числу месяца внутри периода не меняет сумму
+		mid := monthStart(s.From).AddDate(0, int(cut%24)+1, 0)
+		if !mid.Before(s.To) {
+			return true
+		}
+
+		_, total := DailyProrated(&s.Sub, s.Monthly, s.From, s.To)
+		_, left := DailyProrated(&s.Sub, s.Monthly, s.From, mid.AddDate(0, 0, -1))
+		_, right := DailyProrated(&s.Sub, s.Monthly, mid, s.To)
+		return total == left+right
+	}
+
+	require.NoError(t, quick.Check(property, quickConfig))
+}
+
+func TestChargeEvents_MatchesDayModel(t *testing.T) {
+	property := func(s scenario) bool {
+		units, amount := ChargeEvents(&s.Sub, s.Monthly, s.From, s.To)
+		return units == chargesModel(s) && amount == s.Monthly*units
+	}
+
+	require.NoError(t, quick.Check(property, quickConfig))
+}
+
+func TestChargeEvents_Additive(t *testing.T) {
+	property := func(s scenario, cut uint16) bool {
+		// любой разрез периода делит списания без потерь и повторов
+		mid := s.From.AddDate(0, 0, int(cut)%(daysBetween(s.From, s.To)+1))
+
+		_, total := ChargeEvents(&s.Sub, s.Monthly, s.From, s.To)
+		_, left := ChargeEvents(&s.Sub, s.Monthly, s.From, mid)
+		_, right := ChargeEvents(&s.Sub, s.Monthly, mid.AddDate(0, 0, 1), s.To)
+		return total == left+right
+	}
+
+	require.NoError(t, quick.Check(property, quickConfig))
+}
+
+func FuzzModes(f *testing.F) {
+	f.Add(15, 0, 0, 89, 3100, false)
+	f.Add(30, 29, 0, 365, 999, true)    // 31 января -> 1 марта
+	f.Add(1520, 365, 1490, 60, 1, true) // 29 февраля 2024
+	f.Add(0, 0, 0, 0, 100, true)
+
+	f.Fuzz(func(t *testing.T, startDay, length, fromDay, periodLength, monthly int, hasEnd bool) {
+		if startDay < 0 || length < 0 || fromDay < 0 || periodLength < 0 || monthly < 0 ||
+			startDay > 20000 || length > 5000 || fromDay > 20000 || periodLength > 5000 || monthly > 1000000 {
+			t.Skip()
+		}
+		s := newScenario(startDay, length, fromDay, periodLength, monthly, hasEnd)
+
+		units, amount := WholeMonths(&s.Sub, s.Monthly, s.From, s.To)
+		if sqlSelected(s) {
+			assert.Equal(t, s.Monthly*units, amount, "whole months")
+		} else {
+			assert.Zero(t, amount, "whole months outside period")
+		}
+
+		units, amount = DailyProrated(&s.Sub, s.Monthly, s.From, s.To)
+		months, exact := dailyModel(s)
+		assert.Equal(t, months, units, "daily months")
+		assert.InDelta(t, exact, float64(amount), 0.5*float64(months)+1e-6, "daily amount")
+
+		units, _ = ChargeEvents(&s.Sub, s.Monthly, s.From, s.To)
+		assert.Equal(t, chargesModel(s), units, "charges")
+	})
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Периоды оплаты подписки. Цена подписки остается ежемесячной, а списание
+// раз в период стоит цену, умноженную на число месяцев периода.
+const (
+	BillingMonthly    = "monthly"
+	BillingQuarterly  = "quarterly"
+	BillingSemiannual = "semiannual"
+	BillingYearly     = "yearly"
+)
+
+// billingMonths - число месяцев в периоде оплаты
+var billingMonths = map[string]int{
+	"":                1,
+	BillingMonthly:    1,
+	BillingQuarterly:  3,
+	BillingSemiannual: 6,
+	BillingYearly:     12,
+}
+
+// ValidBillingPeriod сообщает, известен ли период оплаты; пустой означает ежемесячный
+func ValidBillingPeriod(period string) bool {
+	_, ok := billingMonths[period]
+	return ok
+}
+
+// PriceChange - запланированное изменение ежемесячной цены с месяца EffectiveFrom
+type PriceChange struct {
+	EffectiveFrom time.Time `json:"effective_from"`
+	Price         int       `json:"price"`
+}
+
+// BillingMonths - число месяцев между списаниями
+func (s *Subscription) BillingMonths() int {
+	if months, ok := billingMonths[s.BillingPeriod]; ok {
+		return months
+	}
+	return 1
+}
+
+// PriceOn - ежемесячная цена, действующая в день date: последнее запланированное
+// изменение, месяц которого уже наступил, или текущая цена подписки
+func (s *Subscription) PriceOn(date time.Time) int {
+	price := s.Price
+	for _, change := range s.PriceChanges {
+		if monthsBetween(change.EffectiveFrom, date) < 0 {
+			break
+		}
+		price = change.Price
+	}
+	return price
+}
+
+// ChargeAmount - сумма списания в день date: цена на эту дату за весь период оплаты.
+// С userID берется доля пользователя, пересчитанная от этой цены по правилу деления.
+func (s *Subscription) ChargeAmount(date time.Time, userID *uuid.UUID) int {
+	priced := *s
+	priced.Price = s.PriceOn(date)
+
+	amount := priced.Price
+	if userID != nil {
+		if s.Split != nil && priced.Price != s.Price {
+			split := Split{Mode: s.Split.Mode, Members: slices.Clone(s.Split.Members)}
+			split.Apply(priced.Price)
+			priced.Split = &split
+		}
+		amount = priced.ShareOf(*userID)
+	}
+
+	return amount * s.BillingMonths()
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Периоды бюджета
+const (
+	BudgetMonthly = "monthly"
+	BudgetYearly  = "yearly"
+)
+
+// Виды оповещений: порог будет превышен к концу периода или уже превышен
+const (
+	AlertProjected = "projected"
+	AlertReached   = "reached"
+)
+
+// Budget - лимит расходов за месяц или год. Область задается пользователем,
+// категорией, сервисом или их сочетанием; пустые поля не ограничивают выборку.
+type Budget struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	ServiceID  *uuid.UUID `json:"service_id,omitempty"`
+	Period     string     `json:"period"`
+	Amount     int        `json:"amount"`
+	Thresholds []int      `json:"thresholds"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// PeriodBounds возвращает первый и последний день периода бюджета, в который попадает now
+func (b *Budget) PeriodBounds(now time.Time) (time.Time, time.Time) {
+	if b.Period == BudgetYearly {
+		start := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, -1)
+	}
+
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1)
+}
+
+// Filter - выборка подписок, которые расходуют бюджет в периоде [from, to]
+func (b *Budget) Filter(from, to time.Time) SubscriptionFilter {
+	return SubscriptionFilter{
+		UserID:      b.UserID,
+		CategoryID:  b.CategoryID,
+		ServiceID:   b.ServiceID,
+		StartPeriod: &from,
+		EndPeriod:   &to,
+	}
+}
+
+// BudgetStatus - расход по бюджету: Spent - списания с начала периода по сегодня,
+// Projected - до конца периода, если действующие подписки не отменят
+type BudgetStatus struct {
+	Budget      Budget    `json:"budget"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Spent       int       `json:"spent"`
+	Projected   int       `json:"projected"`
+}
+
+// Percent - доля суммы от лимита в процентах
+func (s *BudgetStatus) Percent(sum int) float64 {
+	return float64(sum) * 100 / float64(s.Budget.Amount)
+}
+
+// BudgetAlert - оповещение о пересечении порога, создается один раз
+// на бюджет, период, порог и вид
+type BudgetAlert struct {
+	ID          uuid.UUID `json:"id"`
+	BudgetID    uuid.UUID `json:"budget_id"`
+	PeriodStart time.Time `json:"period_start"`
+	Threshold   int       `json:"threshold"`
+	Kind        string    `json:"kind"`
+	Amount      int       `json:"amount"`
+	Spent       int       `json:"spent"`
+	Projected   int       `json:"projected"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CandidatePending   = "pending"
+	CandidateAccepted  = "accepted"
+	CandidateDismissed = "dismissed"
+)
+
+// SubscriptionCandidate - подписка, предложенная по банковской выписке
+type SubscriptionCandidate struct {
+	ID             uuid.UUID  `json:"id"`
+	UserID         uuid.UUID  `json:"user_id"`
+	ServiceName    string     `json:"service_name"`
+	Price          int        `json:"price"`
+	StartDate      time.Time  `json:"start_date"`
+	EndDate        *time.Time `json:"end_date,omitempty"`
+	Occurrences    int        `json:"occurrences"`
+	Confidence     float64    `json:"confidence"`
+	Status         string     `json:"status"`
+	SubscriptionID *uuid.UUID `json:"subscription_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ToSubscription собирает подписку из предложения
+func (c *SubscriptionCandidate) ToSubscription() *Subscription {
+	return &Subscription{
+		ServiceName: c.ServiceName,
+		Price:       c.Price,
+		UserID:      c.UserID,
+		StartDate:   c.StartDate,
+		EndDate:     c.EndDate,
+	}
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// CatalogEntry - сервис из каталога с каноническим названием и синонимами
+type CatalogEntry struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Aliases      []string  `json:"aliases"`
+	Category     string    `json:"category"`
+	Website      string    `json:"website"`
+	DefaultPrice *int      `json:"default_price,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SearchKeys - нормализованные название и синонимы без повторов
+func (e *CatalogEntry) SearchKeys() []string {
+	seen := map[string]bool{}
+	keys := []string{}
+
+	for _, name := range append([]string{e.Name}, e.Aliases...) {
+		key := NormalizeServiceName(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// NormalizeServiceName приводит название к ключу поиска:
+// "Yandex.Plus ", "yandex plus" и "YANDEX-PLUS" дают "yandex plus"
+func NormalizeServiceName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
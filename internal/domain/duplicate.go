@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Duplicate - подписки одного пользователя на один сервис, действующие в одни и те же месяцы.
+// Wasted - переплата по сегодняшний месяц: в каждом месяце пересечения все подписки,
+// кроме самой дорогой, считаются лишними.
+type Duplicate struct {
+	UserID        uuid.UUID      `json:"user_id"`
+	ServiceName   string         `json:"service_name"`
+	ServiceID     *uuid.UUID     `json:"service_id,omitempty"`
+	Subscriptions []Subscription `json:"subscriptions"`
+	From          time.Time      `json:"from"`
+	Months        int            `json:"months"`
+	Wasted        int            `json:"wasted"`
+	Ongoing       bool           `json:"ongoing"`
+}
+
+// DuplicateKey - ключ, по которому подписки пользователя считаются одним сервисом:
+// сервис каталога, а для подписок вне каталога - нормализованное название
+func (s *Subscription) DuplicateKey() string {
+	if s.ServiceID != nil {
+		return s.UserID.String() + "/" + s.ServiceID.String()
+	}
+	return s.UserID.String() + "/" + NormalizeServiceName(s.ServiceName)
+}
+
+// ActiveIn сообщает, есть ли у подписки списание в месяце month
+func (s *Subscription) ActiveIn(month time.Time) bool {
+	if monthsBetween(s.StartDate, month) < 0 {
+		return false
+	}
+	return s.EndDate == nil || monthsBetween(month, *s.EndDate) >= 0
+}
+
+// Overlaps сообщает, пересекаются ли подписки хотя бы в одном месяце
+func (s *Subscription) Overlaps(other *Subscription) bool {
+	if s.EndDate != nil && monthsBetween(*s.EndDate, other.StartDate) > 0 {
+		return false
+	}
+	if other.EndDate != nil && monthsBetween(*other.EndDate, s.StartDate) > 0 {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,12 @@
+package domain
+
+import "errors"
+
+// ErrNotFound возвращается репозиториями, когда запись не найдена
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict возвращается репозиториями при нарушении уникальности
+var ErrConflict = errors.New("already exists")
+
+// ErrReferenceNotFound возвращается, когда запись ссылается на несуществующую связанную запись
+var ErrReferenceNotFound = errors.New("referenced record not found")
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType - вид события outbox
+type EventType string
+
+const (
+	EventSubscriptionCreated    EventType = "subscription.created"
+	EventSubscriptionUpdated    EventType = "subscription.updated"
+	EventSubscriptionDeleted    EventType = "subscription.deleted"
+	EventSubscriptionEndingSoon EventType = "subscription.ending_soon"
+	EventBudgetThresholdCrossed EventType = "budget.threshold_crossed"
+)
+
+// EventTypes - все виды событий, на которые можно подписаться
+var EventTypes = []EventType{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionDeleted,
+	EventSubscriptionEndingSoon,
+	EventBudgetThresholdCrossed,
+}
+
+// Event - событие из outbox. ID растет в порядке записи событий.
+// DedupKey не дает записать одно и то же событие дважды (например, ending_soon
+// при каждой проверке); пустой ключ не проверяется. У событий бюджета SubscriptionID -
+// id бюджета: по нему события упорядочиваются в релее и брокере.
+type Event struct {
+	ID             int64           `json:"id"`
+	Type           EventType       `json:"type"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	Data           json.RawMessage `json:"data"`
+	DedupKey       string          `json:"-"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// NewSubscriptionEvent - событие с подпиской в данных
+func NewSubscriptionEvent(eventType EventType, sub *Subscription) (Event, error) {
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{Type: eventType, SubscriptionID: sub.ID, Data: data}, nil
+}
+
+// BudgetAlertData - данные события budget.threshold_crossed: оповещение вместе с
+// названием и пользователем бюджета, по которому поток фильтрует события
+type BudgetAlertData struct {
+	BudgetAlert
+	BudgetName string     `json:"budget_name"`
+	UserID     *uuid.UUID `json:"user_id,omitempty"`
+}
+
+// NewBudgetAlertEvent - событие о пересечении порога бюджета b
+func NewBudgetAlertEvent(b *Budget, alert *BudgetAlert) (Event, error) {
+	data, err := json.Marshal(BudgetAlertData{BudgetAlert: *alert, BudgetName: b.Name, UserID: b.UserID})
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{Type: EventBudgetThresholdCrossed, SubscriptionID: b.ID, Data: data}, nil
+}
+
+// PendingPublication - событие, которое издатель еще не опубликовал; Attempts - число
+// неудачных попыток
+type PendingPublication struct {
+	Event    Event
+	Attempts int
+}
+
+// PublicationFailure - исход неудачной публикации. NextAttemptAt == nil - попытки
+// исчерпаны, событие получает статус dead и больше не публикуется.
+type PublicationFailure struct {
+	Attempts      int
+	Error         string
+	NextAttemptAt *time.Time
+}
@@ -0,0 +1,8 @@
+package domain
+
+// Readiness - результат проверки готовности сервиса принимать трафик.
+// Checks - итог каждой проверки: "ok" или текст ошибки.
+type Readiness struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
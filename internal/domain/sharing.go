@@ -0,0 +1,69 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Способы деления стоимости совместной подписки
+const (
+	SplitEqual      = "equal"
+	SplitPercentage = "percentage"
+	SplitFixed      = "fixed"
+)
+
+// Split - деление стоимости подписки между владельцем и участниками.
+// Владелец (Subscription.UserID) платит остаток цены после долей участников.
+type Split struct {
+	Mode    string   `json:"mode"`
+	Members []Member `json:"members"`
+}
+
+// Member - участник совместной подписки. Share - процент для percentage или
+// сумма для fixed, Amount - ежемесячная доля участника
+type Member struct {
+	UserID uuid.UUID `json:"user_id"`
+	Share  int       `json:"share"`
+	Amount int       `json:"amount"`
+}
+
+// Shared сообщает, делится ли стоимость подписки
+func (s *Split) Shared() bool {
+	return s != nil && len(s.Members) > 0
+}
+
+// Apply пересчитывает доли участников из цены подписки. Доли округляются вниз,
+// остаток от округления достается владельцу.
+func (s *Split) Apply(price int) {
+	for i := range s.Members {
+		m := &s.Members[i]
+		switch s.Mode {
+		case SplitEqual:
+			m.Amount = price / (len(s.Members) + 1)
+		case SplitPercentage:
+			m.Amount = price * m.Share / 100
+		case SplitFixed:
+			m.Amount = m.Share
+		}
+	}
+}
+
+// ShareOf - ежемесячная доля пользователя в подписке: для владельца остаток цены,
+// для участника его сумма, для остальных 0
+func (s *Subscription) ShareOf(userID uuid.UUID) int {
+	if userID == s.UserID {
+		owner := s.Price
+		if s.Split != nil {
+			for _, m := range s.Split.Members {
+				owner -= m.Amount
+			}
+		}
+		return owner
+	}
+
+	if s.Split != nil {
+		for _, m := range s.Split.Members {
+			if m.UserID == userID {
+				return m.Amount
+			}
+		}
+	}
+	return 0
+}
@@ -7,21 +7,181 @@ import (
 )
 
 type Subscription struct {
-	ID          uuid.UUID  `json:"id"`
-	ServiceName string     `json:"service_name"`
-	Price       int        `json:"price"`
-	UserID      uuid.UUID  `json:"user_id"`
-	StartDate   time.Time  `json:"start_date"`
-	EndDate     *time.Time `json:"end_date,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID            uuid.UUID     `json:"id"`
+	ServiceName   string        `json:"service_name"`
+	ServiceID     *uuid.UUID    `json:"service_id,omitempty"`
+	CategoryID    *uuid.UUID    `json:"category_id,omitempty"`
+	Tags          []string      `json:"tags,omitempty"`
+	Split         *Split        `json:"split,omitempty"`
+	Price         int           `json:"price"`
+	BillingPeriod string        `json:"billing_period,omitempty"`
+	PriceChanges  []PriceChange `json:"price_changes,omitempty"`
+	UserID        uuid.UUID     `json:"user_id"`
+	StartDate     time.Time     `json:"start_date"`
+	EndDate       *time.Time    `json:"end_date,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// NextChargeDate возвращает ближайшую дату списания не раньше from.
+// Подписки оплачиваются раз в период оплаты в день start_date, последнее списание
+// приходится не позже месяца end_date. ok=false, если списаний больше не будет.
+func (s *Subscription) NextChargeDate(from time.Time) (time.Time, bool) {
+	dates := s.ChargeDates(from, time.Time{})
+	if len(dates) == 0 {
+		return time.Time{}, false
+	}
+	return dates[0], true
+}
+
+// ChargeDates возвращает даты списаний в полуинтервале [from, to).
+// Нулевой to означает одно ближайшее списание.
+func (s *Subscription) ChargeDates(from, to time.Time) []time.Time {
+	var dates []time.Time
+
+	period := s.BillingMonths()
+	k := monthsBetween(s.StartDate, from) - 1
+	if k < 0 {
+		k = 0
+	}
+	k -= k % period
+
+	for ; ; k += period {
+		date := addMonths(s.StartDate, k)
+		if s.EndDate != nil && monthsBetween(*s.EndDate, date) > 0 {
+			break
+		}
+		if !to.IsZero() && !date.Before(to) {
+			break
+		}
+		if date.Before(from) {
+			continue
+		}
+
+		dates = append(dates, date)
+		if to.IsZero() {
+			break
+		}
+	}
+
+	return dates
+}
+
+// LastChargeDate возвращает дату последнего списания; ok=false для бессрочной подписки.
+func (s *Subscription) LastChargeDate() (time.Time, bool) {
+	if s.EndDate == nil {
+		return time.Time{}, false
+	}
+	months := monthsBetween(s.StartDate, *s.EndDate)
+	return addMonths(s.StartDate, months-months%s.BillingMonths()), true
+}
+
+// monthsBetween - количество календарных месяцев от a до b
+func monthsBetween(a, b time.Time) int {
+	return (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+}
+
+// addMonths сдвигает дату на n месяцев, прижимая день к концу месяца
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
 }
 
 type SubscriptionFilter struct {
 	UserID      *uuid.UUID
 	ServiceName *string
+	ServiceID   *uuid.UUID
+	Category    *string
+	CategoryID  *uuid.UUID
+	Tags        []string
+	TagMatch    string
 	StartPeriod *time.Time
 	EndPeriod   *time.Time
+	// CostMode - способ расчета стоимости за период; пустой - CostModeWholeMonths
+	CostMode CostMode
+}
+
+// SubscriptionStats - подписки, действующие в текущем месяце, и их суммарная месячная цена
+type SubscriptionStats struct {
+	Active       int `json:"active"`
+	MonthlySpend int `json:"monthly_spend"`
+}
+
+// UpcomingCharge - предстоящее списание по подписке
+type UpcomingCharge struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	ServiceName    string    `json:"service_name"`
+	UserID         uuid.UUID `json:"user_id"`
+	ChargeDate     time.Time `json:"charge_date"`
+	Amount         int       `json:"amount"`
+}
+
+// UpcomingCharges - списания за окно [From, To)
+type UpcomingCharges struct {
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	Charges []UpcomingCharge `json:"charges"`
+	Total   int              `json:"total"`
+}
+
+// ForecastMonth - ожидаемые списания за календарный месяц
+type ForecastMonth struct {
+	Month   time.Time `json:"month"`
+	Charges int       `json:"charges"`
+	Total   int       `json:"total"`
+}
+
+// Forecast - прогноз расходов помесячно с месяца From по месяц To включительно
+type Forecast struct {
+	From   time.Time       `json:"from"`
+	To     time.Time       `json:"to"`
+	Months []ForecastMonth `json:"months"`
+	Total  int             `json:"total"`
+}
+
+// ImportRowError - ошибка в строке импортируемого файла
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// ImportResult - итог импорта подписок
+type ImportResult struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// CostMode - способ расчета стоимости подписки за период
+type CostMode string
+
+const (
+	// CostModeWholeMonths - оплачиваются полные месяцы действия в периоде и неполный последний
+	// целиком: 16 января - 31 марта дает 3 месяца, 7 июля - 6 февраля - 7
+	CostModeWholeMonths CostMode = "whole_months"
+	// CostModeDailyProrated - месяц оплачивается пропорционально дням действия подписки в периоде
+	CostModeDailyProrated CostMode = "daily_prorated"
+	// CostModeChargeEvents - учитываются только списания, даты которых попали в период
+	CostModeChargeEvents CostMode = "charge_events"
+)
+
+// CostLine - стоимость одной подписки за период отчета. Share - ежемесячная цена
+// для отчета (доля пользователя при фильтре по нему), Months - оплаченные месяцы,
+// а в режиме CostModeChargeEvents - число списаний.
+type CostLine struct {
+	Subscription Subscription `json:"subscription"`
+	Share        int          `json:"share"`
+	Months       int          `json:"months"`
+	Cost         int          `json:"cost"`
 }
 
 type Pagination struct {
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Режимы фильтра по тегам: хотя бы один из тегов или все сразу
+const (
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// Группировки отчета о стоимости
+const (
+	GroupByCategory = "category"
+	GroupByTag      = "tag"
+)
+
+type Category struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Tag - свободная метка подписки; Subscriptions - число подписок с этим тегом
+type Tag struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	Subscriptions int       `json:"subscriptions"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SubscriptionTag - связь подписки с тегом
+type SubscriptionTag struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	TagID          uuid.UUID `json:"tag_id"`
+}
+
+// CostGroup - стоимость подписок одной категории или тега за период.
+// Key == nil - подписки без категории или без тегов.
+type CostGroup struct {
+	Key   *string `json:"key"`
+	Total int     `json:"total"`
+}
+
+// NormalizeTag приводит тег к виду, в котором он хранится: "  Work  Tools" -> "work tools"
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+// NormalizeTags нормализует теги, убирает пустые и повторы и сортирует их.
+// nil остается nil: так запрос на изменение отличает "теги не переданы" от "очистить теги".
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User - владелец подписок, бюджетов и календаря
+type User struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Email     *string   `json:"email,omitempty"`
+	Currency  string    `json:"currency"`
+	Timezone  string    `json:"timezone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserSummary - сводка по подпискам пользователя. MonthlySpend - расход за текущий
+// месяц с учетом долей в совместных подписках, как в TotalCost.
+type UserSummary struct {
+	User                User           `json:"user"`
+	Subscriptions       int            `json:"subscriptions"`
+	ActiveSubscriptions []Subscription `json:"active_subscriptions"`
+	Month               time.Time      `json:"month"`
+	MonthlySpend        int            `json:"monthly_spend"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook - адрес, на который отправляются события выбранных видов.
+// Secret подписывает тело запроса; API его не возвращает, он нужен только в резервной копии.
+type Webhook struct {
+	ID        uuid.UUID   `json:"id"`
+	URL       string      `json:"url"`
+	Secret    string      `json:"secret"`
+	Events    []EventType `json:"events"`
+	Active    bool        `json:"active"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// Статусы доставки: ждет отправки или повтора, доставлена, попытки исчерпаны
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery - отправка одного события на один webhook
+type WebhookDelivery struct {
+	ID            int64      `json:"id"`
+	WebhookID     uuid.UUID  `json:"webhook_id"`
+	EventID       int64      `json:"event_id"`
+	EventType     EventType  `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	ResponseCode  *int       `json:"response_code,omitempty"`
+	LastError     *string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+}
+
+// PendingDelivery - доставка, взятая в работу, с адресом, секретом и событием
+type PendingDelivery struct {
+	ID       int64
+	Attempts int
+	URL      string
+	Secret   string
+	Event    Event
+}
+
+// DeliveryResult - исход попытки доставки. NextAttemptAt == nil - повторов не будет.
+type DeliveryResult struct {
+	Delivered     bool
+	ResponseCode  *int
+	Error         *string
+	NextAttemptAt *time.Time
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// RowWriter пишет табличные данные построчно. Значения - string или int.
+type RowWriter interface {
+	WriteRow(values ...any) error
+	// Close дописывает хвост файла; сам io.Writer не закрывается
+	Close() error
+	ContentType() string
+}
+
+// NewWriter создает writer для format (csv или xlsx)
+func NewWriter(format string, w io.Writer, sheet string) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(w), nil
+	case FormatXLSX:
+		return NewXLSXWriter(w, sheet)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+type csvWriter struct {
+	w    *csv.Writer
+	rows int
+}
+
+func NewCSVWriter(w io.Writer) RowWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteRow(values ...any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			record[i] = v
+		case int:
+			record[i] = strconv.Itoa(v)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+
+	// периодически сбрасываем буфер, чтобы клиент получал данные по мере чтения
+	c.rows++
+	if c.rows%1000 == 0 {
+		c.w.Flush()
+		return c.w.Error()
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
@@ -0,0 +1,61 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewXLSXWriter(&buf, "Subscriptions")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow("service_name", "price"))
+	require.NoError(t, w.WriteRow("Tom & Jerry <Kids>", 400))
+	require.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(body)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Subscriptions"`)
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr"><is><t>service_name</t></is></c>`)
+	assert.Contains(t, sheet, `<t>Tom &amp; Jerry &lt;Kids&gt;</t>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>400</v></c>`)
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(FormatCSV, &buf, "")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow("service_name", "price"))
+	require.NoError(t, w.WriteRow("Yandex, Plus", 400))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "service_name,price\n\"Yandex, Plus\",400\n", buf.String())
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "BA", columnName(52))
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type BudgetHandler struct {
+	service *service.BudgetService
+	logger  *zap.Logger
+}
+
+func NewBudgetHandler(service *service.BudgetService, logger *zap.Logger) *BudgetHandler {
+	return &BudgetHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *BudgetHandler) RegisterRoutes(api *gin.RouterGroup) {
+	budgets := api.Group("/budgets")
+	{
+		budgets.POST("", h.create)
+		budgets.GET("", h.list)
+		budgets.POST("/evaluate", h.evaluate)
+		budgets.GET("/:id", h.getByID)
+		budgets.PUT("/:id", h.update)
+		budgets.DELETE("/:id", h.delete)
+		budgets.GET("/:id/status", h.status)
+		budgets.GET("/:id/alerts", h.alerts)
+	}
+}
+
+func optionalIDString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+func toBudgetResponse(b *domain.Budget) BudgetResponse {
+	return BudgetResponse{
+		ID:         b.ID.String(),
+		Name:       b.Name,
+		UserID:     optionalIDString(b.UserID),
+		CategoryID: optionalIDString(b.CategoryID),
+		ServiceID:  optionalIDString(b.ServiceID),
+		Period:     b.Period,
+		Amount:     b.Amount,
+		Thresholds: b.Thresholds,
+		CreatedAt:  b.CreatedAt,
+		UpdatedAt:  b.UpdatedAt,
+	}
+}
+
+func toBudgetAlertResponse(a *domain.BudgetAlert) BudgetAlertResponse {
+	return BudgetAlertResponse{
+		ID:          a.ID.String(),
+		BudgetID:    a.BudgetID.String(),
+		PeriodStart: a.PeriodStart.Format("2006-01-02"),
+		Threshold:   a.Threshold,
+		Kind:        a.Kind,
+		Amount:      a.Amount,
+		Spent:       a.Spent,
+		Projected:   a.Projected,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+func (r *BudgetRequest) toBudget() (*domain.Budget, error) {
+	b := &domain.Budget{
+		Name:       r.Name,
+		Period:     r.Period,
+		Amount:     r.Amount,
+		Thresholds: r.Thresholds,
+	}
+
+	var err error
+	if b.UserID, err = parseOptionalID(r.UserID, "user_id"); err != nil {
+		return nil, err
+	}
+	if b.CategoryID, err = parseOptionalID(r.CategoryID, "category_id"); err != nil {
+		return nil, err
+	}
+	if b.ServiceID, err = parseOptionalID(r.ServiceID, "service_id"); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// @Summary Create budget
+// @Description Monthly or yearly spending limit for a user, category, service or their combination.
+// @Description Alerts are raised when spend or its projection crosses the thresholds (percent of amount)
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param budget body BudgetRequest true "Budget data"
+// @Success 201 {object} BudgetResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/budgets [post]
+func (h *BudgetHandler) create(c *gin.Context) {
+	var req BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	budget, err := req.toBudget()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	if err := h.service.Create(c.Request.Context(), budget); err != nil {
+		h.budgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toBudgetResponse(budget))
+}
+
+// @Summary List budgets
+// @Tags budgets
+// @Produce json
+// @Param user_id query string false "Filter by user ID"
+// @Success 200 {array} BudgetResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/budgets [get]
+func (h *BudgetHandler) list(c *gin.Context) {
+	userID, err := parseOptionalID(c.Query("user_id"), "user_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	budgets, err := h.service.List(c.Request.Context(), userID)
+	if err != nil {
+		h.budgetError(c, err)
+		return
+	}
+
+	resp := make([]BudgetResponse, len(budgets))
+	for i := range budgets {
+		resp[i] = toBudgetResponse(&budgets[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get budget by ID
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {object} BudgetResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/budgets/{id} [get]
+func (h *BudgetHandler) getByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	budget, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.budgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toBudgetResponse(budget))
+}
+
+// @Summary Update budget
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param budget body BudgetRequest true "Budget data"
+// @Success 200 {object} BudgetResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/budgets/{id} [put]
+func (h *BudgetHandler) update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	var req BudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	budget, err := req.toBudget()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+	budget.ID = id
+
+	if err := h.service.Update(c.Request.Context(), budget); err != nil {
+		h.budgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toBudgetResponse(budget))
+}
+
+// @Summary Delete budget
+// @Tags budgets
+// @Param id path string true "Budget ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/budgets/{id} [delete]
+func (h *BudgetHandler) delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		h.budgetError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Budget status
+// @Description Spend in the current period so far and projected to the period end
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Param X-Timezone header string false "IANA timezone, e.g. Europe/Moscow"
+// @Success 200 {object} BudgetStatusResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/budgets/{id}/status [get]
+func (h *BudgetHandler) status(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	status, err := h.service.StatusByID(c.Request.Context(), id)
+	if err != nil {
+		h.budgetError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, BudgetStatusResponse{
+		Budget:           toBudgetResponse(&status.Budget),
+		PeriodStart:      status.PeriodStart.Format("2006-01-02"),
+		PeriodEnd:        status.PeriodEnd.Format("2006-01-02"),
+		Spent:            status.Spent,
+		Projected:        status.Projected,
+		SpentPercent:     status.Percent(status.Spent),
+		ProjectedPercent: status.Percent(status.Projected),
+	})
+}
+
+// @Summary Budget alerts
+// @Description Threshold crossings recorded for the budget, newest first
+// @Tags budgets
+// @Produce json
+// @Param id path string true "Budget ID"
+// @Success 200 {array} BudgetAlertResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/budgets/{id}/alerts [get]
+func (h *BudgetHandler) alerts(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	alerts, err := h.service.Alerts(c.Request.Context(), id)
+	if err != nil {
+		h.budgetError(c, err)
+		return
+	}
+
+	resp := make([]BudgetAlertResponse, len(alerts))
+	for i := range alerts {
+		resp[i] = toBudgetAlertResponse(&alerts[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Evaluate budgets
+// @Description Checks all budgets now instead of waiting for the background run and returns new alerts
+// @Tags budgets
+// @Produce json
+// @Success 200 {array} BudgetAlertResponse
+// @Router /api/v1/budgets/evaluate [post]
+func (h *BudgetHandler) evaluate(c *gin.Context) {
+	alerts, err := h.service.Evaluate(c.Request.Context())
+	if err != nil {
+		h.budgetError(c, err)
+		return
+	}
+
+	resp := make([]BudgetAlertResponse, len(alerts))
+	for i := range alerts {
+		resp[i] = toBudgetAlertResponse(&alerts[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *BudgetHandler) budgetError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(c, "budget not found"))
+	case errors.Is(err, service.ErrInvalidBudget), errors.Is(err, domain.ErrReferenceNotFound):
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+	default:
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to process budget", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+	}
+}
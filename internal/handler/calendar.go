@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/calendar"
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type CalendarHandler struct {
+	service *service.CalendarService
+	logger  *zap.Logger
+}
+
+func NewCalendarHandler(service *service.CalendarService, logger *zap.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *CalendarHandler) RegisterRoutes(api *gin.RouterGroup) {
+	users := api.Group("/users")
+	{
+		users.POST("/:id/calendar-token", h.issueToken)
+		users.GET("/:id/renewals.ics", h.feed)
+	}
+}
+
+// @Summary Issue calendar feed token
+// @Description Creates a secret feed URL for the user, previous URL stops working
+// @Tags calendar
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 201 {object} CalendarTokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/{id}/calendar-token [post]
+func (h *CalendarHandler) issueToken(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid user_id"))
+		return
+	}
+
+	token, err := h.service.IssueToken(c.Request.Context(), userID)
+	if errors.Is(err, domain.ErrReferenceNotFound) {
+		c.JSON(http.StatusNotFound, errorResponse(c, "user not found"))
+		return
+	}
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to issue calendar token", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	feedURL := url.URL{
+		Scheme:   scheme,
+		Host:     c.Request.Host,
+		Path:     "/api/v1/users/" + userID.String() + "/renewals.ics",
+		RawQuery: url.Values{"token": {token}}.Encode(),
+	}
+
+	c.JSON(http.StatusCreated, CalendarTokenResponse{Token: token, URL: feedURL.String()})
+}
+
+// @Summary Renewals calendar feed
+// @Description iCalendar feed with monthly charges and end dates of the user's subscriptions
+// @Tags calendar
+// @Produce text/calendar
+// @Param id path string true "User ID"
+// @Param token query string true "Feed token"
+// @Success 200 {string} string
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/{id}/renewals.ics [get]
+func (h *CalendarHandler) feed(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid user_id"))
+		return
+	}
+
+	subs, err := h.service.Feed(c.Request.Context(), userID, c.Query("token"))
+	if errors.Is(err, service.ErrInvalidCalendarToken) {
+		c.JSON(http.StatusNotFound, errorResponse(c, "calendar not found"))
+		return
+	}
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to build calendar feed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := calendar.Render(&buf, subs, time.Now()); err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to render calendar feed", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
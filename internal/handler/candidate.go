@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/internal/importer"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type CandidateHandler struct {
+	service *service.CandidateService
+	logger  *zap.Logger
+}
+
+func NewCandidateHandler(service *service.CandidateService, logger *zap.Logger) *CandidateHandler {
+	return &CandidateHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *CandidateHandler) RegisterRoutes(api *gin.RouterGroup) {
+	users := api.Group("/users")
+	{
+		users.POST("/:id/bank-statements", h.analyze)
+		users.GET("/:id/subscription-candidates", h.list)
+	}
+
+	candidates := api.Group("/subscription-candidates")
+	{
+		candidates.POST("/:id/accept", h.accept)
+		candidates.POST("/:id/dismiss", h.dismiss)
+	}
+}
+
+func toCandidateResponse(c *domain.SubscriptionCandidate) CandidateResponse {
+	resp := CandidateResponse{
+		ID:          c.ID.String(),
+		UserID:      c.UserID.String(),
+		ServiceName: c.ServiceName,
+		Price:       c.Price,
+		StartDate:   c.StartDate.Format("2006-01-02"),
+		Occurrences: c.Occurrences,
+		Confidence:  c.Confidence,
+		Status:      c.Status,
+		CreatedAt:   c.CreatedAt,
+	}
+
+	if c.EndDate != nil {
+		endStr := c.EndDate.Format("2006-01-02")
+		resp.EndDate = &endStr
+	}
+
+	if c.SubscriptionID != nil {
+		subID := c.SubscriptionID.String()
+		resp.SubscriptionID = &subID
+	}
+
+	return resp
+}
+
+// @Summary Detect subscriptions in a bank statement
+// @Description Parses a statement (CSV, OFX or QIF), detects monthly recurring charges and
+// @Description replaces the user's pending candidates with the findings
+// @Tags candidates
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "User ID"
+// @Param format query string false "Statement format" Enums(csv, ofx, qif) default(csv)
+// @Param delimiter query string false "CSV field delimiter" default(,)
+// @Param date_column query string false "CSV header of date column" default(date)
+// @Param amount_column query string false "CSV header of amount column" default(amount)
+// @Param description_column query string false "CSV header of description column" default(description)
+// @Param date_layout query string false "CSV date layout in Go notation, e.g. 02.01.2006"
+// @Success 201 {array} CandidateResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/{id}/bank-statements [post]
+func (h *CandidateHandler) analyze(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid user_id"))
+		return
+	}
+
+	var req BankStatementRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	body, err := uploadedFile(c, maxImportSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+	defer body.Close()
+
+	var txs []importer.Transaction
+	switch req.Format {
+	case "ofx":
+		txs, err = importer.ParseOFX(body)
+	case "qif":
+		txs, err = importer.ParseQIF(body)
+	default:
+		mapping := importer.DefaultBankMapping()
+		if req.DateColumn != "" {
+			mapping.Date = req.DateColumn
+		}
+		if req.AmountColumn != "" {
+			mapping.Amount = req.AmountColumn
+		}
+		if req.DescriptionColumn != "" {
+			mapping.Description = req.DescriptionColumn
+		}
+		mapping.DateLayout = req.DateLayout
+
+		delimiter := ','
+		if req.Delimiter != "" {
+			delimiter = rune(req.Delimiter[0])
+		}
+
+		txs, err = importer.ParseBankCSV(body, mapping, delimiter)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	candidates, err := h.service.Analyze(c.Request.Context(), userID, txs)
+	if errors.Is(err, domain.ErrReferenceNotFound) {
+		c.JSON(http.StatusNotFound, errorResponse(c, "user not found"))
+		return
+	}
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to analyze bank statement", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	resp := make([]CandidateResponse, len(candidates))
+	for i, candidate := range candidates {
+		resp[i] = toCandidateResponse(candidate)
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// @Summary List subscription candidates
+// @Tags candidates
+// @Produce json
+// @Param id path string true "User ID"
+// @Param status query string false "Filter by status" Enums(pending, accepted, dismissed)
+// @Success 200 {array} CandidateResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/users/{id}/subscription-candidates [get]
+func (h *CandidateHandler) list(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid user_id"))
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", domain.CandidatePending, domain.CandidateAccepted, domain.CandidateDismissed:
+	default:
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid status"))
+		return
+	}
+
+	candidates, err := h.service.List(c.Request.Context(), userID, status)
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to list candidates", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	resp := make([]CandidateResponse, len(candidates))
+	for i := range candidates {
+		resp[i] = toCandidateResponse(&candidates[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Accept subscription candidate
+// @Description Creates a subscription from the candidate, name and price can be corrected
+// @Tags candidates
+// @Accept json
+// @Produce json
+// @Param id path string true "Candidate ID"
+// @Param overrides body AcceptCandidateRequest false "Corrections"
+// @Success 201 {object} SubscriptionResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/subscription-candidates/{id}/accept [post]
+func (h *CandidateHandler) accept(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	var req AcceptCandidateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+	}
+
+	sub, err := h.service.Accept(c.Request.Context(), id, req.ServiceName, req.Price)
+	if err != nil {
+		h.candidateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toResponse(sub, requestLocation(c)))
+}
+
+// @Summary Dismiss subscription candidate
+// @Tags candidates
+// @Param id path string true "Candidate ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/subscription-candidates/{id}/dismiss [post]
+func (h *CandidateHandler) dismiss(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	if err := h.service.Dismiss(c.Request.Context(), id); err != nil {
+		h.candidateError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CandidateHandler) candidateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(c, "candidate not found"))
+	case errors.Is(err, service.ErrCandidateProcessed), errors.Is(err, service.ErrDuplicateSubscription):
+		c.JSON(http.StatusConflict, errorResponse(c, err.Error()))
+	default:
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to process candidate", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+	}
+}
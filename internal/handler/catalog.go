@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type CatalogHandler struct {
+	service *service.CatalogService
+	logger  *zap.Logger
+}
+
+func NewCatalogHandler(service *service.CatalogService, logger *zap.Logger) *CatalogHandler {
+	return &CatalogHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *CatalogHandler) RegisterRoutes(api *gin.RouterGroup) {
+	services := api.Group("/services")
+	{
+		services.POST("", h.create)
+		services.GET("", h.list)
+		services.GET("/:id", h.getByID)
+		services.PUT("/:id", h.update)
+		services.DELETE("/:id", h.delete)
+	}
+}
+
+func toCatalogResponse(e *domain.CatalogEntry) CatalogEntryResponse {
+	return CatalogEntryResponse{
+		ID:           e.ID.String(),
+		Name:         e.Name,
+		Aliases:      e.Aliases,
+		Category:     e.Category,
+		Website:      e.Website,
+		DefaultPrice: e.DefaultPrice,
+		CreatedAt:    e.CreatedAt,
+		UpdatedAt:    e.UpdatedAt,
+	}
+}
+
+func (r *CatalogEntryRequest) toEntry() *domain.CatalogEntry {
+	return &domain.CatalogEntry{
+		Name:         r.Name,
+		Aliases:      r.Aliases,
+		Category:     r.Category,
+		Website:      r.Website,
+		DefaultPrice: r.DefaultPrice,
+	}
+}
+
+// @Summary Create catalog service
+// @Description Adds a canonical service. Subscriptions whose name matches the name or
+// @Description one of the aliases (ignoring case and punctuation) are linked to it
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body CatalogEntryRequest true "Service data"
+// @Success 201 {object} CatalogEntryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/services [post]
+func (h *CatalogHandler) create(c *gin.Context) {
+	var req CatalogEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	entry := req.toEntry()
+	if err := h.service.Create(c.Request.Context(), entry); err != nil {
+		h.catalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toCatalogResponse(entry))
+}
+
+// @Summary List catalog services
+// @Tags services
+// @Produce json
+// @Param category query string false "Filter by category"
+// @Success 200 {array} CatalogEntryResponse
+// @Router /api/v1/services [get]
+func (h *CatalogHandler) list(c *gin.Context) {
+	entries, err := h.service.List(c.Request.Context(), c.Query("category"))
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to list services", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	resp := make([]CatalogEntryResponse, len(entries))
+	for i := range entries {
+		resp[i] = toCatalogResponse(&entries[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get catalog service by ID
+// @Tags services
+// @Produce json
+// @Param id path string true "Service ID"
+// @Success 200 {object} CatalogEntryResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/services/{id} [get]
+func (h *CatalogHandler) getByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	entry, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.catalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCatalogResponse(entry))
+}
+
+// @Summary Update catalog service
+// @Description Replaces name, aliases and attributes. Existing subscriptions keep their link
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path string true "Service ID"
+// @Param service body CatalogEntryRequest true "Service data"
+// @Success 200 {object} CatalogEntryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/services/{id} [put]
+func (h *CatalogHandler) update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	var req CatalogEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	entry := req.toEntry()
+	entry.ID = id
+	if err := h.service.Update(c.Request.Context(), entry); err != nil {
+		h.catalogError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toCatalogResponse(entry))
+}
+
+// @Summary Delete catalog service
+// @Description Subscriptions linked to the service keep their name and lose the link
+// @Tags services
+// @Param id path string true "Service ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/services/{id} [delete]
+func (h *CatalogHandler) delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		h.catalogError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CatalogHandler) catalogError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(c, "service not found"))
+	case errors.Is(err, service.ErrInvalidCatalogEntry):
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+	case errors.Is(err, service.ErrAliasConflict):
+		c.JSON(http.StatusConflict, errorResponse(c, err.Error()))
+	default:
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to process catalog service", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+	}
+}
@@ -1,44 +1,471 @@
 package handler
 
-import "time"
+import (
+	"errors"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+)
 
 type CreateSubscriptionRequest struct {
-	ServiceName string `json:"service_name" binding:"required" example:"Yandex Plus"`
-	Price       int    `json:"price" binding:"required,min=0" example:"400"`
-	UserID      string `json:"user_id" binding:"required,uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
-	StartDate   string `json:"start_date" binding:"required" example:"07-2025"`
-	EndDate     string `json:"end_date,omitempty" example:"12-2025"`
+	ServiceName string        `json:"service_name" binding:"required_without=ServiceID" example:"Yandex Plus"`
+	ServiceID   string        `json:"service_id,omitempty" binding:"omitempty,uuid" example:"5b1c2f0e-7a3d-4c8e-9f61-2d4b8a0c6e13"`
+	CategoryID  string        `json:"category_id,omitempty" binding:"omitempty,uuid" example:"3f2a9c1d-8b7e-4f60-a5d4-1c2b3a4d5e6f"`
+	Tags        []string      `json:"tags,omitempty" example:"music,family"`
+	Split       *SplitRequest `json:"split,omitempty"`
+	Price       int           `json:"price" binding:"required,min=0" example:"400"`
+	UserID      string        `json:"user_id" binding:"required,uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate   string        `json:"start_date" binding:"required" example:"07-2025"`
+	EndDate     string        `json:"end_date,omitempty" example:"12-2025"`
+	// BillingPeriod - как часто списывается оплата; price остается ценой за месяц
+	BillingPeriod string               `json:"billing_period,omitempty" binding:"omitempty,oneof=monthly quarterly semiannual yearly" example:"yearly"`
+	PriceChanges  []PriceChangeRequest `json:"price_changes,omitempty" binding:"dive"`
 }
 
 type UpdateSubscriptionRequest struct {
-	ServiceName string `json:"service_name" binding:"required"`
-	Price       int    `json:"price" binding:"required,min=0"`
-	StartDate   string `json:"start_date" binding:"required"`
-	EndDate     string `json:"end_date,omitempty"`
+	ServiceName string `json:"service_name" binding:"required_without=ServiceID"`
+	ServiceID   string `json:"service_id,omitempty" binding:"omitempty,uuid"`
+	CategoryID  string `json:"category_id,omitempty" binding:"omitempty,uuid"`
+	// Tags заменяют теги подписки; если поле не передано, теги не меняются
+	Tags []string `json:"tags,omitempty"`
+	// Split заменяет деление стоимости; пустой members убирает его, без поля деление не меняется
+	Split     *SplitRequest `json:"split,omitempty"`
+	Price     int           `json:"price" binding:"required,min=0"`
+	StartDate string        `json:"start_date" binding:"required"`
+	EndDate   string        `json:"end_date,omitempty"`
+	// BillingPeriod без значения не меняется
+	BillingPeriod string `json:"billing_period,omitempty" binding:"omitempty,oneof=monthly quarterly semiannual yearly"`
+	// PriceChanges заменяют запланированные изменения цены; пустой список убирает их, без поля они не меняются
+	PriceChanges []PriceChangeRequest `json:"price_changes,omitempty" binding:"dive"`
+}
+
+// PriceChangeRequest - запланированное изменение цены за месяц с месяца effective_from
+type PriceChangeRequest struct {
+	EffectiveFrom string `json:"effective_from" binding:"required" example:"01-2026"`
+	Price         int    `json:"price" binding:"min=0" example:"500"`
+}
+
+// toPriceChanges разбирает изменения цены; nil остается nil, чтобы при изменении подписки
+// отличать отсутствие поля от пустого списка
+func toPriceChanges(reqs []PriceChangeRequest) ([]domain.PriceChange, error) {
+	if reqs == nil {
+		return nil, nil
+	}
+
+	changes := make([]domain.PriceChange, len(reqs))
+	for i, r := range reqs {
+		from, err := time.Parse("01-2006", r.EffectiveFrom)
+		if err != nil {
+			return nil, errors.New("invalid price_changes effective_from format")
+		}
+		changes[i] = domain.PriceChange{EffectiveFrom: from, Price: r.Price}
+	}
+
+	return changes, nil
+}
+
+// SplitRequest - деление стоимости подписки: владелец (user_id подписки) платит
+// остаток цены, участники - свои доли
+type SplitRequest struct {
+	Mode    string          `json:"mode" binding:"omitempty,oneof=equal percentage fixed" example:"percentage"`
+	Members []MemberRequest `json:"members" binding:"dive"`
+}
+
+// MemberRequest - участник подписки; share - процент для percentage или сумма для fixed
+type MemberRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid" example:"7a1f3c2e-4b5d-4e6f-8a9b-0c1d2e3f4a5b"`
+	Share  int    `json:"share,omitempty" binding:"min=0" example:"30"`
+}
+
+func (r *SplitRequest) toSplit() *domain.Split {
+	if r == nil {
+		return nil
+	}
+
+	split := &domain.Split{Mode: r.Mode, Members: make([]domain.Member, len(r.Members))}
+	for i, m := range r.Members {
+		// формат user_id уже проверен при разборе запроса
+		split.Members[i] = domain.Member{UserID: uuid.MustParse(m.UserID), Share: m.Share}
+	}
+
+	return split
 }
 
 type SubscriptionResponse struct {
-	ID          string    `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
-	ServiceName string    `json:"service_name" example:"Yandex Plus"`
-	Price       int       `json:"price" example:"400"`
-	UserID      string    `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
-	StartDate   string    `json:"start_date" example:"2025-07-01"`
-	EndDate     *string   `json:"end_date,omitempty" example:"2025-12-01"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string         `json:"id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ServiceName string         `json:"service_name" example:"Yandex Plus"`
+	ServiceID   *string        `json:"service_id,omitempty" example:"5b1c2f0e-7a3d-4c8e-9f61-2d4b8a0c6e13"`
+	CategoryID  *string        `json:"category_id,omitempty" example:"3f2a9c1d-8b7e-4f60-a5d4-1c2b3a4d5e6f"`
+	Tags        []string       `json:"tags" example:"music,family"`
+	Split       *SplitResponse `json:"split,omitempty"`
+	Price       int            `json:"price" example:"400"`
+	UserID      string         `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate   string         `json:"start_date" example:"2025-07-01"`
+	EndDate     *string        `json:"end_date,omitempty" example:"2025-12-01"`
+	// BillingPeriod - период оплаты; списание стоит price за каждый месяц периода
+	BillingPeriod string                `json:"billing_period" example:"monthly"`
+	PriceChanges  []PriceChangeResponse `json:"price_changes,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+// PriceChangeResponse - запланированное изменение цены за месяц
+type PriceChangeResponse struct {
+	EffectiveFrom string `json:"effective_from" example:"2026-01-01"`
+	Price         int    `json:"price" example:"500"`
+}
+
+// SplitResponse - деление стоимости; owner_amount - ежемесячная доля владельца
+type SplitResponse struct {
+	Mode        string           `json:"mode" example:"percentage"`
+	OwnerAmount int              `json:"owner_amount" example:"280"`
+	Members     []MemberResponse `json:"members"`
+}
+
+type MemberResponse struct {
+	UserID string `json:"user_id" example:"7a1f3c2e-4b5d-4e6f-8a9b-0c1d2e3f4a5b"`
+	Share  int    `json:"share" example:"30"`
+	Amount int    `json:"amount" example:"120"`
 }
 
 type TotalCostRequest struct {
-	StartPeriod string  `form:"start_period" binding:"required" example:"2025-01-01"`
-	EndPeriod   string  `form:"end_period" binding:"required" example:"2025-12-31"`
+	StartPeriod string   `form:"start_period" binding:"required" example:"2025-01-01"`
+	EndPeriod   string   `form:"end_period" binding:"required" example:"2025-12-31"`
+	UserID      *string  `form:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ServiceName *string  `form:"service_name" example:"Yandex"`
+	ServiceID   *string  `form:"service_id" example:"5b1c2f0e-7a3d-4c8e-9f61-2d4b8a0c6e13"`
+	Category    *string  `form:"category" example:"Streaming"`
+	Tags        []string `form:"tag" example:"music"`
+	TagMatch    string   `form:"tag_match" binding:"omitempty,oneof=any all" example:"any"`
+	GroupBy     string   `form:"group_by" binding:"omitempty,oneof=category tag" example:"category"`
+	Mode        string   `form:"mode" binding:"omitempty,oneof=whole_months daily_prorated charge_events" example:"whole_months"`
+}
+
+// toFilter проверяет период и фильтры отчета о стоимости; границы периода,
+// заданные моментом времени, переводятся в даты часового пояса loc
+func (r *TotalCostRequest) toFilter(loc *time.Location) (domain.SubscriptionFilter, error) {
+	startPeriod, err := parseDay(r.StartPeriod, loc)
+	if err != nil {
+		return domain.SubscriptionFilter{}, errors.New("invalid start_period")
+	}
+
+	endPeriod, err := parseDay(r.EndPeriod, loc)
+	if err != nil {
+		return domain.SubscriptionFilter{}, errors.New("invalid end_period")
+	}
+
+	filter := domain.SubscriptionFilter{
+		StartPeriod: &startPeriod,
+		EndPeriod:   &endPeriod,
+	}
+
+	if r.UserID != nil {
+		userID, err := uuid.Parse(*r.UserID)
+		if err != nil {
+			return domain.SubscriptionFilter{}, errors.New("invalid user_id")
+		}
+		filter.UserID = &userID
+	}
+
+	if r.ServiceName != nil {
+		filter.ServiceName = r.ServiceName
+	}
+
+	if r.ServiceID != nil {
+		serviceID, err := uuid.Parse(*r.ServiceID)
+		if err != nil {
+			return domain.SubscriptionFilter{}, errors.New("invalid service_id")
+		}
+		filter.ServiceID = &serviceID
+	}
+
+	filter.Category = r.Category
+	filter.Tags = r.Tags
+	filter.TagMatch = r.TagMatch
+	filter.CostMode = domain.CostMode(r.Mode)
+
+	return filter, nil
+}
+
+type ExportRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx" example:"csv"`
+}
+
+type TotalCostResponse struct {
+	Total  int                 `json:"total" example:"12000"`
+	Groups []CostGroupResponse `json:"groups,omitempty"`
+}
+
+// CostGroupResponse - стоимость группы; key = null - подписки без категории или тегов
+type CostGroupResponse struct {
+	Key   *string `json:"key" example:"streaming"`
+	Total int     `json:"total" example:"9600"`
+}
+
+type StreamRequest struct {
 	UserID      *string `form:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
 	ServiceName *string `form:"service_name" example:"Yandex"`
 }
 
-type TotalCostResponse struct {
-	Total int `json:"total" example:"12000"`
+type UpcomingRequest struct {
+	Days        int     `form:"days" binding:"omitempty,min=1,max=366" example:"7"`
+	UserID      *string `form:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ServiceName *string `form:"service_name" example:"Yandex"`
+}
+
+type UpcomingChargeResponse struct {
+	SubscriptionID string `json:"subscription_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ServiceName    string `json:"service_name" example:"Yandex Plus"`
+	UserID         string `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ChargeDate     string `json:"charge_date" example:"2025-08-01"`
+	Amount         int    `json:"amount" example:"400"`
+}
+
+type UpcomingResponse struct {
+	From    string                   `json:"from" example:"2025-07-28"`
+	To      string                   `json:"to" example:"2025-08-04"`
+	Charges []UpcomingChargeResponse `json:"charges"`
+	Total   int                      `json:"total" example:"400"`
+}
+
+type ForecastRequest struct {
+	Months      int     `form:"months" binding:"omitempty,min=1,max=60" example:"12"`
+	UserID      *string `form:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ServiceName *string `form:"service_name" example:"Yandex"`
+	ServiceID   *string `form:"service_id" example:"5b1c2f0e-7a3d-4c8e-9f61-2d4b8a0c6e13"`
+}
+
+type ForecastMonthResponse struct {
+	Month   string `json:"month" example:"2025-08"`
+	Charges int    `json:"charges" example:"3"`
+	Total   int    `json:"total" example:"1100"`
+}
+
+type ForecastResponse struct {
+	From   string                  `json:"from" example:"2025-08"`
+	To     string                  `json:"to" example:"2026-07"`
+	Months []ForecastMonthResponse `json:"months"`
+	Total  int                     `json:"total" example:"13200"`
+}
+
+// DuplicateResponse - подписки на один сервис с пересекающимися периодами
+type DuplicateResponse struct {
+	UserID        string                 `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ServiceName   string                 `json:"service_name" example:"Yandex Plus"`
+	ServiceID     *string                `json:"service_id,omitempty" example:"5b1c2f0e-7a3d-4c8e-9f61-2d4b8a0c6e13"`
+	Subscriptions []SubscriptionResponse `json:"subscriptions"`
+	From          string                 `json:"from" example:"2025-03"`
+	Months        int                    `json:"months" example:"5"`
+	Wasted        int                    `json:"wasted" example:"1500"`
+	Ongoing       bool                   `json:"ongoing" example:"true"`
+}
+
+type ImportRequest struct {
+	DryRun            bool   `form:"dry_run" example:"true"`
+	Delimiter         string `form:"delimiter" binding:"omitempty,len=1" example:";"`
+	ServiceNameColumn string `form:"service_name_column" example:"Service"`
+	PriceColumn       string `form:"price_column" example:"Price"`
+	UserIDColumn      string `form:"user_id_column" example:"User"`
+	StartDateColumn   string `form:"start_date_column" example:"Start"`
+	EndDateColumn     string `form:"end_date_column" example:"End"`
+}
+
+type ImportRowErrorResponse struct {
+	Line  int    `json:"line" example:"3"`
+	Error string `json:"error" example:"invalid start_date format"`
+}
+
+type ImportResponse struct {
+	DryRun   bool                     `json:"dry_run" example:"true"`
+	Total    int                      `json:"total" example:"10"`
+	Valid    int                      `json:"valid" example:"9"`
+	Imported int                      `json:"imported" example:"0"`
+	Errors   []ImportRowErrorResponse `json:"errors"`
+}
+
+type BankStatementRequest struct {
+	Format            string `form:"format" binding:"omitempty,oneof=csv ofx qif" example:"csv"`
+	Delimiter         string `form:"delimiter" binding:"omitempty,len=1" example:";"`
+	DateColumn        string `form:"date_column" example:"Дата операции"`
+	AmountColumn      string `form:"amount_column" example:"Сумма"`
+	DescriptionColumn string `form:"description_column" example:"Описание"`
+	DateLayout        string `form:"date_layout" example:"02.01.2006"`
+}
+
+type CandidateResponse struct {
+	ID             string    `json:"id" example:"0b0e6f5c-9d43-4a4e-b1a0-3c2f6a2f1e11"`
+	UserID         string    `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	ServiceName    string    `json:"service_name" example:"YANDEX*PLUS"`
+	Price          int       `json:"price" example:"399"`
+	StartDate      string    `json:"start_date" example:"2025-01-01"`
+	EndDate        *string   `json:"end_date,omitempty" example:"2025-06-01"`
+	Occurrences    int       `json:"occurrences" example:"6"`
+	Confidence     float64   `json:"confidence" example:"0.94"`
+	Status         string    `json:"status" example:"pending"`
+	SubscriptionID *string   `json:"subscription_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type AcceptCandidateRequest struct {
+	ServiceName *string `json:"service_name,omitempty" example:"Yandex Plus"`
+	Price       *int    `json:"price,omitempty" binding:"omitempty,min=0" example:"399"`
+}
+
+type CalendarTokenResponse struct {
+	Token string `json:"token" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	URL   string `json:"url" example:"http://localhost:8080/api/v1/users/60601fee-2bf1-4721-ae6f-7636e79a0cba/renewals.ics?token=9f86d081"`
+}
+
+type CatalogEntryRequest struct {
+	Name         string   `json:"name" binding:"required,max=255" example:"Yandex Plus"`
+	Aliases      []string `json:"aliases" example:"Яндекс Плюс,YANDEX*PLUS"`
+	Category     string   `json:"category" binding:"max=100" example:"music"`
+	Website      string   `json:"website" binding:"omitempty,url,max=255" example:"https://plus.yandex.ru"`
+	DefaultPrice *int     `json:"default_price,omitempty" binding:"omitempty,min=0" example:"399"`
+}
+
+type CatalogEntryResponse struct {
+	ID           string    `json:"id" example:"5b1c2f0e-7a3d-4c8e-9f61-2d4b8a0c6e13"`
+	Name         string    `json:"name" example:"Yandex Plus"`
+	Aliases      []string  `json:"aliases" example:"Яндекс Плюс,YANDEX*PLUS"`
+	Category     string    `json:"category" example:"music"`
+	Website      string    `json:"website" example:"https://plus.yandex.ru"`
+	DefaultPrice *int      `json:"default_price,omitempty" example:"399"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type CategoryRequest struct {
+	Name string `json:"name" binding:"required,max=100" example:"Streaming"`
+}
+
+type CategoryResponse struct {
+	ID        string    `json:"id" example:"3f2a9c1d-8b7e-4f60-a5d4-1c2b3a4d5e6f"`
+	Name      string    `json:"name" example:"Streaming"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TagResponse struct {
+	ID            string    `json:"id" example:"7d3e5f1a-2b4c-4d6e-8f0a-1b2c3d4e5f60"`
+	Name          string    `json:"name" example:"music"`
+	Subscriptions int       `json:"subscriptions" example:"3"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required,max=50" example:"music"`
+}
+
+type SetTagsRequest struct {
+	Tags []string `json:"tags" binding:"required" example:"music,family"`
+}
+
+type BudgetRequest struct {
+	Name       string `json:"name" binding:"max=255" example:"Streaming"`
+	UserID     string `json:"user_id,omitempty" binding:"omitempty,uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	CategoryID string `json:"category_id,omitempty" binding:"omitempty,uuid" example:"3f2a9c1d-8b7e-4f60-a5d4-1c2b3a4d5e6f"`
+	ServiceID  string `json:"service_id,omitempty" binding:"omitempty,uuid"`
+	Period     string `json:"period" binding:"required,oneof=monthly yearly" example:"monthly"`
+	Amount     int    `json:"amount" binding:"required,min=1" example:"1500"`
+	Thresholds []int  `json:"thresholds,omitempty" binding:"omitempty,dive,min=1,max=1000" example:"80,100"`
+}
+
+type BudgetResponse struct {
+	ID         string    `json:"id" example:"9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"`
+	Name       string    `json:"name" example:"Streaming"`
+	UserID     *string   `json:"user_id,omitempty" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	CategoryID *string   `json:"category_id,omitempty" example:"3f2a9c1d-8b7e-4f60-a5d4-1c2b3a4d5e6f"`
+	ServiceID  *string   `json:"service_id,omitempty"`
+	Period     string    `json:"period" example:"monthly"`
+	Amount     int       `json:"amount" example:"1500"`
+	Thresholds []int     `json:"thresholds" example:"80,100"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type BudgetStatusResponse struct {
+	Budget           BudgetResponse `json:"budget"`
+	PeriodStart      string         `json:"period_start" example:"2025-08-01"`
+	PeriodEnd        string         `json:"period_end" example:"2025-08-31"`
+	Spent            int            `json:"spent" example:"1200"`
+	Projected        int            `json:"projected" example:"1200"`
+	SpentPercent     float64        `json:"spent_percent" example:"80"`
+	ProjectedPercent float64        `json:"projected_percent" example:"80"`
+}
+
+type BudgetAlertResponse struct {
+	ID          string    `json:"id" example:"1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"`
+	BudgetID    string    `json:"budget_id" example:"9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d"`
+	PeriodStart string    `json:"period_start" example:"2025-08-01"`
+	Threshold   int       `json:"threshold" example:"80"`
+	Kind        string    `json:"kind" example:"projected"`
+	Amount      int       `json:"amount" example:"1500"`
+	Spent       int       `json:"spent" example:"900"`
+	Projected   int       `json:"projected" example:"1300"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type ErrorResponse struct {
-	Error string `json:"error" example:"invalid request"`
+	Error     string `json:"error" example:"invalid request"`
+	RequestID string `json:"request_id,omitempty" example:"5f0c2a9e-3b1d-4c47-9a5e-8f3d2b7c1e64"`
+}
+
+type UserRequest struct {
+	Name     string  `json:"name" binding:"required,max=255" example:"Иван Петров"`
+	Email    *string `json:"email,omitempty" example:"ivan@example.com"`
+	Currency string  `json:"currency,omitempty" example:"RUB"`
+	Timezone string  `json:"timezone,omitempty" example:"Europe/Moscow"`
+}
+
+type UserResponse struct {
+	ID        string    `json:"id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Name      string    `json:"name" example:"Иван Петров"`
+	Email     *string   `json:"email,omitempty" example:"ivan@example.com"`
+	Currency  string    `json:"currency" example:"RUB"`
+	Timezone  string    `json:"timezone" example:"Europe/Moscow"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserSummaryResponse - сводка по подпискам; monthly_spend учитывает доли в совместных подписках
+type UserSummaryResponse struct {
+	User                UserResponse           `json:"user"`
+	Subscriptions       int                    `json:"subscriptions" example:"5"`
+	ActiveCount         int                    `json:"active_count" example:"3"`
+	ActiveSubscriptions []SubscriptionResponse `json:"active_subscriptions"`
+	Month               string                 `json:"month" example:"2025-08"`
+	MonthlySpend        int                    `json:"monthly_spend" example:"1299"`
+}
+
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required" example:"https://example.com/hooks/subscriptions"`
+	Secret string   `json:"secret" binding:"required" example:"s3cr3t-signing-key-123"`
+	Events []string `json:"events" binding:"required,min=1" example:"subscription.created,subscription.deleted"`
+}
+
+type WebhookResponse struct {
+	ID        string    `json:"id" example:"7d4e2c1b-9a8f-4e6d-b5c4-3a2b1c0d9e8f"`
+	URL       string    `json:"url" example:"https://example.com/hooks/subscriptions"`
+	Events    []string  `json:"events" example:"subscription.created,subscription.deleted"`
+	Active    bool      `json:"active" example:"true"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDeliveriesRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=200" example:"50"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID            int64      `json:"id" example:"1024"`
+	EventID       int64      `json:"event_id" example:"512"`
+	EventType     string     `json:"event_type" example:"subscription.created"`
+	Status        string     `json:"status" example:"pending"`
+	Attempts      int        `json:"attempts" example:"2"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	ResponseCode  *int       `json:"response_code,omitempty" example:"503"`
+	LastError     *string    `json:"last_error,omitempty" example:"unexpected status 503"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
 }
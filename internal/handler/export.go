@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/internal/export"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// @Summary Export subscriptions
+// @Description Streams subscriptions matching the list filters as CSV or XLSX
+// @Tags subscriptions
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format" Enums(csv, xlsx) default(csv)
+// @Param user_id query string false "Filter by user ID"
+// @Param service_name query string false "Filter by service name"
+// @Param service_id query string false "Filter by catalog service ID"
+// @Param category query string false "Filter by category name"
+// @Param tag query []string false "Filter by tags" collectionFormat(multi)
+// @Param tag_match query string false "Match any or all of the tags" Enums(any, all) default(any)
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/subscriptions/export [get]
+func (h *SubscriptionHandler) export(c *gin.Context) {
+	var req ExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	w, ok := h.startExport(c, req.Format, "subscriptions")
+	if !ok {
+		return
+	}
+
+	loc := requestLocation(c)
+	err = w.WriteRow("id", "service_name", "price", "user_id", "start_date", "end_date", "created_at", "updated_at")
+	if err != nil {
+		h.finishExport(c, w, err)
+		return
+	}
+
+	err = h.service.Export(c.Request.Context(), filter, func(sub *domain.Subscription) error {
+		resp := toResponse(sub, loc)
+		endDate := ""
+		if resp.EndDate != nil {
+			endDate = *resp.EndDate
+		}
+
+		return w.WriteRow(resp.ID, resp.ServiceName, resp.Price, resp.UserID, resp.StartDate, endDate,
+			resp.CreatedAt.Format(time.RFC3339), resp.UpdatedAt.Format(time.RFC3339))
+	})
+
+	h.finishExport(c, w, err)
+}
+
+// @Summary Export total cost report
+// @Description Streams per-subscription cost for the period with a total row as CSV or XLSX
+// @Tags subscriptions
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format" Enums(csv, xlsx) default(csv)
+// @Param start_period query string true "Start period YYYY-MM-DD or RFC 3339"
+// @Param end_period query string true "End period YYYY-MM-DD or RFC 3339"
+// @Param user_id query string false "Filter by user ID"
+// @Param service_name query string false "Filter by service name"
+// @Param service_id query string false "Filter by catalog service ID"
+// @Param category query string false "Filter by category name"
+// @Param tag query []string false "Filter by tags" collectionFormat(multi)
+// @Param tag_match query string false "Match any or all of the tags" Enums(any, all) default(any)
+// @Param mode query string false "Cost calculation mode" Enums(whole_months, daily_prorated, charge_events) default(whole_months)
+// @Param X-Timezone header string false "IANA timezone, e.g. Europe/Moscow"
+// @Success 200 {file} file
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/subscriptions/total-cost/export [get]
+func (h *SubscriptionHandler) exportTotalCost(c *gin.Context) {
+	var req ExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	var costReq TotalCostRequest
+	if err := c.ShouldBindQuery(&costReq); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	filter, err := costReq.toFilter(requestLocation(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	w, ok := h.startExport(c, req.Format, "total-cost")
+	if !ok {
+		return
+	}
+
+	err = w.WriteRow("id", "service_name", "user_id", "start_date", "end_date", "price", "months", "cost")
+	if err != nil {
+		h.finishExport(c, w, err)
+		return
+	}
+
+	loc := requestLocation(c)
+	total, err := h.service.ExportCost(c.Request.Context(), filter, func(line *domain.CostLine) error {
+		resp := toResponse(&line.Subscription, loc)
+		endDate := ""
+		if resp.EndDate != nil {
+			endDate = *resp.EndDate
+		}
+
+		return w.WriteRow(resp.ID, resp.ServiceName, resp.UserID, resp.StartDate, endDate,
+			resp.Price, line.Months, line.Cost)
+	})
+	if err == nil {
+		err = w.WriteRow("total", "", "", "", "", "", "", total)
+	}
+
+	h.finishExport(c, w, err)
+}
+
+// startExport выставляет заголовки ответа и создает writer нужного формата
+func (h *SubscriptionHandler) startExport(c *gin.Context, format, name string) (export.RowWriter, bool) {
+	if format == "" {
+		format = export.FormatCSV
+	}
+
+	w, err := export.NewWriter(format, c.Writer, name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return nil, false
+	}
+
+	c.Header("Content-Type", w.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, time.Now().Format("20060102"), format))
+	c.Status(http.StatusOK)
+
+	return w, true
+}
+
+// finishExport дописывает файл. Заголовки уже отправлены, поэтому ошибку
+// можно только залогировать, клиент получит обрезанный файл.
+func (h *SubscriptionHandler) finishExport(c *gin.Context, w export.RowWriter, err error) {
+	if err == nil {
+		err = w.Close()
+	}
+
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("export failed", zap.String("path", c.Request.URL.Path), zap.Error(err))
+		c.Abort()
+	}
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"time"
 
 	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/internal/importer"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
 	"github.com/SoulStalker/subscribes_api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -17,23 +21,57 @@ import (
 )
 
 type SubscriptionHandler struct {
-	service *service.SubscriptionService
-	logger  *zap.Logger
+	service   *service.SubscriptionService
+	timezones TimezoneResolver
+	readiness ReadinessChecker
+	metrics   MetricsRecorder
+	limiter   RateLimiter
+	// trustedProxies - прокси, которым gin верит в X-Forwarded-For при определении IP клиента
+	trustedProxies []string
+	logger         *zap.Logger
 }
 
-func NewHandler(service *service.SubscriptionService, logger *zap.Logger) *SubscriptionHandler {
-	return &SubscriptionHandler{
+func NewHandler(service *service.SubscriptionService, logger *zap.Logger, opts ...HandlerOption) *SubscriptionHandler {
+	h := &SubscriptionHandler{
 		service: service,
 		logger:  logger,
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// RouteRegistrar регистрирует маршруты дополнительного ресурса в группе /api/v1
+type RouteRegistrar interface {
+	RegisterRoutes(api *gin.RouterGroup)
 }
 
-func (h *SubscriptionHandler) InitRoutes(mode string) *gin.Engine {
+func (h *SubscriptionHandler) InitRoutes(mode string, registrars ...RouteRegistrar) *gin.Engine {
 	gin.SetMode(mode)
 	router := gin.New()
 
+	// по умолчанию gin верит X-Forwarded-For от любого адреса
+	if err := router.SetTrustedProxies(h.trustedProxies); err != nil {
+		h.logger.Error("invalid trusted proxies, using connection address as client IP", zap.Error(err))
+		_ = router.SetTrustedProxies(nil)
+	}
+
 	router.Use(gin.Recovery())
+	h.registerProbes(router)
+
+	if h.metrics != nil {
+		router.Use(h.metricsMiddleware())
+	}
+	router.Use(h.tracingMiddleware())
+	router.Use(h.requestIDMiddleware())
 	router.Use(h.loggingMiddleware())
+	if h.limiter != nil {
+		router.Use(h.rateLimitMiddleware())
+	}
+	router.Use(h.timezoneMiddleware())
 
 	api := router.Group("/api/v1")
 
@@ -45,10 +83,25 @@ func (h *SubscriptionHandler) InitRoutes(mode string) *gin.Engine {
 			subs.GET("", h.list)
 			subs.PUT("/:id", h.update)
 			subs.DELETE("/:id", h.delete)
+			subs.PUT("/:id/tags", h.setTags)
 			subs.GET("/total-cost", h.totalCost)
+			subs.GET("/upcoming", h.upcoming)
+			subs.POST("/import", h.importCSV)
+			subs.GET("/export", h.export)
+			subs.GET("/total-cost/export", h.exportTotalCost)
+		}
+
+		reports := api.Group("/reports")
+		{
+			reports.GET("/forecast", h.forecast)
+			reports.GET("/duplicates", h.duplicates)
 		}
 	}
 
+	for _, registrar := range registrars {
+		registrar.RegisterRoutes(api)
+	}
+
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	return router
@@ -59,7 +112,7 @@ func (h *SubscriptionHandler) loggingMiddleware() gin.HandlerFunc {
 		start := time.Now()
 		c.Next()
 
-		h.logger.Info("request",
+		applogger.FromContext(c.Request.Context(), h.logger).Info("request",
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
 			zap.Int("status", c.Writer.Status()),
@@ -68,15 +121,21 @@ func (h *SubscriptionHandler) loggingMiddleware() gin.HandlerFunc {
 	}
 }
 
-func toResponse(sub *domain.Subscription) SubscriptionResponse {
+// toResponse отдает даты подписки как есть, а время создания и изменения - в часовом поясе loc
+func toResponse(sub *domain.Subscription, loc *time.Location) SubscriptionResponse {
 	resp := SubscriptionResponse{
 		ID:          sub.ID.String(),
 		ServiceName: sub.ServiceName,
 		Price:       sub.Price,
 		UserID:      sub.UserID.String(),
 		StartDate:   sub.StartDate.Format("2006-01-02"),
-		CreatedAt:   sub.CreatedAt,
-		UpdatedAt:   sub.UpdatedAt,
+		Tags:        sub.Tags,
+		CreatedAt:   sub.CreatedAt.In(loc),
+		UpdatedAt:   sub.UpdatedAt.In(loc),
+	}
+
+	if resp.Tags == nil {
+		resp.Tags = []string{}
 	}
 
 	if sub.EndDate != nil {
@@ -84,33 +143,92 @@ func toResponse(sub *domain.Subscription) SubscriptionResponse {
 		resp.EndDate = &endStr
 	}
 
+	if sub.ServiceID != nil {
+		serviceID := sub.ServiceID.String()
+		resp.ServiceID = &serviceID
+	}
+
+	if sub.CategoryID != nil {
+		categoryID := sub.CategoryID.String()
+		resp.CategoryID = &categoryID
+	}
+
+	resp.BillingPeriod = sub.BillingPeriod
+	if resp.BillingPeriod == "" {
+		resp.BillingPeriod = domain.BillingMonthly
+	}
+	for _, c := range sub.PriceChanges {
+		resp.PriceChanges = append(resp.PriceChanges, PriceChangeResponse{
+			EffectiveFrom: c.EffectiveFrom.Format("2006-01-02"),
+			Price:         c.Price,
+		})
+	}
+
+	if sub.Split.Shared() {
+		resp.Split = &SplitResponse{
+			Mode:        sub.Split.Mode,
+			OwnerAmount: sub.ShareOf(sub.UserID),
+			Members:     make([]MemberResponse, len(sub.Split.Members)),
+		}
+		for i, m := range sub.Split.Members {
+			resp.Split.Members[i] = MemberResponse{UserID: m.UserID.String(), Share: m.Share, Amount: m.Amount}
+		}
+	}
+
 	return resp
 }
 
+// parseOptionalID разбирает необязательный идентификатор из тела запроса
+func parseOptionalID(raw, field string) (*uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, errors.New("invalid " + field)
+	}
+
+	return &id, nil
+}
+
+// isClientError - ошибки записи подписки, вызванные данными запроса
+func isClientError(err error) bool {
+	return errors.Is(err, service.ErrUnknownService) ||
+		errors.Is(err, service.ErrInvalidTaxonomy) ||
+		errors.Is(err, service.ErrInvalidSplit) ||
+		errors.Is(err, service.ErrInvalidBilling) ||
+		errors.Is(err, service.ErrInvalidCostMode) ||
+		errors.Is(err, domain.ErrReferenceNotFound)
+}
+
 // @Summary Create subscription
+// @Description The service is taken from service_id or resolved from service_name through
+// @Description catalog aliases; a matched service replaces the name with its canonical one
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body CreateSubscriptionRequest true "Subscription data"
 // @Success 201 {object} SubscriptionResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse "Overlapping subscription exists (strict duplicates mode)"
 // @Router /api/v1/subscriptions [post]
 func (h *SubscriptionHandler) create(c *gin.Context) {
 	var req CreateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
 		return
 	}
 
 	userID, err := uuid.Parse(req.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid user_id"))
 		return
 	}
 
 	startDate, err := time.Parse("01-2006", req.StartDate)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid start_date format"})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid start_date format"))
 		return
 	}
 
@@ -118,27 +236,59 @@ func (h *SubscriptionHandler) create(c *gin.Context) {
 	if req.EndDate != "" {
 		ed, err := time.Parse("01-2006", req.EndDate)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid end_date format"})
+			c.JSON(http.StatusBadRequest, errorResponse(c, "invalid end_date format"))
 			return
 		}
 		endDate = &ed
 	}
 
+	serviceID, err := parseOptionalID(req.ServiceID, "service_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	categoryID, err := parseOptionalID(req.CategoryID, "category_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	priceChanges, err := toPriceChanges(req.PriceChanges)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
 	sub := &domain.Subscription{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      userID,
-		StartDate:   startDate,
-		EndDate:     endDate,
+		ServiceName:   req.ServiceName,
+		ServiceID:     serviceID,
+		CategoryID:    categoryID,
+		Tags:          req.Tags,
+		Split:         req.Split.toSplit(),
+		Price:         req.Price,
+		BillingPeriod: req.BillingPeriod,
+		PriceChanges:  priceChanges,
+		UserID:        userID,
+		StartDate:     startDate,
+		EndDate:       endDate,
 	}
 
 	if err := h.service.Create(c.Request.Context(), sub); err != nil {
-		h.logger.Error("failed to create subscription", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		if errors.Is(err, service.ErrDuplicateSubscription) {
+			c.JSON(http.StatusConflict, errorResponse(c, err.Error()))
+			return
+		}
+		if isClientError(err) {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to create subscription", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusCreated, toResponse(sub))
+	c.JSON(http.StatusCreated, toResponse(sub, requestLocation(c)))
 }
 
 // @Summary Get subscription by ID
@@ -151,17 +301,17 @@ func (h *SubscriptionHandler) create(c *gin.Context) {
 func (h *SubscriptionHandler) getByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
 		return
 	}
 
 	sub, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "subscription not found"})
+		c.JSON(http.StatusNotFound, errorResponse(c, "subscription not found"))
 		return
 	}
 
-	c.JSON(http.StatusOK, toResponse(sub))
+	c.JSON(http.StatusOK, toResponse(sub, requestLocation(c)))
 }
 
 // @Summary List subscriptions
@@ -169,16 +319,43 @@ func (h *SubscriptionHandler) getByID(c *gin.Context) {
 // @Produce json
 // @Param user_id query string false "Filter by user ID"
 // @Param service_name query string false "Filter by service name"
+// @Param service_id query string false "Filter by catalog service ID"
+// @Param category query string false "Filter by category name"
+// @Param tag query []string false "Filter by tags" collectionFormat(multi)
+// @Param tag_match query string false "Match any or all of the tags" Enums(any, all) default(any)
 // @Success 200 {array} SubscriptionResponse
 // @Router /api/v1/subscriptions [get]
 func (h *SubscriptionHandler) list(c *gin.Context) {
+	filter, err := parseListFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	subs, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to list subscriptions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	loc := requestLocation(c)
+	resp := make([]SubscriptionResponse, len(subs))
+	for i, sub := range subs {
+		resp[i] = toResponse(&sub, loc)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// parseListFilter разбирает фильтры списка подписок из query
+func parseListFilter(c *gin.Context) (domain.SubscriptionFilter, error) {
 	filter := domain.SubscriptionFilter{}
 
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
 		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user id"})
-			return
+			return filter, errors.New("invalid user id")
 		}
 
 		filter.UserID = &userID
@@ -188,19 +365,29 @@ func (h *SubscriptionHandler) list(c *gin.Context) {
 		filter.ServiceName = &serviceName
 	}
 
-	subs, err := h.service.List(c.Request.Context(), filter)
-	if err != nil {
-		h.logger.Error("failed to list subscriptions", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
-		return
+	if serviceIDStr := c.Query("service_id"); serviceIDStr != "" {
+		serviceID, err := uuid.Parse(serviceIDStr)
+		if err != nil {
+			return filter, errors.New("invalid service id")
+		}
+
+		filter.ServiceID = &serviceID
 	}
 
-	resp := make([]SubscriptionResponse, len(subs))
-	for i, sub := range subs {
-		resp[i] = toResponse(&sub)
+	if category := c.Query("category"); category != "" {
+		filter.Category = &category
 	}
 
-	c.JSON(http.StatusOK, resp)
+	filter.Tags = c.QueryArray("tag")
+
+	switch tagMatch := c.Query("tag_match"); tagMatch {
+	case "", domain.TagMatchAny, domain.TagMatchAll:
+		filter.TagMatch = tagMatch
+	default:
+		return filter, errors.New("invalid tag_match")
+	}
+
+	return filter, nil
 }
 
 // @Summary Update subscription
@@ -211,41 +398,84 @@ func (h *SubscriptionHandler) list(c *gin.Context) {
 // @Param subscription body UpdateSubscriptionRequest true "Updated data"
 // @Success 200 {object} SubscriptionResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
 // @Router /api/v1/subscriptions/{id} [put]
 func (h *SubscriptionHandler) update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
 		return
 	}
 
 	var req UpdateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	startDate, err := time.Parse("01-2006", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid start_date format"))
 		return
 	}
 
-	startDate, _ := time.Parse("01-2006", req.StartDate)
 	var endDate *time.Time
 	if req.EndDate != "" {
-		ed, _ := time.Parse("01-2006", req.EndDate)
+		ed, err := time.Parse("01-2006", req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "invalid end_date format"))
+			return
+		}
 		endDate = &ed
 	}
 
+	serviceID, err := parseOptionalID(req.ServiceID, "service_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	categoryID, err := parseOptionalID(req.CategoryID, "category_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	priceChanges, err := toPriceChanges(req.PriceChanges)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
 	sub := &domain.Subscription{
-		ID:          id,
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		StartDate:   startDate,
-		EndDate:     endDate,
+		ID:            id,
+		ServiceName:   req.ServiceName,
+		ServiceID:     serviceID,
+		CategoryID:    categoryID,
+		Tags:          req.Tags,
+		Split:         req.Split.toSplit(),
+		Price:         req.Price,
+		BillingPeriod: req.BillingPeriod,
+		PriceChanges:  priceChanges,
+		StartDate:     startDate,
+		EndDate:       endDate,
 	}
 
 	if err := h.service.Update(c.Request.Context(), sub); err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		if errors.Is(err, service.ErrDuplicateSubscription) {
+			c.JSON(http.StatusConflict, errorResponse(c, err.Error()))
+			return
+		}
+		if isClientError(err) {
+			c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+			return
+		}
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to update subscription", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, toResponse(sub))
+	c.JSON(http.StatusOK, toResponse(sub, requestLocation(c)))
 }
 
 // @Summary Delete subscription
@@ -257,12 +487,12 @@ func (h *SubscriptionHandler) update(c *gin.Context) {
 func (h *SubscriptionHandler) delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid id"})
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
 		return
 	}
 
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusNotFound, ErrorResponse{Error: "subscription not found"})
+		c.JSON(http.StatusNotFound, errorResponse(c, "subscription not found"))
 		return
 	}
 
@@ -272,41 +502,138 @@ func (h *SubscriptionHandler) delete(c *gin.Context) {
 // @Summary Calculate total cost
 // @Tags subscriptions
 // @Produce json
-// @Param start_period query string true "Start period YYYY-MM-DD"
-// @Param end_period query string true "End period YYYY-MM-DD"
+// @Param start_period query string true "Start period YYYY-MM-DD or RFC 3339"
+// @Param end_period query string true "End period YYYY-MM-DD or RFC 3339"
 // @Param user_id query string false "Filter by user ID"
 // @Param service_name query string false "Filter by service name"
+// @Param service_id query string false "Filter by catalog service ID"
+// @Param category query string false "Filter by category name"
+// @Param tag query []string false "Filter by tags" collectionFormat(multi)
+// @Param tag_match query string false "Match any or all of the tags" Enums(any, all) default(any)
+// @Param group_by query string false "Break the total down by category or tag (whole_months mode only)" Enums(category, tag)
+// @Param mode query string false "Cost calculation mode" Enums(whole_months, daily_prorated, charge_events) default(whole_months)
+// @Param X-Timezone header string false "IANA timezone, e.g. Europe/Moscow"
 // @Success 200 {object} TotalCostResponse
 // @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
 // @Router /api/v1/subscriptions/total-cost [get]
 func (h *SubscriptionHandler) totalCost(c *gin.Context) {
 	var req TotalCostRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
 		return
 	}
 
-	startPeriod, err := time.Parse("2006-01-02", req.StartPeriod)
+	filter, err := req.toFilter(requestLocation(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid start_period"})
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
 		return
 	}
 
-	endPeriod, err := time.Parse("2006-01-02", req.EndPeriod)
+	total, err := h.service.TotalCost(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid end_period"})
+		h.costError(c, err)
 		return
 	}
 
-	filter := domain.SubscriptionFilter{
-		StartPeriod: &startPeriod,
-		EndPeriod:   &endPeriod,
+	resp := TotalCostResponse{Total: total}
+	if req.GroupBy != "" {
+		groups, err := h.service.TotalCostByGroup(c.Request.Context(), filter, req.GroupBy)
+		if err != nil {
+			h.costError(c, err)
+			return
+		}
+
+		resp.Groups = make([]CostGroupResponse, len(groups))
+		for i, group := range groups {
+			resp.Groups[i] = CostGroupResponse{Key: group.Key, Total: group.Total}
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// costError отвечает на ошибку отчета о стоимости; ошибки сервера пишутся в журнал запроса
+func (h *SubscriptionHandler) costError(c *gin.Context, err error) {
+	if isClientError(err) {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
 	}
+	applogger.FromContext(c.Request.Context(), h.logger).Error("failed to calculate total cost", zap.Error(err))
+	c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+}
+
+// @Summary Replace subscription tags
+// @Description Tags are free-form: they are lowercased, deduplicated and created on first use
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param tags body SetTagsRequest true "New set of tags"
+// @Success 200 {array} string
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/subscriptions/{id}/tags [put]
+func (h *SubscriptionHandler) setTags(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	var req SetTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	tags, err := h.service.SetTags(c.Request.Context(), id, req.Tags)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(c, "subscription not found"))
+		return
+	case isClientError(err):
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	case err != nil:
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to set subscription tags", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// @Summary Upcoming renewals
+// @Description Returns charges of active subscriptions for the next N days sorted by date. With user_id shared
+// @Description subscriptions where the user is a member are included and amounts are the user's share
+// @Tags subscriptions
+// @Produce json
+// @Param days query int false "Window size in days (1-366)" default(7)
+// @Param user_id query string false "Filter by user ID"
+// @Param service_name query string false "Filter by service name"
+// @Param X-Timezone header string false "IANA timezone, e.g. Europe/Moscow"
+// @Success 200 {object} UpcomingResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/subscriptions/upcoming [get]
+func (h *SubscriptionHandler) upcoming(c *gin.Context) {
+	var req UpcomingRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	if req.Days == 0 {
+		req.Days = 7
+	}
+
+	filter := domain.SubscriptionFilter{}
 
 	if req.UserID != nil {
 		userID, err := uuid.Parse(*req.UserID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid user_id"})
+			c.JSON(http.StatusBadRequest, errorResponse(c, "invalid user_id"))
 			return
 		}
 		filter.UserID = &userID
@@ -316,11 +643,246 @@ func (h *SubscriptionHandler) totalCost(c *gin.Context) {
 		filter.ServiceName = req.ServiceName
 	}
 
-	total, err := h.service.TotalCost(c.Request.Context(), filter)
+	upcoming, err := h.service.Upcoming(c.Request.Context(), filter, req.Days)
+	switch {
+	case errors.Is(err, service.ErrInvalidDays):
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	case err != nil:
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to list upcoming charges", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	resp := UpcomingResponse{
+		From:    upcoming.From.Format("2006-01-02"),
+		To:      upcoming.To.Format("2006-01-02"),
+		Charges: make([]UpcomingChargeResponse, len(upcoming.Charges)),
+		Total:   upcoming.Total,
+	}
+
+	for i, charge := range upcoming.Charges {
+		resp.Charges[i] = UpcomingChargeResponse{
+			SubscriptionID: charge.SubscriptionID.String(),
+			ServiceName:    charge.ServiceName,
+			UserID:         charge.UserID.String(),
+			ChargeDate:     charge.ChargeDate.Format("2006-01-02"),
+			Amount:         charge.Amount,
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Spending forecast
+// @Description Projects spend of active subscriptions month by month starting with the current month.
+// @Description Subscriptions are charged once per billing_period until the end_date month; a charge costs
+// @Description the monthly price in effect on its date (including scheduled price_changes) times the months of the period.
+// @Tags reports
+// @Produce json
+// @Param months query int false "Number of months (1-60)" default(12)
+// @Param user_id query string false "Filter by user ID; shared subscriptions count with the user's share"
+// @Param service_name query string false "Filter by service name"
+// @Param service_id query string false "Filter by catalog service ID"
+// @Param X-Timezone header string false "IANA timezone, e.g. Europe/Moscow"
+// @Success 200 {object} ForecastResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/reports/forecast [get]
+func (h *SubscriptionHandler) forecast(c *gin.Context) {
+	var req ForecastRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	if req.Months == 0 {
+		req.Months = 12
+	}
+
+	filter := domain.SubscriptionFilter{ServiceName: req.ServiceName}
+
+	if req.UserID != nil {
+		userID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "invalid user_id"))
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	if req.ServiceID != nil {
+		serviceID, err := uuid.Parse(*req.ServiceID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "invalid service_id"))
+			return
+		}
+		filter.ServiceID = &serviceID
+	}
+
+	forecast, err := h.service.Forecast(c.Request.Context(), filter, req.Months)
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to build forecast", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	resp := ForecastResponse{
+		From:   forecast.From.Format("2006-01"),
+		To:     forecast.To.Format("2006-01"),
+		Months: make([]ForecastMonthResponse, len(forecast.Months)),
+		Total:  forecast.Total,
+	}
+
+	for i, month := range forecast.Months {
+		resp.Months[i] = ForecastMonthResponse{
+			Month:   month.Month.Format("2006-01"),
+			Charges: month.Charges,
+			Total:   month.Total,
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Duplicate subscriptions
+// @Description Finds subscriptions of the same user to the same service (by catalog or normalized name)
+// @Description with overlapping periods. wasted is the overpayment up to the current month
+// @Tags reports
+// @Produce json
+// @Param user_id query string false "Filter by user ID"
+// @Param X-Timezone header string false "IANA timezone, e.g. Europe/Moscow"
+// @Success 200 {array} DuplicateResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/reports/duplicates [get]
+func (h *SubscriptionHandler) duplicates(c *gin.Context) {
+	userID, err := parseOptionalID(c.Query("user_id"), "user_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	duplicates, err := h.service.Duplicates(c.Request.Context(), domain.SubscriptionFilter{UserID: userID})
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to find duplicates", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	loc := requestLocation(c)
+	resp := make([]DuplicateResponse, len(duplicates))
+	for i, dup := range duplicates {
+		resp[i] = DuplicateResponse{
+			UserID:        dup.UserID.String(),
+			ServiceName:   dup.ServiceName,
+			ServiceID:     optionalIDString(dup.ServiceID),
+			Subscriptions: make([]SubscriptionResponse, len(dup.Subscriptions)),
+			From:          dup.From.Format("2006-01"),
+			Months:        dup.Months,
+			Wasted:        dup.Wasted,
+			Ongoing:       dup.Ongoing,
+		}
+		for j := range dup.Subscriptions {
+			resp[i].Subscriptions[j] = toResponse(&dup.Subscriptions[j], loc)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+const maxImportSize = 10 << 20
+
+// uploadedFile возвращает загружаемый файл: поле "file" multipart-формы или тело запроса целиком
+func uploadedFile(c *gin.Context, limit int64) (io.ReadCloser, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+
+	if c.ContentType() != "multipart/form-data" {
+		return c.Request.Body, nil
+	}
+
+	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return nil, errors.New("file is required")
+	}
+
+	return file.Open()
+}
+
+// @Summary Import subscriptions from CSV
+// @Description Accepts CSV as request body or multipart field "file". Dates use MM-YYYY.
+// @Description Valid rows are inserted in one transaction, dry_run only validates.
+// @Tags subscriptions
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Param dry_run query bool false "Validate without saving"
+// @Param delimiter query string false "Field delimiter" default(,)
+// @Param service_name_column query string false "Header of service name column" default(service_name)
+// @Param price_column query string false "Header of price column" default(price)
+// @Param user_id_column query string false "Header of user ID column" default(user_id)
+// @Param start_date_column query string false "Header of start date column" default(start_date)
+// @Param end_date_column query string false "Header of end date column" default(end_date)
+// @Success 200 {object} ImportResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/subscriptions/import [post]
+func (h *SubscriptionHandler) importCSV(c *gin.Context) {
+	var req ImportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	mapping := importer.DefaultMapping()
+	if req.ServiceNameColumn != "" {
+		mapping.ServiceName = req.ServiceNameColumn
+	}
+	if req.PriceColumn != "" {
+		mapping.Price = req.PriceColumn
+	}
+	if req.UserIDColumn != "" {
+		mapping.UserID = req.UserIDColumn
+	}
+	if req.StartDateColumn != "" {
+		mapping.StartDate = req.StartDateColumn
+	}
+	if req.EndDateColumn != "" {
+		mapping.EndDate = req.EndDateColumn
+	}
+
+	delimiter := ','
+	if req.Delimiter != "" {
+		delimiter = rune(req.Delimiter[0])
+	}
+
+	body, err := uploadedFile(c, maxImportSize)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+	defer body.Close()
+
+	rows, err := importer.ParseCSV(body, mapping, delimiter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
 		return
 	}
 
-	c.JSON(http.StatusOK, TotalCostResponse{Total: total})
+	result, err := h.service.Import(c.Request.Context(), rows, req.DryRun)
+	if err != nil {
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to import subscriptions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+		return
+	}
+
+	resp := ImportResponse{
+		DryRun:   result.DryRun,
+		Total:    result.Total,
+		Valid:    result.Valid,
+		Imported: result.Imported,
+		Errors:   make([]ImportRowErrorResponse, len(result.Errors)),
+	}
+	for i, rowErr := range result.Errors {
+		resp.Errors[i] = ImportRowErrorResponse{Line: rowErr.Line, Error: rowErr.Error}
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessChecker сообщает, готов ли сервис принимать трафик
+type ReadinessChecker interface {
+	Ready(ctx context.Context) domain.Readiness
+}
+
+// WithReadiness включает проверки готовности в /readyz; без него /readyz повторяет /healthz
+func WithReadiness(checker ReadinessChecker) HandlerOption {
+	return func(h *SubscriptionHandler) {
+		h.readiness = checker
+	}
+}
+
+type HealthResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+type ReadinessResponse struct {
+	Status string            `json:"status" example:"ok"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// registerProbes добавляет зонды и /metrics в корень, до журнала и учета запросов:
+// их вызывают каждые несколько секунд
+func (h *SubscriptionHandler) registerProbes(router gin.IRoutes) {
+	router.GET("/healthz", h.healthz)
+	router.GET("/readyz", h.readyz)
+
+	if h.metrics != nil {
+		router.GET("/metrics", gin.WrapH(h.metrics.Handler()))
+	}
+}
+
+// @Summary Liveness probe
+// @Description Answers while the process is able to serve HTTP
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Router /healthz [get]
+func (h *SubscriptionHandler) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// @Summary Readiness probe
+// @Description Checks the database connection and migration state; fails as soon as shutdown starts
+// @Tags health
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /readyz [get]
+func (h *SubscriptionHandler) readyz(c *gin.Context) {
+	if h.readiness == nil {
+		c.JSON(http.StatusOK, ReadinessResponse{Status: "ok"})
+		return
+	}
+
+	readiness := h.readiness.Ready(c.Request.Context())
+	if !readiness.Ready {
+		c.JSON(http.StatusServiceUnavailable, ReadinessResponse{Status: "unavailable", Checks: readiness.Checks})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReadinessResponse{Status: "ok", Checks: readiness.Checks})
+}
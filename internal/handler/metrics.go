@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsRecorder учитывает HTTP-запросы и отдает накопленные метрики
+type MetricsRecorder interface {
+	ObserveHTTP(method, route string, status int, duration time.Duration)
+	Handler() http.Handler
+}
+
+// WithMetrics включает /metrics и учет запросов к API
+func WithMetrics(recorder MetricsRecorder) HandlerOption {
+	return func(h *SubscriptionHandler) {
+		h.metrics = recorder
+	}
+}
+
+// metricsMiddleware учитывает запрос по шаблону маршрута: /api/v1/subscriptions/:id,
+// а не по пути с идентификатором. Запросы без маршрута попадают в "unmatched".
+func (h *SubscriptionHandler) metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		h.metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/ratelimit"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// RateLimiter решает, пропустить ли запрос клиента к маршруту route
+type RateLimiter interface {
+	Allow(ctx context.Context, route string, client ratelimit.Client) (ratelimit.Decision, error)
+}
+
+// WithRateLimiter включает ограничение частоты запросов к API
+func WithRateLimiter(limiter RateLimiter) HandlerOption {
+	return func(h *SubscriptionHandler) {
+		h.limiter = limiter
+	}
+}
+
+// WithTrustedProxies задает адреса и подсети прокси, от которых принимается X-Forwarded-For.
+// Без них адрес клиента - адрес соединения: иначе клиент подставит любой IP в заголовок.
+func WithTrustedProxies(proxies []string) HandlerOption {
+	return func(h *SubscriptionHandler) {
+		h.trustedProxies = proxies
+	}
+}
+
+// rateLimitMiddleware отвечает 429 с Retry-After, когда клиент исчерпал лимит маршрута,
+// и сообщает остаток в заголовках RateLimit-*. Если хранилище лимитов недоступно,
+// запрос пропускается: лучше не ограничить, чем отказать всем.
+func (h *SubscriptionHandler) rateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		decision, err := h.limiter.Allow(c.Request.Context(), c.FullPath(), requestClient(c))
+		if err != nil {
+			applogger.FromContext(c.Request.Context(), h.logger).Warn("rate limit check failed", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if decision.Limit > 0 {
+			c.Header("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			c.Header("RateLimit-Reset", seconds(decision.Reset))
+		}
+
+		if !decision.Allowed {
+			c.Header("Retry-After", seconds(decision.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse(c, "rate limit exceeded"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// requestClient - признаки клиента, из которых правило маршрута выбирает корзину:
+// API-ключ (по хешу), пользователь из пути или user_id и IP-адрес. X-Forwarded-For
+// учитывается только от доверенных прокси (WithTrustedProxies), иначе берется адрес соединения.
+func requestClient(c *gin.Context) ratelimit.Client {
+	client := ratelimit.Client{IP: c.ClientIP()}
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		client.APIKey = hex.EncodeToString(sum[:16])
+	}
+	if userID, ok := requestUserID(c); ok {
+		client.UserID = userID.String()
+	}
+	return client
+}
+
+// seconds округляет вверх до целых секунд, как принято в Retry-After и RateLimit-Reset
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
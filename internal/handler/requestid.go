@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+
+	// maxRequestIDLength ограничивает принятый от клиента идентификатор
+	maxRequestIDLength = 128
+)
+
+// requestIDMiddleware берет X-Request-ID из запроса или создает новый, возвращает его
+// в ответе и кладет в контекст запроса логгер с request_id и трейсом
+func (h *SubscriptionHandler) requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+
+		ctx := c.Request.Context()
+		fields := append([]zap.Field{zap.String("request_id", id)}, applogger.TraceFields(ctx)...)
+		c.Request = c.Request.WithContext(applogger.WithContext(ctx, h.logger.With(fields...)))
+
+		c.Next()
+	}
+}
+
+// validRequestID пропускает только печатные ASCII-символы, чтобы идентификатор
+// клиента не ломал журнал и заголовки ответа
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestID - идентификатор текущего запроса, пусто вне requestIDMiddleware
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// errorResponse - тело ошибки с идентификатором запроса для поиска в журнале
+func errorResponse(c *gin.Context, msg string) ErrorResponse {
+	return ErrorResponse{Error: msg, RequestID: requestID(c)}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	lastEventIDHeader = "Last-Event-ID"
+	// streamRetry - через сколько миллисекунд EventSource переподключается после обрыва
+	streamRetry = 3000
+	// defaultStreamHeartbeat - интервал heartbeat, если он не задан
+	defaultStreamHeartbeat = 15 * time.Second
+)
+
+type StreamHandler struct {
+	service   *service.StreamService
+	heartbeat time.Duration
+	logger    *zap.Logger
+}
+
+// NewStreamHandler отдает поток изменений подписок; heartbeat - как часто слать комментарий,
+// чтобы прокси не закрывали простаивающее соединение; 0 и меньше - defaultStreamHeartbeat
+func NewStreamHandler(service *service.StreamService, heartbeat time.Duration, logger *zap.Logger) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = defaultStreamHeartbeat
+	}
+	return &StreamHandler{
+		service:   service,
+		heartbeat: heartbeat,
+		logger:    logger,
+	}
+}
+
+func (h *StreamHandler) RegisterRoutes(api *gin.RouterGroup) {
+	api.GET("/subscriptions/stream", h.stream)
+}
+
+// @Summary Stream subscription changes
+// @Description Server-Sent Events: subscription.created, subscription.updated, subscription.deleted and
+// @Description budget.threshold_crossed from every API instance. Each event has "id" (outbox event id), "event"
+// @Description (type) and "data" (the event as JSON, "data" field holds the subscription or the budget alert;
+// @Description service_name filters out budget events). After a reconnect the client sends
+// @Description Last-Event-ID and first receives missed events still kept in the bounded history.
+// @Tags subscriptions
+// @Produce text/event-stream
+// @Param user_id query string false "Owner ID (UUID)"
+// @Param service_name query string false "Service name (partial match, case-insensitive)"
+// @Param Last-Event-ID header int false "ID of the last received event"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} ErrorResponse
+// @Failure 429 {object} ErrorResponse
+// @Router /api/v1/subscriptions/stream [get]
+func (h *StreamHandler) stream(c *gin.Context) {
+	var req StreamRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	filter := service.StreamFilter{ServiceName: req.ServiceName}
+	if req.UserID != nil {
+		userID, err := uuid.Parse(*req.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "invalid user_id"))
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	var lastEventID *int64
+	if header := c.GetHeader(lastEventIDHeader); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, errorResponse(c, "invalid "+lastEventIDHeader+" header"))
+			return
+		}
+		lastEventID = &id
+	}
+
+	replay, events, cancel := h.service.Subscribe(filter, lastEventID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// nginx иначе буферизует ответ и события приходят пачками
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if _, err := fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry); err != nil {
+		return
+	}
+	for _, event := range replay {
+		if err := writeEvent(c, event); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(c *gin.Context, event domain.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// TaxonomyHandler - справочники категорий и тегов
+type TaxonomyHandler struct {
+	categories *service.CategoryService
+	tags       *service.TagService
+	logger     *zap.Logger
+}
+
+func NewTaxonomyHandler(categories *service.CategoryService, tags *service.TagService, logger *zap.Logger) *TaxonomyHandler {
+	return &TaxonomyHandler{
+		categories: categories,
+		tags:       tags,
+		logger:     logger,
+	}
+}
+
+func (h *TaxonomyHandler) RegisterRoutes(api *gin.RouterGroup) {
+	categories := api.Group("/categories")
+	{
+		categories.POST("", h.createCategory)
+		categories.GET("", h.listCategories)
+		categories.GET("/:id", h.getCategory)
+		categories.PUT("/:id", h.updateCategory)
+		categories.DELETE("/:id", h.deleteCategory)
+	}
+
+	tags := api.Group("/tags")
+	{
+		tags.GET("", h.listTags)
+		tags.PUT("/:id", h.renameTag)
+		tags.DELETE("/:id", h.deleteTag)
+	}
+}
+
+func toCategoryResponse(c *domain.Category) CategoryResponse {
+	return CategoryResponse{
+		ID:        c.ID.String(),
+		Name:      c.Name,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func toTagResponse(t *domain.Tag) TagResponse {
+	return TagResponse{
+		ID:            t.ID.String(),
+		Name:          t.Name,
+		Subscriptions: t.Subscriptions,
+		CreatedAt:     t.CreatedAt,
+	}
+}
+
+// @Summary Create category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param category body CategoryRequest true "Category data"
+// @Success 201 {object} CategoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/categories [post]
+func (h *TaxonomyHandler) createCategory(c *gin.Context) {
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	category := &domain.Category{Name: req.Name}
+	if err := h.categories.Create(c.Request.Context(), category); err != nil {
+		h.taxonomyError(c, err, "category not found")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toCategoryResponse(category))
+}
+
+// @Summary List categories
+// @Tags categories
+// @Produce json
+// @Success 200 {array} CategoryResponse
+// @Router /api/v1/categories [get]
+func (h *TaxonomyHandler) listCategories(c *gin.Context) {
+	categories, err := h.categories.List(c.Request.Context())
+	if err != nil {
+		h.taxonomyError(c, err, "")
+		return
+	}
+
+	resp := make([]CategoryResponse, len(categories))
+	for i := range categories {
+		resp[i] = toCategoryResponse(&categories[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get category by ID
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} CategoryResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/categories/{id} [get]
+func (h *TaxonomyHandler) getCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	category, err := h.categories.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.taxonomyError(c, err, "category not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, toCategoryResponse(category))
+}
+
+// @Summary Rename category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param category body CategoryRequest true "Category data"
+// @Success 200 {object} CategoryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/categories/{id} [put]
+func (h *TaxonomyHandler) updateCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	var req CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	category := &domain.Category{ID: id, Name: req.Name}
+	if err := h.categories.Update(c.Request.Context(), category); err != nil {
+		h.taxonomyError(c, err, "category not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, toCategoryResponse(category))
+}
+
+// @Summary Delete category
+// @Description Subscriptions of the category are left without a category
+// @Tags categories
+// @Param id path string true "Category ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/categories/{id} [delete]
+func (h *TaxonomyHandler) deleteCategory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	if err := h.categories.Delete(c.Request.Context(), id); err != nil {
+		h.taxonomyError(c, err, "category not found")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List tags
+// @Description Returns all tags with the number of subscriptions using each
+// @Tags tags
+// @Produce json
+// @Success 200 {array} TagResponse
+// @Router /api/v1/tags [get]
+func (h *TaxonomyHandler) listTags(c *gin.Context) {
+	tags, err := h.tags.List(c.Request.Context())
+	if err != nil {
+		h.taxonomyError(c, err, "")
+		return
+	}
+
+	resp := make([]TagResponse, len(tags))
+	for i := range tags {
+		resp[i] = toTagResponse(&tags[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Rename tag
+// @Description Renames the tag on all subscriptions at once
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path string true "Tag ID"
+// @Param tag body RenameTagRequest true "New name"
+// @Success 200 {object} TagResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/tags/{id} [put]
+func (h *TaxonomyHandler) renameTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	var req RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	tag, err := h.tags.Rename(c.Request.Context(), id, req.Name)
+	if err != nil {
+		h.taxonomyError(c, err, "tag not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, toTagResponse(tag))
+}
+
+// @Summary Delete tag
+// @Description Removes the tag from all subscriptions
+// @Tags tags
+// @Param id path string true "Tag ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/tags/{id} [delete]
+func (h *TaxonomyHandler) deleteTag(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	if err := h.tags.Delete(c.Request.Context(), id); err != nil {
+		h.taxonomyError(c, err, "tag not found")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TaxonomyHandler) taxonomyError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(c, notFound))
+	case errors.Is(err, service.ErrInvalidTaxonomy):
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+	case errors.Is(err, service.ErrDuplicateName):
+		c.JSON(http.StatusConflict, errorResponse(c, err.Error()))
+	default:
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to process taxonomy request", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// timezoneHeader - часовой пояс клиента в формате IANA, например Europe/Moscow
+const timezoneHeader = "X-Timezone"
+
+// TimezoneResolver возвращает часовой пояс из настроек пользователя
+type TimezoneResolver interface {
+	Location(ctx context.Context, userID uuid.UUID) (*time.Location, error)
+}
+
+// HandlerOption настраивает необязательные зависимости SubscriptionHandler
+type HandlerOption func(*SubscriptionHandler)
+
+// WithUserTimezones берет часовой пояс из настроек пользователя, если клиент не передал X-Timezone
+func WithUserTimezones(users TimezoneResolver) HandlerOption {
+	return func(h *SubscriptionHandler) {
+		h.timezones = users
+	}
+}
+
+// timezoneMiddleware определяет часовой пояс запроса: заголовок X-Timezone, иначе
+// настройка пользователя из пути /users/:id или параметра user_id, иначе UTC.
+// Выбранный пояс возвращается в том же заголовке ответа.
+func (h *SubscriptionHandler) timezoneMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		loc := time.UTC
+
+		if name := c.GetHeader(timezoneHeader); name != "" {
+			var err error
+			if loc, err = time.LoadLocation(name); err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(c, "invalid "+timezoneHeader+" header"))
+				return
+			}
+		} else if userID, ok := requestUserID(c); ok && h.timezones != nil {
+			// неизвестного пользователя обработает сам запрос
+			if userLoc, err := h.timezones.Location(c.Request.Context(), userID); err == nil {
+				loc = userLoc
+			}
+		}
+
+		c.Request = c.Request.WithContext(service.WithLocation(c.Request.Context(), loc))
+		c.Header(timezoneHeader, loc.String())
+		c.Next()
+	}
+}
+
+// requestUserID - пользователь, к которому относится запрос
+func requestUserID(c *gin.Context) (uuid.UUID, bool) {
+	raw := c.Query("user_id")
+	if strings.HasPrefix(c.FullPath(), "/api/v1/users/:id") {
+		raw = c.Param("id")
+	}
+
+	id, err := uuid.Parse(raw)
+	return id, err == nil
+}
+
+// requestLocation - часовой пояс, выбранный timezoneMiddleware
+func requestLocation(c *gin.Context) *time.Location {
+	return service.LocationFrom(c.Request.Context())
+}
+
+// parseDay разбирает границу периода: дату YYYY-MM-DD как есть или момент времени
+// RFC 3339, который переводится в календарную дату часового пояса loc
+func parseDay(value string, loc *time.Location) (time.Time, error) {
+	if day, err := time.Parse("2006-01-02", value); err == nil {
+		return day, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type UserHandler struct {
+	service *service.UserService
+	logger  *zap.Logger
+}
+
+func NewUserHandler(service *service.UserService, logger *zap.Logger) *UserHandler {
+	return &UserHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *UserHandler) RegisterRoutes(api *gin.RouterGroup) {
+	users := api.Group("/users")
+	{
+		users.POST("", h.create)
+		users.GET("", h.list)
+		users.GET("/:id", h.getByID)
+		users.PUT("/:id", h.update)
+		users.DELETE("/:id", h.delete)
+		users.GET("/:id/summary", h.summary)
+	}
+}
+
+func toUserResponse(u *domain.User) UserResponse {
+	return UserResponse{
+		ID:        u.ID.String(),
+		Name:      u.Name,
+		Email:     u.Email,
+		Currency:  u.Currency,
+		Timezone:  u.Timezone,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+// @Summary Create user
+// @Description currency defaults to RUB, timezone to UTC
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body UserRequest true "User data"
+// @Success 201 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/users [post]
+func (h *UserHandler) create(c *gin.Context) {
+	var req UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	user := &domain.User{Name: req.Name, Email: req.Email, Currency: req.Currency, Timezone: req.Timezone}
+	if err := h.service.Create(c.Request.Context(), user); err != nil {
+		h.userError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toUserResponse(user))
+}
+
+// @Summary List users
+// @Tags users
+// @Produce json
+// @Success 200 {array} UserResponse
+// @Router /api/v1/users [get]
+func (h *UserHandler) list(c *gin.Context) {
+	users, err := h.service.List(c.Request.Context())
+	if err != nil {
+		h.userError(c, err)
+		return
+	}
+
+	resp := make([]UserResponse, len(users))
+	for i := range users {
+		resp[i] = toUserResponse(&users[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get user by ID
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} UserResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/{id} [get]
+func (h *UserHandler) getByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	user, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.userError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// @Summary Update user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param user body UserRequest true "User data"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/v1/users/{id} [put]
+func (h *UserHandler) update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	var req UserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	user := &domain.User{ID: id, Name: req.Name, Email: req.Email, Currency: req.Currency, Timezone: req.Timezone}
+	if err := h.service.Update(c.Request.Context(), user); err != nil {
+		h.userError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserResponse(user))
+}
+
+// @Summary Delete user
+// @Description Deletes the user together with their subscriptions, budgets and calendar token.
+// @Description Writes subscription.deleted for their subscriptions and subscription.updated for shared ones they leave.
+// @Tags users
+// @Param id path string true "User ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/{id} [delete]
+func (h *UserHandler) delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		h.userError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary User summary
+// @Description Subscription count, subscriptions active in the current month and the month's spend
+// @Description including the user's shares in shared subscriptions
+// @Tags users
+// @Produce json
+// @Param id path string true "User ID"
+// @Param X-Timezone header string false "IANA timezone, e.g. Europe/Moscow"
+// @Success 200 {object} UserSummaryResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/users/{id}/summary [get]
+func (h *UserHandler) summary(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	summary, err := h.service.Summary(c.Request.Context(), id)
+	if err != nil {
+		h.userError(c, err)
+		return
+	}
+
+	resp := UserSummaryResponse{
+		User:                toUserResponse(&summary.User),
+		Subscriptions:       summary.Subscriptions,
+		ActiveCount:         len(summary.ActiveSubscriptions),
+		ActiveSubscriptions: make([]SubscriptionResponse, len(summary.ActiveSubscriptions)),
+		Month:               summary.Month.Format("2006-01"),
+		MonthlySpend:        summary.MonthlySpend,
+	}
+	for i := range summary.ActiveSubscriptions {
+		resp.ActiveSubscriptions[i] = toResponse(&summary.ActiveSubscriptions[i], requestLocation(c))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *UserHandler) userError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(c, "user not found"))
+	case errors.Is(err, service.ErrInvalidUser):
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+	case errors.Is(err, service.ErrEmailTaken):
+		c.JSON(http.StatusConflict, errorResponse(c, err.Error()))
+	default:
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to process user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+	}
+}
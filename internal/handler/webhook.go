@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const defaultDeliveriesLimit = 50
+
+type WebhookHandler struct {
+	service *service.WebhookService
+	logger  *zap.Logger
+}
+
+func NewWebhookHandler(service *service.WebhookService, logger *zap.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *WebhookHandler) RegisterRoutes(api *gin.RouterGroup) {
+	webhooks := api.Group("/webhooks")
+	{
+		webhooks.POST("", h.create)
+		webhooks.GET("", h.list)
+		webhooks.GET("/:id", h.getByID)
+		webhooks.DELETE("/:id", h.delete)
+		webhooks.GET("/:id/deliveries", h.deliveries)
+	}
+}
+
+func toWebhookResponse(w *domain.Webhook) WebhookResponse {
+	events := make([]string, len(w.Events))
+	for i, e := range w.Events {
+		events[i] = string(e)
+	}
+
+	return WebhookResponse{
+		ID:        w.ID.String(),
+		URL:       w.URL,
+		Events:    events,
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+// @Summary Register webhook
+// @Description Events of the selected types are POSTed to the URL as JSON. Each request is signed:
+// @Description X-Webhook-Signature is "sha256=" + hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>" with the secret.
+// @Description Failed deliveries are retried with exponential backoff.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookRequest true "Webhook data; events: subscription.created, subscription.updated, subscription.deleted, subscription.ending_soon, budget.threshold_crossed"
+// @Success 201 {object} WebhookResponse
+// @Failure 400 {object} ErrorResponse
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) create(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+
+	webhook := &domain.Webhook{URL: req.URL, Secret: req.Secret}
+	for _, e := range req.Events {
+		webhook.Events = append(webhook.Events, domain.EventType(e))
+	}
+
+	if err := h.service.Create(c.Request.Context(), webhook); err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toWebhookResponse(webhook))
+}
+
+// @Summary List webhooks
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} WebhookResponse
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) list(c *gin.Context) {
+	webhooks, err := h.service.List(c.Request.Context())
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	resp := make([]WebhookResponse, len(webhooks))
+	for i := range webhooks {
+		resp[i] = toWebhookResponse(&webhooks[i])
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// @Summary Get webhook by ID
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} WebhookResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/webhooks/{id} [get]
+func (h *WebhookHandler) getByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	webhook, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(webhook))
+}
+
+// @Summary Delete webhook
+// @Description Pending deliveries and the delivery log are deleted too
+// @Tags webhooks
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Webhook delivery log
+// @Description Delivery attempts of the webhook, newest first
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "Maximum number of deliveries" default(50)
+// @Success 200 {array} WebhookDeliveryResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) deliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, "invalid id"))
+		return
+	}
+
+	var req WebhookDeliveriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultDeliveriesLimit
+	}
+
+	deliveries, err := h.service.Deliveries(c.Request.Context(), id, req.Limit)
+	if err != nil {
+		h.webhookError(c, err)
+		return
+	}
+
+	resp := make([]WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		resp[i] = WebhookDeliveryResponse{
+			ID:            d.ID,
+			EventID:       d.EventID,
+			EventType:     string(d.EventType),
+			Status:        d.Status,
+			Attempts:      d.Attempts,
+			NextAttemptAt: d.NextAttemptAt,
+			ResponseCode:  d.ResponseCode,
+			LastError:     d.LastError,
+			CreatedAt:     d.CreatedAt,
+			DeliveredAt:   d.DeliveredAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *WebhookHandler) webhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		c.JSON(http.StatusNotFound, errorResponse(c, "webhook not found"))
+	case errors.Is(err, service.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, errorResponse(c, err.Error()))
+	default:
+		applogger.FromContext(c.Request.Context(), h.logger).Error("failed to process webhook", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, errorResponse(c, err.Error()))
+	}
+}
@@ -0,0 +1,292 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transaction - операция из банковской выписки. Amount в рублях, списания отрицательные.
+type Transaction struct {
+	Date   time.Time
+	Amount float64
+	Payee  string
+}
+
+// BankMapping сопоставляет поля операции с заголовками CSV выписки
+type BankMapping struct {
+	Date        string
+	Amount      string
+	Description string
+	// DateLayout - формат даты в нотации Go; пустой - перебор распространенных форматов
+	DateLayout string
+}
+
+func DefaultBankMapping() BankMapping {
+	return BankMapping{
+		Date:        "date",
+		Amount:      "amount",
+		Description: "description",
+	}
+}
+
+var bankDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"02.01.2006",
+	"02.01.2006 15:04:05",
+	"02.01.06",
+	"01/02/2006",
+	"1/2/2006",
+	"01/02/06",
+	"1/2/06",
+	"1/2'06",
+	"1/ 2'06",
+	"20060102",
+}
+
+// ParseBankCSV читает CSV выписку с заголовком. Строки с нераспознанной датой
+// или суммой пропускаются: в выписках часто встречаются итоговые и служебные строки.
+func ParseBankCSV(r io.Reader, mapping BankMapping, delimiter rune) ([]Transaction, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	index := headerIndex(header)
+
+	column := func(name string) (int, error) {
+		i, ok := index[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return -1, fmt.Errorf("column %q not found", name)
+		}
+		return i, nil
+	}
+
+	dateCol, err := column(mapping.Date)
+	if err != nil {
+		return nil, err
+	}
+	amountCol, err := column(mapping.Amount)
+	if err != nil {
+		return nil, err
+	}
+	descCol, err := column(mapping.Description)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []Transaction
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read statement: %w", err)
+		}
+
+		if dateCol >= len(record) || amountCol >= len(record) || descCol >= len(record) {
+			continue
+		}
+
+		date, err := parseBankDate(record[dateCol], mapping.DateLayout)
+		if err != nil {
+			continue
+		}
+		amount, err := parseAmount(record[amountCol])
+		if err != nil {
+			continue
+		}
+
+		txs = append(txs, Transaction{Date: date, Amount: amount, Payee: strings.TrimSpace(record[descCol])})
+	}
+
+	return txs, nil
+}
+
+var ofxTag = regexp.MustCompile(`<(/?)([A-Za-z0-9.]+)>([^<]*)`)
+
+// ParseOFX извлекает операции STMTTRN из OFX 1.x (SGML) и 2.x (XML)
+func ParseOFX(r io.Reader) ([]Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read statement: %w", err)
+	}
+
+	var (
+		txs     []Transaction
+		current *Transaction
+		name    string
+		memo    string
+		valid   bool
+	)
+
+	finish := func() {
+		if current != nil && valid {
+			current.Payee = strings.TrimSpace(name)
+			if current.Payee == "" {
+				current.Payee = strings.TrimSpace(memo)
+			}
+			txs = append(txs, *current)
+		}
+		current, name, memo, valid = nil, "", "", false
+	}
+
+	for _, m := range ofxTag.FindAllStringSubmatch(string(data), -1) {
+		closing, tag, value := m[1] == "/", strings.ToUpper(m[2]), strings.TrimSpace(m[3])
+
+		if tag == "STMTTRN" {
+			finish()
+			if !closing {
+				current = &Transaction{}
+			}
+			continue
+		}
+		if current == nil || closing {
+			continue
+		}
+
+		switch tag {
+		case "DTPOSTED":
+			// 20250105120000.000[+3:MSK] - берем только дату
+			if len(value) >= 8 {
+				if date, err := time.Parse("20060102", value[:8]); err == nil {
+					current.Date = date
+					valid = true
+				}
+			}
+		case "TRNAMT":
+			amount, err := parseAmount(value)
+			if err != nil {
+				valid = false
+				continue
+			}
+			current.Amount = amount
+		case "NAME", "PAYEE":
+			name = value
+		case "MEMO":
+			memo = value
+		}
+	}
+	finish()
+
+	if len(txs) == 0 {
+		return nil, errors.New("no transactions found")
+	}
+
+	return txs, nil
+}
+
+// ParseQIF читает Quicken Interchange Format: записи D/T/P/M, разделенные "^"
+func ParseQIF(r io.Reader) ([]Transaction, error) {
+	scanner := bufio.NewScanner(r)
+
+	var (
+		txs     []Transaction
+		current Transaction
+		memo    string
+		hasDate bool
+		hasAmt  bool
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		code, value := line[0], strings.TrimSpace(line[1:])
+		switch code {
+		case '!':
+			continue
+		case 'D':
+			date, err := parseBankDate(value, "")
+			hasDate = err == nil
+			current.Date = date
+		case 'T', 'U':
+			amount, err := parseAmount(value)
+			hasAmt = err == nil
+			current.Amount = amount
+		case 'P':
+			current.Payee = value
+		case 'M':
+			memo = value
+		case '^':
+			if current.Payee == "" {
+				current.Payee = memo
+			}
+			if hasDate && hasAmt {
+				txs = append(txs, current)
+			}
+			current, memo, hasDate, hasAmt = Transaction{}, "", false, false
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read statement: %w", err)
+	}
+	if len(txs) == 0 {
+		return nil, errors.New("no transactions found")
+	}
+
+	return txs, nil
+}
+
+func parseBankDate(value, layout string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if layout != "" {
+		return time.Parse(layout, value)
+	}
+
+	for _, l := range bankDateLayouts {
+		if date, err := time.Parse(l, value); err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unknown date format %q", value)
+}
+
+// parseAmount понимает "-1 234,56", "-399.00 RUB", "1,234.56" и знак минуса U+2212
+func parseAmount(value string) (float64, error) {
+	value = strings.ReplaceAll(value, "\u2212", "-")
+
+	var b strings.Builder
+	for _, r := range value {
+		if (r >= '0' && r <= '9') || r == '-' || r == '+' || r == '.' || r == ',' {
+			b.WriteRune(r)
+		}
+	}
+	s := b.String()
+
+	// последний из разделителей считается десятичным, остальные - разделители разрядов
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		intPart := strings.NewReplacer(".", "", ",", "").Replace(s[:i])
+		s = intPart + "." + s[i+1:]
+	}
+
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+
+	return amount, nil
+}
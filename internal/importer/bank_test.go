@@ -0,0 +1,64 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBankCSV(t *testing.T) {
+	data := "Дата;Сумма;Описание\n" +
+		"05.01.2025;-399,00;YANDEX*PLUS 4567\n" +
+		"Итого;;\n" +
+		"07.01.2025;1 500,50;Перевод\n"
+
+	mapping := BankMapping{Date: "Дата", Amount: "Сумма", Description: "Описание"}
+
+	txs, err := ParseBankCSV(strings.NewReader(data), mapping, ';')
+
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	assert.Equal(t, -399.0, txs[0].Amount)
+	assert.Equal(t, "YANDEX*PLUS 4567", txs[0].Payee)
+	assert.Equal(t, 1500.5, txs[1].Amount)
+}
+
+func TestParseOFX(t *testing.T) {
+	data := `OFXHEADER:100
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20250105120000.000[+3:MSK]
+<TRNAMT>-399.00
+<NAME>NETFLIX.COM
+</STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250205</DTPOSTED><TRNAMT>-399.00</TRNAMT><MEMO>NETFLIX.COM 123</MEMO></STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>`
+
+	txs, err := ParseOFX(strings.NewReader(data))
+
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	assert.Equal(t, "NETFLIX.COM", txs[0].Payee)
+	assert.Equal(t, "NETFLIX.COM 123", txs[1].Payee)
+	assert.Equal(t, -399.0, txs[1].Amount)
+}
+
+func TestParseQIF(t *testing.T) {
+	data := "!Type:Bank\nD01/05/2025\nT-399.00\nPSpotify\n^\nD2/5'25\nT-1,399.00\nMSpotify Family\n^\n"
+
+	txs, err := ParseQIF(strings.NewReader(data))
+
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), txs[0].Date)
+	assert.Equal(t, "Spotify", txs[0].Payee)
+	assert.Equal(t, time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), txs[1].Date)
+	assert.Equal(t, -1399.0, txs[1].Amount)
+	assert.Equal(t, "Spotify Family", txs[1].Payee)
+}
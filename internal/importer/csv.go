@@ -0,0 +1,184 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+)
+
+// MonthLayout - формат дат MM-YYYY, как в CreateSubscriptionRequest
+const MonthLayout = "01-2006"
+
+// ColumnMapping сопоставляет поля подписки с заголовками CSV
+type ColumnMapping struct {
+	ServiceName string
+	Price       string
+	UserID      string
+	StartDate   string
+	EndDate     string
+}
+
+// DefaultMapping - заголовки совпадают с именами полей API
+func DefaultMapping() ColumnMapping {
+	return ColumnMapping{
+		ServiceName: "service_name",
+		Price:       "price",
+		UserID:      "user_id",
+		StartDate:   "start_date",
+		EndDate:     "end_date",
+	}
+}
+
+// Row - разобранная строка файла; Line считается с учетом заголовка
+type Row struct {
+	Line         int
+	Subscription *domain.Subscription
+	Err          error
+}
+
+// ParseCSV читает CSV с заголовком. Ошибки отдельных строк не прерывают разбор
+// и возвращаются в Row.Err, ошибка функции означает нечитаемый файл.
+func ParseCSV(r io.Reader, mapping ColumnMapping, delimiter rune) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	index := headerIndex(header)
+
+	columns, err := resolveColumns(index, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, Row{Line: line, Err: err})
+				continue
+			}
+			return nil, fmt.Errorf("read line %d: %w", line, err)
+		}
+
+		sub, err := columns.parse(record)
+		rows = append(rows, Row{Line: line, Subscription: sub, Err: err})
+	}
+
+	return rows, nil
+}
+
+// headerIndex сопоставляет нормализованные заголовки с номерами колонок
+func headerIndex(header []string) map[string]int {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	return index
+}
+
+type columnIndex struct {
+	serviceName, price, userID, startDate, endDate int
+}
+
+func resolveColumns(index map[string]int, mapping ColumnMapping) (columnIndex, error) {
+	find := func(name string, required bool) (int, error) {
+		i, ok := index[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			if required {
+				return -1, fmt.Errorf("column %q not found", name)
+			}
+			return -1, nil
+		}
+		return i, nil
+	}
+
+	var c columnIndex
+	var err error
+	if c.serviceName, err = find(mapping.ServiceName, true); err != nil {
+		return c, err
+	}
+	if c.price, err = find(mapping.Price, true); err != nil {
+		return c, err
+	}
+	if c.userID, err = find(mapping.UserID, true); err != nil {
+		return c, err
+	}
+	if c.startDate, err = find(mapping.StartDate, true); err != nil {
+		return c, err
+	}
+	if c.endDate, err = find(mapping.EndDate, false); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (c columnIndex) parse(record []string) (*domain.Subscription, error) {
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	serviceName := field(c.serviceName)
+	if serviceName == "" {
+		return nil, errors.New("service_name is required")
+	}
+
+	price, err := strconv.Atoi(field(c.price))
+	if err != nil {
+		return nil, errors.New("invalid price")
+	}
+
+	userID, err := uuid.Parse(field(c.userID))
+	if err != nil {
+		return nil, errors.New("invalid user_id")
+	}
+
+	startDate, err := time.Parse(MonthLayout, field(c.startDate))
+	if err != nil {
+		return nil, errors.New("invalid start_date format")
+	}
+
+	var endDate *time.Time
+	if value := field(c.endDate); value != "" {
+		ed, err := time.Parse(MonthLayout, value)
+		if err != nil {
+			return nil, errors.New("invalid end_date format")
+		}
+		endDate = &ed
+	}
+
+	return &domain.Subscription{
+		ServiceName: serviceName,
+		Price:       price,
+		UserID:      userID,
+		StartDate:   startDate,
+		EndDate:     endDate,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV_CustomMapping(t *testing.T) {
+	data := "Service;Monthly;Owner;From;To\n" +
+		"Yandex Plus;400;60601fee-2bf1-4721-ae6f-7636e79a0cba;07-2025;12-2025\n" +
+		"Netflix;abc;60601fee-2bf1-4721-ae6f-7636e79a0cba;07-2025;\n" +
+		"Spotify;300;60601fee-2bf1-4721-ae6f-7636e79a0cba;2025-07-01;\n"
+
+	mapping := ColumnMapping{
+		ServiceName: "service",
+		Price:       "monthly",
+		UserID:      "owner",
+		StartDate:   "from",
+		EndDate:     "to",
+	}
+
+	rows, err := ParseCSV(strings.NewReader(data), mapping, ';')
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	require.NoError(t, rows[0].Err)
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, "Yandex Plus", rows[0].Subscription.ServiceName)
+	assert.Equal(t, 400, rows[0].Subscription.Price)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), rows[0].Subscription.StartDate)
+	require.NotNil(t, rows[0].Subscription.EndDate)
+	assert.Equal(t, time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), *rows[0].Subscription.EndDate)
+
+	assert.EqualError(t, rows[1].Err, "invalid price")
+	assert.Equal(t, 3, rows[1].Line)
+	assert.EqualError(t, rows[2].Err, "invalid start_date format")
+}
+
+func TestParseCSV_MissingColumn(t *testing.T) {
+	_, err := ParseCSV(strings.NewReader("service_name,price\nNetflix,100\n"), DefaultMapping(), ',')
+
+	assert.EqualError(t, err, `column "user_id" not found`)
+}
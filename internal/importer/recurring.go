@@ -0,0 +1,167 @@
+package importer
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	minMonthlyInterval = 25
+	maxMonthlyInterval = 35
+	// разница цен внутри одной подписки, больше - считаем разными тарифами
+	amountTolerance = 0.15
+	// подписка считается действующей, если последнее списание не старше этого срока до конца выписки
+	activeWindowDays = 40
+	merchantTokens   = 3
+)
+
+// Recurring - регулярное ежемесячное списание, найденное в выписке
+type Recurring struct {
+	Merchant    string
+	Amount      int
+	FirstDate   time.Time
+	LastDate    time.Time
+	Occurrences int
+	Active      bool
+	Confidence  float64
+}
+
+type charge struct {
+	date   time.Time
+	amount float64
+	payee  string
+}
+
+// DetectRecurring ищет ежемесячные списания: операции группируются по продавцу
+// и близкой сумме, затем проверяется регулярность интервалов между ними.
+// Если в выписке нет отрицательных сумм, все операции считаются списаниями.
+func DetectRecurring(txs []Transaction) []Recurring {
+	hasNegative := false
+	var statementEnd time.Time
+	for _, tx := range txs {
+		if tx.Amount < 0 {
+			hasNegative = true
+		}
+		if tx.Date.After(statementEnd) {
+			statementEnd = tx.Date
+		}
+	}
+
+	groups := map[string][]charge{}
+	for _, tx := range txs {
+		if hasNegative && tx.Amount >= 0 {
+			continue
+		}
+		key := normalizeMerchant(tx.Payee)
+		if key == "" || tx.Amount == 0 {
+			continue
+		}
+		groups[key] = append(groups[key], charge{date: tx.Date, amount: math.Abs(tx.Amount), payee: tx.Payee})
+	}
+
+	var result []Recurring
+	for _, charges := range groups {
+		for _, cluster := range clusterByAmount(charges) {
+			if rec, ok := evaluate(cluster, statementEnd); ok {
+				result = append(result, rec)
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Confidence != result[j].Confidence {
+			return result[i].Confidence > result[j].Confidence
+		}
+		return result[i].Merchant < result[j].Merchant
+	})
+
+	return result
+}
+
+// normalizeMerchant убирает из назначения платежа номера карт, даты и знаки препинания
+func normalizeMerchant(payee string) string {
+	fields := strings.FieldsFunc(strings.ToLower(payee), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	tokens := make([]string, 0, merchantTokens)
+	for _, f := range fields {
+		if len([]rune(f)) < 2 {
+			continue
+		}
+		tokens = append(tokens, f)
+		if len(tokens) == merchantTokens {
+			break
+		}
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+func clusterByAmount(charges []charge) [][]charge {
+	sort.Slice(charges, func(i, j int) bool { return charges[i].amount < charges[j].amount })
+
+	var clusters [][]charge
+	start := 0
+	for i := 1; i <= len(charges); i++ {
+		if i == len(charges) || charges[i].amount > charges[start].amount*(1+amountTolerance) {
+			clusters = append(clusters, charges[start:i])
+			start = i
+		}
+	}
+
+	return clusters
+}
+
+func evaluate(cluster []charge, statementEnd time.Time) (Recurring, bool) {
+	sort.Slice(cluster, func(i, j int) bool { return cluster[i].date.Before(cluster[j].date) })
+
+	// несколько списаний в один день (повтор, частичный возврат) считаем одним
+	dates := []charge{cluster[0]}
+	for _, c := range cluster[1:] {
+		if !c.date.Equal(dates[len(dates)-1].date) {
+			dates = append(dates, c)
+		}
+	}
+	if len(dates) < 2 {
+		return Recurring{}, false
+	}
+
+	regular := 0
+	for i := 1; i < len(dates); i++ {
+		days := int(dates[i].date.Sub(dates[i-1].date).Hours() / 24)
+		if days >= minMonthlyInterval && days <= maxMonthlyInterval {
+			regular++
+		}
+	}
+	intervalScore := float64(regular) / float64(len(dates)-1)
+	if intervalScore < 0.5 {
+		return Recurring{}, false
+	}
+
+	minAmount, maxAmount := dates[0].amount, dates[0].amount
+	for _, c := range dates {
+		minAmount = math.Min(minAmount, c.amount)
+		maxAmount = math.Max(maxAmount, c.amount)
+	}
+	amountScore := 1 - (maxAmount-minAmount)/maxAmount
+
+	// шесть и более списаний подряд - максимальная уверенность по количеству
+	countScore := math.Min(1, float64(len(dates)-1)/5)
+
+	confidence := 0.5*intervalScore + 0.3*countScore + 0.2*amountScore
+	last := dates[len(dates)-1]
+
+	return Recurring{
+		Merchant:    strings.TrimSpace(last.payee),
+		Amount:      int(math.Round(last.amount)),
+		FirstDate:   dates[0].date,
+		LastDate:    last.date,
+		Occurrences: len(dates),
+		Active:      statementEnd.Sub(last.date) <= activeWindowDays*24*time.Hour,
+		Confidence:  math.Round(confidence*100) / 100,
+	}, true
+}
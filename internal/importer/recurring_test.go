@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDetectRecurring(t *testing.T) {
+	txs := []Transaction{
+		// ежемесячная подписка с номером операции в описании
+		{Date: day(2025, 1, 5), Amount: -399, Payee: "YANDEX*PLUS 1001"},
+		{Date: day(2025, 2, 5), Amount: -399, Payee: "YANDEX*PLUS 1002"},
+		{Date: day(2025, 3, 6), Amount: -399, Payee: "YANDEX*PLUS 1003"},
+		{Date: day(2025, 4, 5), Amount: -449, Payee: "YANDEX*PLUS 1004"},
+		// два тарифа одного продавца
+		{Date: day(2025, 1, 10), Amount: -149, Payee: "APPLE.COM/BILL"},
+		{Date: day(2025, 2, 10), Amount: -149, Payee: "APPLE.COM/BILL"},
+		{Date: day(2025, 1, 12), Amount: -599, Payee: "APPLE.COM/BILL"},
+		{Date: day(2025, 2, 12), Amount: -599, Payee: "APPLE.COM/BILL"},
+		// нерегулярные покупки
+		{Date: day(2025, 1, 3), Amount: -1200, Payee: "PYATEROCHKA 77"},
+		{Date: day(2025, 1, 9), Amount: -1150, Payee: "PYATEROCHKA 77"},
+		{Date: day(2025, 1, 20), Amount: -1250, Payee: "PYATEROCHKA 77"},
+		// поступления не учитываются
+		{Date: day(2025, 1, 25), Amount: 50000, Payee: "SALARY"},
+		{Date: day(2025, 2, 25), Amount: 50000, Payee: "SALARY"},
+	}
+
+	found := DetectRecurring(txs)
+
+	require.Len(t, found, 3)
+
+	yandex := found[0]
+	assert.Equal(t, "YANDEX*PLUS 1004", yandex.Merchant)
+	assert.Equal(t, 449, yandex.Amount)
+	assert.Equal(t, 4, yandex.Occurrences)
+	assert.Equal(t, day(2025, 1, 5), yandex.FirstDate)
+	assert.True(t, yandex.Active)
+	assert.Greater(t, yandex.Confidence, found[1].Confidence)
+
+	amounts := []int{found[1].Amount, found[2].Amount}
+	assert.ElementsMatch(t, []int{149, 599}, amounts)
+	assert.False(t, found[1].Active)
+}
+
+func TestNormalizeMerchant(t *testing.T) {
+	assert.Equal(t, "yandex plus moscow", normalizeMerchant("YANDEX*PLUS 4567 MOSCOW RUS"))
+	assert.Equal(t, "яндекс плюс", normalizeMerchant("Яндекс.Плюс 05.01"))
+	assert.Equal(t, "", normalizeMerchant("1234 5678"))
+}
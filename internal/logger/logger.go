@@ -1 +1,39 @@
 package logger
+
+import (
+	"github.com/SoulStalker/subscribes_api/internal/config"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New создает zap-логгер по настройкам из конфига
+func New(cfg config.LogConfig) *zap.Logger {
+	var zapCfg zap.Config
+
+	if cfg.Encoding == "json" {
+		zapCfg = zap.NewProductionConfig()
+	} else {
+		zapCfg = zap.NewDevelopmentConfig()
+	}
+
+	zapCfg.Level = zap.NewAtomicLevelAt(parseLogLevel(cfg.Level))
+
+	logger, _ := zapCfg.Build()
+	return logger
+}
+
+func parseLogLevel(level string) zapcore.Level {
+	switch level {
+	case "debug":
+		return zap.DebugLevel
+	case "info":
+		return zap.InfoLevel
+	case "warn":
+		return zap.WarnLevel
+	case "error":
+		return zap.ErrorLevel
+	default:
+		return zap.InfoLevel
+	}
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func desc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil)
+}
+
+// poolCollector читает статистику pgxpool при каждом сборе
+type poolCollector struct {
+	stat func() *pgxpool.Stat
+
+	acquired     *prometheus.Desc
+	idle         *prometheus.Desc
+	total        *prometheus.Desc
+	max          *prometheus.Desc
+	acquires     *prometheus.Desc
+	acquireTime  *prometheus.Desc
+	waits        *prometheus.Desc
+	waitTime     *prometheus.Desc
+	canceledWait *prometheus.Desc
+}
+
+func newPoolCollector(stat func() *pgxpool.Stat) *poolCollector {
+	return &poolCollector{
+		stat:         stat,
+		acquired:     desc("db_pool_acquired_conns", "Connections currently in use."),
+		idle:         desc("db_pool_idle_conns", "Idle connections."),
+		total:        desc("db_pool_total_conns", "Open connections."),
+		max:          desc("db_pool_max_conns", "Maximum pool size."),
+		acquires:     desc("db_pool_acquires_total", "Successful connection acquires."),
+		acquireTime:  desc("db_pool_acquire_seconds_total", "Total time spent acquiring connections."),
+		waits:        desc("db_pool_empty_acquires_total", "Acquires that had to wait for a free connection."),
+		waitTime:     desc("db_pool_empty_acquire_wait_seconds_total", "Total time spent waiting for a free connection."),
+		canceledWait: desc("db_pool_canceled_acquires_total", "Acquires canceled by the caller while waiting."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.acquired, c.idle, c.total, c.max, c.acquires, c.acquireTime, c.waits, c.waitTime, c.canceledWait} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(s.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(s.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(s.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(s.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(s.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireTime, prometheus.CounterValue, s.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.waits, prometheus.CounterValue, float64(s.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.waitTime, prometheus.CounterValue, s.EmptyAcquireWaitTime().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.canceledWait, prometheus.CounterValue, float64(s.CanceledAcquireCount()))
+}
+
+var (
+	activeDesc = desc("active_subscriptions", "Subscriptions active in the current month.")
+	spendDesc  = desc("monthly_recurring_spend", "Sum of monthly prices of active subscriptions.")
+	upDesc     = desc("stats_up", "Whether business stats were read on the last scrape.")
+)
+
+// statsCollector запрашивает бизнес-показатели при каждом сборе. Если база
+// не ответила, показатели пропускаются, а stats_up становится 0.
+type statsCollector struct {
+	source StatsSource
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeDesc
+	ch <- spendDesc
+	ch <- upDesc
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	stats, err := c.source.Stats(ctx)
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(activeDesc, prometheus.GaugeValue, float64(stats.Active))
+	ch <- prometheus.MustNewConstMetric(spendDesc, prometheus.GaugeValue, float64(stats.MonthlySpend))
+}
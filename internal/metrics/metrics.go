@@ -0,0 +1,119 @@
+// Package metrics собирает метрики Prometheus: HTTP-запросы, запросы
+// репозиториев, пул соединений и бизнес-показатели.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "subscriptions"
+
+// statsTimeout ограничивает запрос бизнес-показателей при сборе метрик
+const statsTimeout = 2 * time.Second
+
+// StatsSource отдает бизнес-показатели на момент сбора метрик
+type StatsSource interface {
+	Stats(ctx context.Context) (domain.SubscriptionStats, error)
+}
+
+// Metrics - метрики сервиса в собственном реестре
+type Metrics struct {
+	registry      *prometheus.Registry
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+	queryErrors   *prometheus.CounterVec
+	reportCache   *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database call latency by repository method.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"repository", "method"}),
+		queryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Failed database calls by repository method, not counting missing rows.",
+		}, []string{"repository", "method"}),
+		reportCache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "report_cache_requests_total",
+			Help:      "Report cache lookups by report and result (hit or miss).",
+		}, []string{"report", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration, m.queryDuration, m.queryErrors, m.reportCache,
+	)
+
+	return m
+}
+
+// Handler отдает метрики в формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTP учитывает HTTP-запрос; route - шаблон маршрута, а не путь, чтобы не плодить серии
+func (m *Metrics) ObserveHTTP(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveQuery учитывает обращение репозитория к базе. Отсутствие строк - обычный
+// ответ (404), а не сбой, поэтому в ошибки не попадает.
+func (m *Metrics) ObserveQuery(repository, method string, duration time.Duration, err error) {
+	m.queryDuration.WithLabelValues(repository, method).Observe(duration.Seconds())
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		m.queryErrors.WithLabelValues(repository, method).Inc()
+	}
+}
+
+// ObserveCache учитывает обращение к кешу отчетов
+func (m *Metrics) ObserveCache(report string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.reportCache.WithLabelValues(report, result).Inc()
+}
+
+// RegisterPool экспортирует статистику пула соединений
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) {
+	m.registry.MustRegister(newPoolCollector(pool.Stat))
+}
+
+// RegisterStats экспортирует бизнес-показатели; они считаются при каждом сборе метрик
+func (m *Metrics) RegisterStats(source StatsSource) {
+	m.registry.MustRegister(&statsCollector{source: source})
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStats struct {
+	stats domain.SubscriptionStats
+	err   error
+}
+
+func (f fakeStats) Stats(context.Context) (domain.SubscriptionStats, error) {
+	return f.stats, f.err
+}
+
+func TestMetrics_ObserveHTTP(t *testing.T) {
+	m := New()
+
+	m.ObserveHTTP("GET", "/api/v1/subscriptions/:id", 200, 30*time.Millisecond)
+	m.ObserveHTTP("GET", "/api/v1/subscriptions/:id", 404, 10*time.Millisecond)
+	m.ObserveHTTP("GET", "/api/v1/subscriptions/:id", 200, 20*time.Millisecond)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/v1/subscriptions/:id", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/v1/subscriptions/:id", "404")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.httpDuration))
+}
+
+func TestMetrics_ObserveQuery_SkipsNoRows(t *testing.T) {
+	m := New()
+
+	m.ObserveQuery("SubscriptionRepository", "GetByID", time.Millisecond, pgx.ErrNoRows)
+	m.ObserveQuery("SubscriptionRepository", "GetByID", time.Millisecond, errors.New("connection reset"))
+	m.ObserveQuery("SubscriptionRepository", "List", time.Millisecond, nil)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.queryErrors.WithLabelValues("SubscriptionRepository", "GetByID")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.queryErrors.WithLabelValues("SubscriptionRepository", "List")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.queryDuration))
+}
+
+func TestMetrics_ObserveCache(t *testing.T) {
+	m := New()
+
+	m.ObserveCache("total_cost", false)
+	m.ObserveCache("total_cost", true)
+	m.ObserveCache("total_cost", true)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.reportCache.WithLabelValues("total_cost", "hit")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.reportCache.WithLabelValues("total_cost", "miss")))
+}
+
+func TestMetrics_Stats(t *testing.T) {
+	m := New()
+	m.RegisterStats(fakeStats{stats: domain.SubscriptionStats{Active: 12, MonthlySpend: 4800}})
+
+	expected := `
+# HELP subscriptions_active_subscriptions Subscriptions active in the current month.
+# TYPE subscriptions_active_subscriptions gauge
+subscriptions_active_subscriptions 12
+# HELP subscriptions_monthly_recurring_spend Sum of monthly prices of active subscriptions.
+# TYPE subscriptions_monthly_recurring_spend gauge
+subscriptions_monthly_recurring_spend 4800
+# HELP subscriptions_stats_up Whether business stats were read on the last scrape.
+# TYPE subscriptions_stats_up gauge
+subscriptions_stats_up 1
+`
+	require.NoError(t, testutil.GatherAndCompare(m.registry, strings.NewReader(expected),
+		"subscriptions_active_subscriptions", "subscriptions_monthly_recurring_spend", "subscriptions_stats_up"))
+}
+
+func TestMetrics_Stats_SourceDown(t *testing.T) {
+	m := New()
+	m.RegisterStats(fakeStats{err: errors.New("timeout")})
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, rec.Code)
+	assert.Contains(t, rec.Body.String(), "subscriptions_stats_up 0")
+	assert.NotContains(t, rec.Body.String(), "subscriptions_active_subscriptions ")
+}
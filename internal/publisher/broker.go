@@ -0,0 +1,54 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+// Message - сообщение брокера. Key определяет партицию (Kafka) или дополняет тему (NATS),
+// поэтому сообщения с одним ключом читаются в порядке отправки.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// Producer - клиент брокера сообщений. Produce возвращается после подтверждения
+// записи брокером: acks=all в Kafka, PubAck в NATS JetStream. Клиента в проекте
+// нет: реализуйте Producer поверх выбранной библиотеки и передайте его в NewBroker.
+type Producer interface {
+	Produce(ctx context.Context, msg Message) error
+}
+
+// Broker публикует события в тему topic с ключом subscription_id: события одной
+// подписки попадают в одну партицию и сохраняют порядок у потребителей
+type Broker struct {
+	producer Producer
+	topic    string
+}
+
+func NewBroker(producer Producer, topic string) *Broker {
+	return &Broker{producer: producer, topic: topic}
+}
+
+func (p *Broker) Publish(ctx context.Context, event domain.Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	return p.producer.Produce(ctx, Message{
+		Topic: p.topic,
+		Key:   []byte(event.SubscriptionID.String()),
+		Value: value,
+		Headers: map[string]string{
+			"event_id":   strconv.FormatInt(event.ID, 10),
+			"event_type": string(event.Type),
+		},
+	})
+}
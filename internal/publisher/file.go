@@ -0,0 +1,46 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+// File дописывает события в файл по одному JSON на строку. Publish возвращается
+// после fsync, поэтому принятое событие переживает падение процесса.
+type File struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFile(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open events file: %w", err)
+	}
+	return &File{file: f}, nil
+}
+
+func (p *File) Publish(_ context.Context, event domain.Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	line = append(line, '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(line); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+	return p.file.Sync()
+}
+
+func (p *File) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,30 @@
+// Package publisher - издатели событий outbox для service.EventRelay: журнал,
+// файл JSON Lines, HTTP и брокер сообщений через интерфейс Producer.
+package publisher
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+// Log пишет события в журнал сервиса; удобен для отладки и как образец издателя
+type Log struct {
+	logger *zap.Logger
+}
+
+func NewLog(logger *zap.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (p *Log) Publish(_ context.Context, event domain.Event) error {
+	p.logger.Info("event published",
+		zap.Int64("event_id", event.ID),
+		zap.String("type", string(event.Type)),
+		zap.String("subscription_id", event.SubscriptionID.String()),
+		zap.ByteString("data", event.Data),
+	)
+	return nil
+}
@@ -0,0 +1,101 @@
+package publisher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+func testEvent(id int64) domain.Event {
+	return domain.Event{
+		ID:             id,
+		Type:           domain.EventSubscriptionUpdated,
+		SubscriptionID: uuid.MustParse("0b0e6f5c-9d43-4a4e-b1a0-3c2f6a2f1e11"),
+		Data:           json.RawMessage(`{"price":400}`),
+		CreatedAt:      time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestFile_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	p, err := NewFile(path)
+	require.NoError(t, err)
+	require.NoError(t, p.Publish(context.Background(), testEvent(1)))
+	require.NoError(t, p.Publish(context.Background(), testEvent(2)))
+	require.NoError(t, p.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var ids []int64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e domain.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		assert.JSONEq(t, `{"price":400}`, string(e.Data))
+		ids = append(ids, e.ID)
+	}
+	assert.Equal(t, []int64{1, 2}, ids)
+}
+
+func TestHTTP_Publish(t *testing.T) {
+	var got domain.Event
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	p := NewHTTP(srv.URL, map[string]string{"Authorization": "Bearer token"}, srv.Client())
+
+	require.NoError(t, p.Publish(context.Background(), testEvent(7)))
+	assert.Equal(t, int64(7), got.ID)
+	assert.Equal(t, "7", header.Get(EventIDHeader))
+	assert.Equal(t, "subscription.updated", header.Get(EventTypeHeader))
+	assert.Equal(t, "Bearer token", header.Get("Authorization"))
+}
+
+func TestHTTP_Publish_FailsOnNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := NewHTTP(srv.URL, nil, srv.Client()).Publish(context.Background(), testEvent(7))
+
+	assert.EqualError(t, err, "unexpected status 503")
+}
+
+type producerFunc func(ctx context.Context, msg Message) error
+
+func (f producerFunc) Produce(ctx context.Context, msg Message) error { return f(ctx, msg) }
+
+func TestBroker_KeysBySubscription(t *testing.T) {
+	var got Message
+	p := NewBroker(producerFunc(func(_ context.Context, msg Message) error {
+		got = msg
+		return nil
+	}), "subscriptions.events")
+
+	require.NoError(t, p.Publish(context.Background(), testEvent(3)))
+	assert.Equal(t, "subscriptions.events", got.Topic)
+	assert.Equal(t, "0b0e6f5c-9d43-4a4e-b1a0-3c2f6a2f1e11", string(got.Key))
+	assert.Equal(t, "3", got.Headers["event_id"])
+	assert.Equal(t, "subscription.updated", got.Headers["event_type"])
+}
@@ -0,0 +1,216 @@
+// Package ratelimit ограничивает частоту запросов алгоритмом token bucket.
+// Корзины хранятся в Store: в памяти процесса или в общей базе, если
+// экземпляров сервиса несколько.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Limit - скорость пополнения корзины в токенах в секунду и ее емкость.
+// Rate <= 0 снимает ограничение, Burst меньше 1 считается 1.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Bucket - состояние корзины на момент Updated
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Decision - результат попытки взять токен
+type Decision struct {
+	Allowed bool
+	// Limit - емкость корзины; 0, если ограничения нет
+	Limit     int
+	Remaining int
+	// RetryAfter - через сколько появится токен, если запрос отклонен
+	RetryAfter time.Duration
+	// Reset - через сколько корзина наполнится полностью
+	Reset time.Duration
+}
+
+func (l Limit) normalize() Limit {
+	l.Burst = max(l.Burst, 1)
+	return l
+}
+
+// Full - полная корзина на момент now
+func (l Limit) Full(now time.Time) Bucket {
+	return Bucket{Tokens: float64(l.Burst), Updated: now}
+}
+
+// Take пополняет корзину за время с прошлого обращения и берет из нее токен,
+// если он есть. Возвращает новое состояние корзины и решение.
+func (l Limit) Take(b Bucket, now time.Time) (Bucket, Decision) {
+	burst := float64(l.Burst)
+
+	elapsed := now.Sub(b.Updated).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	b.Tokens = math.Min(burst, b.Tokens+elapsed*l.Rate)
+	b.Updated = now
+
+	allowed := b.Tokens >= 1
+	if allowed {
+		b.Tokens--
+	}
+
+	return b, l.Decide(b.Tokens, allowed)
+}
+
+// Decide - решение по корзине, в которой после попытки осталось tokens токенов
+func (l Limit) Decide(tokens float64, allowed bool) Decision {
+	d := Decision{Allowed: allowed, Limit: l.Burst}
+	if !allowed {
+		d.RetryAfter = l.refill(1 - tokens)
+	}
+
+	d.Remaining = int(math.Floor(tokens))
+	d.Reset = l.refill(float64(l.Burst) - tokens)
+	return d
+}
+
+// refill - время, за которое в корзину добавится tokens токенов
+func (l Limit) refill(tokens float64) time.Duration {
+	return time.Duration(math.Ceil(tokens / l.Rate * float64(time.Second)))
+}
+
+// Store хранит корзины по ключу
+type Store interface {
+	// Take атомарно применяет limit.Take к корзине key; отсутствующая корзина считается полной
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Decision, error)
+	// Sweep удаляет корзины, к которым не обращались с before
+	Sweep(ctx context.Context, before time.Time) error
+}
+
+// Способы определить клиента, чью корзину расходует запрос
+const (
+	IdentityIP     = "ip"
+	IdentityAPIKey = "api_key"
+	IdentityUser   = "user"
+)
+
+// ValidIdentity сообщает, известен ли способ определить клиента; пустой означает IP
+func ValidIdentity(identity string) bool {
+	switch identity {
+	case "", IdentityIP, IdentityAPIKey, IdentityUser:
+		return true
+	}
+	return false
+}
+
+// Client - признаки клиента запроса; пустое поле значит, что признака в запросе нет
+type Client struct {
+	// APIKey - отпечаток ключа, чтобы сам ключ не попал в хранилище
+	APIKey string
+	UserID string
+	IP     string
+}
+
+// key - идентификатор клиента для способа identity; без нужного признака - IP-адрес
+func (c Client) key(identity string) string {
+	switch {
+	case identity == IdentityAPIKey && c.APIKey != "":
+		return "key:" + c.APIKey
+	case identity == IdentityUser && c.UserID != "":
+		return "user:" + c.UserID
+	}
+	return "ip:" + c.IP
+}
+
+// Rule - ограничение для маршрутов, шаблон которых начинается с Prefix.
+// Identity - по какому признаку клиента вести корзины, пустой - по IP.
+type Rule struct {
+	Prefix   string
+	Limit    Limit
+	Identity string
+}
+
+// Limiter выбирает правило по маршруту и ведет по корзине на пару правило-клиент
+type Limiter struct {
+	store    Store
+	rules    []Rule
+	fallback Limit
+	idle     time.Duration
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+// New создает Limiter; fallback действует для маршрутов без своего правила.
+// Из правил, подходящих маршруту, выбирается с самым длинным префиксом.
+func New(store Store, fallback Limit, rules []Rule, logger *zap.Logger) *Limiter {
+	fallback = fallback.normalize()
+	sorted := make([]Rule, len(rules))
+	for i, rule := range rules {
+		rule.Limit = rule.Limit.normalize()
+		sorted[i] = rule
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Prefix) > len(sorted[j].Prefix) })
+
+	l := &Limiter{store: store, rules: sorted, fallback: fallback, logger: logger, now: time.Now}
+
+	// корзина, не тронутая дольше полного пополнения, ничем не отличается от новой
+	for _, limit := range append([]Limit{fallback}, limitsOf(sorted)...) {
+		if limit.Rate > 0 {
+			l.idle = max(l.idle, limit.refill(float64(limit.Burst)))
+		}
+	}
+
+	return l
+}
+
+func limitsOf(rules []Rule) []Limit {
+	limits := make([]Limit, len(rules))
+	for i, rule := range rules {
+		limits[i] = rule.Limit
+	}
+	return limits
+}
+
+// Allow берет токен для клиента на маршруте route (шаблон gin). Клиент определяется
+// способом из правила маршрута; маршруты без правила считаются по IP.
+func (l *Limiter) Allow(ctx context.Context, route string, client Client) (Decision, error) {
+	rule := l.match(route)
+	if rule.Limit.Rate <= 0 {
+		return Decision{Allowed: true}, nil
+	}
+
+	return l.store.Take(ctx, rule.Prefix+"|"+client.key(rule.Identity), rule.Limit, l.now())
+}
+
+func (l *Limiter) match(route string) Rule {
+	for _, rule := range l.rules {
+		if strings.HasPrefix(route, rule.Prefix) {
+			return rule
+		}
+	}
+	return Rule{Limit: l.fallback}
+}
+
+// Run раз в interval удаляет из хранилища корзины, которые успели наполниться
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := l.store.Sweep(ctx, l.now().Add(-l.idle)); err != nil && ctx.Err() == nil {
+			l.logger.Error("failed to sweep rate limit buckets", zap.Error(err))
+		}
+	}
+}
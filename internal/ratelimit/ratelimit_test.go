@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var t0 = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestLimit_Take(t *testing.T) {
+	limit := Limit{Rate: 2, Burst: 3}
+	b := limit.Full(t0)
+
+	for i := 2; i >= 0; i-- {
+		var d Decision
+		b, d = limit.Take(b, t0)
+		require.True(t, d.Allowed)
+		assert.Equal(t, 3, d.Limit)
+		assert.Equal(t, i, d.Remaining)
+	}
+
+	b, d := limit.Take(b, t0)
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 500*time.Millisecond, d.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, d.Reset)
+
+	// за полсекунды пополнился один токен
+	_, d = limit.Take(b, t0.Add(500*time.Millisecond))
+	assert.True(t, d.Allowed)
+	assert.Zero(t, d.Remaining)
+}
+
+func TestLimit_Take_RefillCapsAtBurst(t *testing.T) {
+	limit := Limit{Rate: 1, Burst: 2}
+
+	b, _ := limit.Take(Bucket{Tokens: 0, Updated: t0}, t0.Add(time.Hour))
+
+	assert.Equal(t, 1.0, b.Tokens)
+	assert.Equal(t, t0.Add(time.Hour), b.Updated)
+}
+
+func TestLimiter_Allow_PicksLongestPrefix(t *testing.T) {
+	store := NewMemoryStore()
+	limiter := New(store, Limit{Rate: 10, Burst: 10}, []Rule{
+		{Prefix: "/api/v1/subscriptions", Limit: Limit{Rate: 5, Burst: 5}},
+		{Prefix: "/api/v1/subscriptions/total-cost", Limit: Limit{Rate: 1, Burst: 1}},
+	}, zaptest.NewLogger(t))
+	limiter.now = func() time.Time { return t0 }
+	ctx := context.Background()
+
+	d, err := limiter.Allow(ctx, "/api/v1/subscriptions/total-cost", Client{IP: "10.0.0.1"})
+	require.NoError(t, err)
+	assert.True(t, d.Allowed)
+	assert.Equal(t, 1, d.Limit)
+
+	d, err = limiter.Allow(ctx, "/api/v1/subscriptions/total-cost", Client{IP: "10.0.0.1"})
+	require.NoError(t, err)
+	assert.False(t, d.Allowed)
+
+	// другой клиент и другая группа маршрутов расходуют свои корзины
+	d, _ = limiter.Allow(ctx, "/api/v1/subscriptions/total-cost", Client{IP: "10.0.0.2"})
+	assert.True(t, d.Allowed)
+	d, _ = limiter.Allow(ctx, "/api/v1/subscriptions/:id", Client{IP: "10.0.0.1"})
+	assert.Equal(t, 5, d.Limit)
+	d, _ = limiter.Allow(ctx, "/api/v1/users", Client{IP: "10.0.0.1"})
+	assert.Equal(t, 10, d.Limit)
+}
+
+func TestLimiter_Allow_RuleIdentity(t *testing.T) {
+	limiter := New(NewMemoryStore(), Limit{Rate: 1, Burst: 1}, []Rule{
+		{Prefix: "/api/v1/users", Limit: Limit{Rate: 1, Burst: 1}, Identity: IdentityUser},
+		{Prefix: "/api/v1/subscriptions", Limit: Limit{Rate: 1, Burst: 1}, Identity: IdentityAPIKey},
+	}, zaptest.NewLogger(t))
+	limiter.now = func() time.Time { return t0 }
+	ctx := context.Background()
+
+	// один пользователь с разных адресов расходует одну корзину
+	d, _ := limiter.Allow(ctx, "/api/v1/users/:id", Client{UserID: "u1", IP: "10.0.0.1"})
+	assert.True(t, d.Allowed)
+	d, _ = limiter.Allow(ctx, "/api/v1/users/:id", Client{UserID: "u1", IP: "10.0.0.2"})
+	assert.False(t, d.Allowed)
+
+	// разные ключи с одного адреса - разные корзины
+	d, _ = limiter.Allow(ctx, "/api/v1/subscriptions", Client{APIKey: "a", IP: "10.0.0.1"})
+	assert.True(t, d.Allowed)
+	d, _ = limiter.Allow(ctx, "/api/v1/subscriptions", Client{APIKey: "b", IP: "10.0.0.1"})
+	assert.True(t, d.Allowed)
+
+	// без ключа клиент считается по IP, как и на маршрутах без правила
+	d, _ = limiter.Allow(ctx, "/api/v1/subscriptions", Client{IP: "10.0.0.3"})
+	assert.True(t, d.Allowed)
+	d, _ = limiter.Allow(ctx, "/api/v1/subscriptions", Client{IP: "10.0.0.3"})
+	assert.False(t, d.Allowed)
+	d, _ = limiter.Allow(ctx, "/healthz", Client{UserID: "u1", IP: "10.0.0.4"})
+	assert.True(t, d.Allowed)
+	d, _ = limiter.Allow(ctx, "/healthz", Client{UserID: "u2", IP: "10.0.0.4"})
+	assert.False(t, d.Allowed)
+}
+
+func TestLimiter_Allow_Unlimited(t *testing.T) {
+	store := NewMemoryStore()
+	limiter := New(store, Limit{}, nil, zaptest.NewLogger(t))
+
+	d, err := limiter.Allow(context.Background(), "/api/v1/users", Client{IP: "10.0.0.1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, Decision{Allowed: true}, d)
+	assert.Empty(t, store.buckets)
+}
+
+func TestLimiter_IdleIsLongestRefill(t *testing.T) {
+	limiter := New(NewMemoryStore(), Limit{Rate: 10, Burst: 20}, []Rule{
+		{Prefix: "/a", Limit: Limit{Rate: 0.5, Burst: 5}},
+		{Prefix: "/b", Limit: Limit{}},
+	}, zaptest.NewLogger(t))
+
+	assert.Equal(t, 10*time.Second, limiter.idle)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	_, _ = store.Take(ctx, "old", limit, t0)
+	_, _ = store.Take(ctx, "fresh", limit, t0.Add(time.Minute))
+
+	require.NoError(t, store.Sweep(ctx, t0.Add(time.Second)))
+
+	assert.NotContains(t, store.buckets, "old")
+	assert.Contains(t, store.buckets, "fresh")
+}
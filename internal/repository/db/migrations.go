@@ -10,15 +10,21 @@ import (
 	_ "github.com/jackc/pgx/v5"
 )
 
-func RunMigrations(dsn string) error {
+// RunMigrations применяет миграции и возвращает версию схемы, до которой дошла база
+func RunMigrations(dsn string) (uint, error) {
 	m, err := migrate.New("file://./migrations", dsn)
 	if err != nil {
-		return fmt.Errorf("create migrate instance: %w", err)
+		return 0, fmt.Errorf("create migrate instance: %w", err)
 	}
 
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("apply migrations: %w", err)
+		return 0, fmt.Errorf("apply migrations: %w", err)
 	}
 
-	return nil
+	version, _, err := m.Version()
+	if err != nil {
+		return 0, fmt.Errorf("read migration version: %w", err)
+	}
+
+	return version, nil
 }
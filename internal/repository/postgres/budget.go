@@ -0,0 +1,270 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+type BudgetRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewBudgetRepository(db PgxPool, logger *zap.Logger) *BudgetRepository {
+	return &BudgetRepository{db: db, logger: logger}
+}
+
+const budgetColumns = `id, name, user_id, category_id, service_id, period, amount, thresholds, created_at, updated_at`
+
+func scanBudget(row pgx.Row, b *domain.Budget) error {
+	return row.Scan(&b.ID, &b.Name, &b.UserID, &b.CategoryID, &b.ServiceID,
+		&b.Period, &b.Amount, &b.Thresholds, &b.CreatedAt, &b.UpdatedAt)
+}
+
+const alertColumns = `id, budget_id, period_start, threshold, kind, amount, spent, projected, created_at`
+
+func scanAlert(row pgx.Row, a *domain.BudgetAlert) error {
+	return row.Scan(&a.ID, &a.BudgetID, &a.PeriodStart, &a.Threshold, &a.Kind,
+		&a.Amount, &a.Spent, &a.Projected, &a.CreatedAt)
+}
+
+func (r *BudgetRepository) Create(ctx context.Context, b *domain.Budget) error {
+	query := `
+        INSERT INTO budgets (name, user_id, category_id, service_id, period, amount, thresholds)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, created_at, updated_at
+    `
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, b.Name, b.UserID, b.CategoryID, b.ServiceID, b.Period, b.Amount, b.Thresholds).
+		Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to create budget", zap.Error(err))
+		return recordError("create budget", err)
+	}
+
+	r.logger.Info("budget created", zap.String("id", b.ID.String()))
+	return nil
+}
+
+func (r *BudgetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Budget, error) {
+	var b domain.Budget
+	if err := scanBudget(conn(ctx, r.db).QueryRow(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE id = $1`, id), &b); err != nil {
+		return nil, recordError("get budget", err)
+	}
+
+	return &b, nil
+}
+
+// List возвращает бюджеты пользователя или все бюджеты, если userID == nil
+func (r *BudgetRepository) List(ctx context.Context, userID *uuid.UUID) ([]domain.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets`
+	args := []any{}
+
+	if userID != nil {
+		query += ` WHERE user_id = $1`
+		args = append(args, *userID)
+	}
+	query += ` ORDER BY created_at`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	budgets := []domain.Budget{}
+	for rows.Next() {
+		var b domain.Budget
+		if err := scanBudget(rows, &b); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+
+	return budgets, rows.Err()
+}
+
+func (r *BudgetRepository) Update(ctx context.Context, b *domain.Budget) error {
+	query := `
+        UPDATE budgets
+        SET name = $1, user_id = $2, category_id = $3, service_id = $4, period = $5, amount = $6, thresholds = $7
+        WHERE id = $8
+        RETURNING created_at, updated_at
+    `
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, b.Name, b.UserID, b.CategoryID, b.ServiceID, b.Period, b.Amount, b.Thresholds, b.ID).
+		Scan(&b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to update budget", zap.String("id", b.ID.String()), zap.Error(err))
+		return recordError("update budget", err)
+	}
+
+	return nil
+}
+
+func (r *BudgetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM budgets WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete budget", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("delete budget: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// SaveAlert записывает оповещение, если такого еще не было в этом периоде.
+// Возвращает false, если оповещение уже создано раньше.
+func (r *BudgetRepository) SaveAlert(ctx context.Context, a *domain.BudgetAlert) (bool, error) {
+	query := `
+        INSERT INTO budget_alerts (budget_id, period_start, threshold, kind, amount, spent, projected)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (budget_id, period_start, threshold, kind) DO NOTHING
+        RETURNING id, created_at
+    `
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, a.BudgetID, a.PeriodStart, a.Threshold, a.Kind, a.Amount, a.Spent, a.Projected).
+		Scan(&a.ID, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("save budget alert: %w", err)
+	}
+
+	return true, nil
+}
+
+// ListAlerts возвращает оповещения бюджета, новые первыми
+func (r *BudgetRepository) ListAlerts(ctx context.Context, budgetID uuid.UUID) ([]domain.BudgetAlert, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+alertColumns+` FROM budget_alerts WHERE budget_id = $1 ORDER BY created_at DESC`, budgetID)
+	if err != nil {
+		return nil, fmt.Errorf("list budget alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []domain.BudgetAlert{}
+	for rows.Next() {
+		var a domain.BudgetAlert
+		if err := scanAlert(rows, &a); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, rows.Err()
+}
+
+func (r *BudgetRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM budgets`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count budgets: %w", err)
+	}
+	return count, nil
+}
+
+func (r *BudgetRepository) Stream(ctx context.Context, fn func(*domain.Budget) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+budgetColumns+` FROM budgets`)
+	if err != nil {
+		return fmt.Errorf("list budgets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b domain.Budget
+		if err := scanBudget(rows, &b); err != nil {
+			return err
+		}
+		if err := fn(&b); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *BudgetRepository) Restore(ctx context.Context, budgets []domain.Budget) error {
+	query := `INSERT INTO budgets (` + budgetColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, b := range budgets {
+		_, err := tx.Exec(ctx, query, b.ID, b.Name, b.UserID, b.CategoryID, b.ServiceID,
+			b.Period, b.Amount, b.Thresholds, b.CreatedAt, b.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("restore budget %s: %w", b.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *BudgetRepository) CountAlerts(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM budget_alerts`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count budget alerts: %w", err)
+	}
+	return count, nil
+}
+
+func (r *BudgetRepository) StreamAlerts(ctx context.Context, fn func(*domain.BudgetAlert) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+alertColumns+` FROM budget_alerts`)
+	if err != nil {
+		return fmt.Errorf("list budget alerts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a domain.BudgetAlert
+		if err := scanAlert(rows, &a); err != nil {
+			return err
+		}
+		if err := fn(&a); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *BudgetRepository) RestoreAlerts(ctx context.Context, alerts []domain.BudgetAlert) error {
+	query := `INSERT INTO budget_alerts (` + alertColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, a := range alerts {
+		_, err := tx.Exec(ctx, query, a.ID, a.BudgetID, a.PeriodStart, a.Threshold, a.Kind,
+			a.Amount, a.Spent, a.Projected, a.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("restore budget alert %s: %w", a.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
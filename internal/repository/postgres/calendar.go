@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+type CalendarTokenRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewCalendarTokenRepository(db PgxPool, logger *zap.Logger) *CalendarTokenRepository {
+	return &CalendarTokenRepository{db: db, logger: logger}
+}
+
+func (r *CalendarTokenRepository) SaveTokenHash(ctx context.Context, userID uuid.UUID, hash string) error {
+	query := `
+        INSERT INTO calendar_tokens (user_id, token_hash)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = CURRENT_TIMESTAMP
+    `
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, userID, hash); err != nil {
+		r.logger.Error("failed to save calendar token", zap.String("user_id", userID.String()), zap.Error(err))
+		return recordError("save calendar token", err)
+	}
+
+	r.logger.Info("calendar token issued", zap.String("user_id", userID.String()))
+	return nil
+}
+
+func (r *CalendarTokenRepository) GetTokenHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	query := `SELECT token_hash FROM calendar_tokens WHERE user_id = $1`
+
+	var hash string
+	if err := conn(ctx, r.db).QueryRow(ctx, query, userID).Scan(&hash); err != nil {
+		return "", recordError("get calendar token", err)
+	}
+
+	return hash, nil
+}
+
+func (r *CalendarTokenRepository) CountTokens(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM calendar_tokens`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count calendar tokens: %w", err)
+	}
+	return count, nil
+}
+
+func (r *CalendarTokenRepository) StreamTokens(ctx context.Context, fn func(*domain.CalendarToken) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT user_id, token_hash, created_at FROM calendar_tokens`)
+	if err != nil {
+		return fmt.Errorf("list calendar tokens: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token domain.CalendarToken
+		if err := rows.Scan(&token.UserID, &token.TokenHash, &token.CreatedAt); err != nil {
+			return err
+		}
+		if err := fn(&token); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *CalendarTokenRepository) RestoreTokens(ctx context.Context, tokens []domain.CalendarToken) error {
+	query := `INSERT INTO calendar_tokens (user_id, token_hash, created_at) VALUES ($1, $2, $3)`
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, token := range tokens {
+		if _, err := tx.Exec(ctx, query, token.UserID, token.TokenHash, token.CreatedAt); err != nil {
+			return fmt.Errorf("restore calendar token: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
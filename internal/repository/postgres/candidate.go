@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+type CandidateRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewCandidateRepository(db PgxPool, logger *zap.Logger) *CandidateRepository {
+	return &CandidateRepository{db: db, logger: logger}
+}
+
+const candidateColumns = `id, user_id, service_name, price, start_date, end_date, occurrences, confidence, status, subscription_id, created_at`
+
+func scanCandidate(row pgx.Row, c *domain.SubscriptionCandidate) error {
+	return row.Scan(&c.ID, &c.UserID, &c.ServiceName, &c.Price, &c.StartDate, &c.EndDate,
+		&c.Occurrences, &c.Confidence, &c.Status, &c.SubscriptionID, &c.CreatedAt)
+}
+
+// ReplacePending заменяет необработанные предложения пользователя новыми в одной транзакции
+func (r *CandidateRepository) ReplacePending(ctx context.Context, userID uuid.UUID, candidates []*domain.SubscriptionCandidate) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM subscription_candidates WHERE user_id = $1 AND status = 'pending'`, userID); err != nil {
+		return fmt.Errorf("delete pending candidates: %w", err)
+	}
+
+	query := `
+        INSERT INTO subscription_candidates (user_id, service_name, price, start_date, end_date, occurrences, confidence)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id, status, created_at
+    `
+	for _, c := range candidates {
+		err := tx.QueryRow(ctx, query, c.UserID, c.ServiceName, c.Price, c.StartDate, c.EndDate, c.Occurrences, c.Confidence).
+			Scan(&c.ID, &c.Status, &c.CreatedAt)
+		if err != nil {
+			r.logger.Error("failed to create candidate", zap.Error(err))
+			return recordError("create candidate", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	r.logger.Info("candidates saved", zap.String("user_id", userID.String()), zap.Int("count", len(candidates)))
+	return nil
+}
+
+func (r *CandidateRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SubscriptionCandidate, error) {
+	query := `SELECT ` + candidateColumns + ` FROM subscription_candidates WHERE id = $1`
+
+	var c domain.SubscriptionCandidate
+	if err := scanCandidate(conn(ctx, r.db).QueryRow(ctx, query, id), &c); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, domain.ErrNotFound
+		}
+		return nil, fmt.Errorf("get candidate: %w", err)
+	}
+
+	return &c, nil
+}
+
+func (r *CandidateRepository) ListByUser(ctx context.Context, userID uuid.UUID, status string) ([]domain.SubscriptionCandidate, error) {
+	query := `SELECT ` + candidateColumns + ` FROM subscription_candidates WHERE user_id = $1`
+	args := []any{userID}
+
+	if status != "" {
+		query += ` AND status = $2`
+		args = append(args, status)
+	}
+	query += ` ORDER BY confidence DESC, created_at`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list candidates: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := []domain.SubscriptionCandidate{}
+	for rows.Next() {
+		var c domain.SubscriptionCandidate
+		if err := scanCandidate(rows, &c); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
+
+// SetStatus переводит предложение из pending в новый статус. Возвращает false,
+// если предложение уже обработано.
+func (r *CandidateRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, subscriptionID *uuid.UUID) (bool, error) {
+	query := `
+        UPDATE subscription_candidates
+        SET status = $1, subscription_id = $2
+        WHERE id = $3 AND status = 'pending'
+    `
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query, status, subscriptionID, id)
+	if err != nil {
+		r.logger.Error("failed to update candidate", zap.String("id", id.String()), zap.Error(err))
+		return false, fmt.Errorf("update candidate: %w", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// LinkSubscription запоминает подписку, созданную из принятого предложения
+func (r *CandidateRepository) LinkSubscription(ctx context.Context, id, subscriptionID uuid.UUID) error {
+	query := `UPDATE subscription_candidates SET subscription_id = $1 WHERE id = $2`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, subscriptionID, id); err != nil {
+		r.logger.Error("failed to link candidate", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("link candidate: %w", err)
+	}
+	return nil
+}
+
+func (r *CandidateRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM subscription_candidates`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count candidates: %w", err)
+	}
+	return count, nil
+}
+
+func (r *CandidateRepository) Stream(ctx context.Context, fn func(*domain.SubscriptionCandidate) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+candidateColumns+` FROM subscription_candidates`)
+	if err != nil {
+		return fmt.Errorf("list candidates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c domain.SubscriptionCandidate
+		if err := scanCandidate(rows, &c); err != nil {
+			return err
+		}
+		if err := fn(&c); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *CandidateRepository) Restore(ctx context.Context, candidates []domain.SubscriptionCandidate) error {
+	query := `INSERT INTO subscription_candidates (` + candidateColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, c := range candidates {
+		_, err := tx.Exec(ctx, query, c.ID, c.UserID, c.ServiceName, c.Price, c.StartDate, c.EndDate,
+			c.Occurrences, c.Confidence, c.Status, c.SubscriptionID, c.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("restore candidate %s: %w", c.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
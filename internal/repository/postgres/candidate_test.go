@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCandidateRepository_GetByID_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCandidateRepository(mock, zaptest.NewLogger(t))
+	id := uuid.New()
+
+	mock.ExpectQuery("SELECT (.+) FROM subscription_candidates WHERE id").
+		WithArgs(id).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.GetByID(context.Background(), id)
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCandidateRepository_SetStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCandidateRepository(mock, zaptest.NewLogger(t))
+	id := uuid.New()
+	subID := uuid.New()
+
+	mock.ExpectExec(`UPDATE subscription_candidates SET (.+) WHERE id = \$3 AND status = 'pending'`).
+		WithArgs(domain.CandidateAccepted, &subID, id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	ok, err := repo.SetStatus(context.Background(), id, domain.CandidateAccepted, &subID)
+
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCandidateRepository_AcceptInTransaction(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCandidateRepository(mock, zaptest.NewLogger(t))
+	id := uuid.New()
+	subID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE subscription_candidates SET (.+) WHERE id = \$3 AND status = 'pending'`).
+		WithArgs(domain.CandidateAccepted, (*uuid.UUID)(nil), id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(`UPDATE subscription_candidates SET subscription_id = \$1 WHERE id = \$2`).
+		WithArgs(subID, id).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	err = NewTransactor(mock).WithinTx(context.Background(), func(ctx context.Context) error {
+		if _, err := repo.SetStatus(ctx, id, domain.CandidateAccepted, nil); err != nil {
+			return err
+		}
+		return repo.LinkSubscription(ctx, id, subID)
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+type CatalogRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewCatalogRepository(db PgxPool, logger *zap.Logger) *CatalogRepository {
+	return &CatalogRepository{db: db, logger: logger}
+}
+
+const catalogColumns = `id, name, aliases, category, website, default_price, created_at, updated_at`
+
+func scanCatalogEntry(row pgx.Row, e *domain.CatalogEntry) error {
+	return row.Scan(&e.ID, &e.Name, &e.Aliases, &e.Category, &e.Website, &e.DefaultPrice, &e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *CatalogRepository) Create(ctx context.Context, e *domain.CatalogEntry) error {
+	query := `
+        INSERT INTO services (name, aliases, search_keys, category, website, default_price)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at, updated_at
+    `
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, e.Name, e.Aliases, e.SearchKeys(), e.Category, e.Website, e.DefaultPrice).
+		Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to create service", zap.String("name", e.Name), zap.Error(err))
+		return recordError("create service", err)
+	}
+
+	r.logger.Info("service created", zap.String("id", e.ID.String()), zap.String("name", e.Name))
+	return nil
+}
+
+func (r *CatalogRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.CatalogEntry, error) {
+	query := `SELECT ` + catalogColumns + ` FROM services WHERE id = $1`
+
+	var e domain.CatalogEntry
+	if err := scanCatalogEntry(conn(ctx, r.db).QueryRow(ctx, query, id), &e); err != nil {
+		return nil, recordError("get service", err)
+	}
+
+	return &e, nil
+}
+
+// FindByKey ищет сервис, у которого название или синоним нормализуются в key
+func (r *CatalogRepository) FindByKey(ctx context.Context, key string) (*domain.CatalogEntry, error) {
+	query := `SELECT ` + catalogColumns + ` FROM services WHERE $1 = ANY(search_keys) ORDER BY created_at LIMIT 1`
+
+	var e domain.CatalogEntry
+	if err := scanCatalogEntry(conn(ctx, r.db).QueryRow(ctx, query, key), &e); err != nil {
+		return nil, recordError("find service", err)
+	}
+
+	return &e, nil
+}
+
+// FindConflict возвращает другой сервис, который уже занял одно из keys
+func (r *CatalogRepository) FindConflict(ctx context.Context, keys []string, exceptID uuid.UUID) (*domain.CatalogEntry, error) {
+	query := `SELECT ` + catalogColumns + ` FROM services WHERE search_keys && $1 AND id <> $2 LIMIT 1`
+
+	var e domain.CatalogEntry
+	if err := scanCatalogEntry(conn(ctx, r.db).QueryRow(ctx, query, keys, exceptID), &e); err != nil {
+		return nil, recordError("find service conflict", err)
+	}
+
+	return &e, nil
+}
+
+func (r *CatalogRepository) List(ctx context.Context, category string) ([]domain.CatalogEntry, error) {
+	query := `SELECT ` + catalogColumns + ` FROM services`
+	args := []any{}
+
+	if category != "" {
+		query += ` WHERE category = $1`
+		args = append(args, category)
+	}
+	query += ` ORDER BY name`
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list services: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []domain.CatalogEntry{}
+	for rows.Next() {
+		var e domain.CatalogEntry
+		if err := scanCatalogEntry(rows, &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *CatalogRepository) Update(ctx context.Context, e *domain.CatalogEntry) error {
+	query := `
+        UPDATE services
+        SET name = $1, aliases = $2, search_keys = $3, category = $4, website = $5, default_price = $6
+        WHERE id = $7
+        RETURNING created_at, updated_at
+    `
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, e.Name, e.Aliases, e.SearchKeys(), e.Category, e.Website, e.DefaultPrice, e.ID).
+		Scan(&e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to update service", zap.String("id", e.ID.String()), zap.Error(err))
+		return recordError("update service", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет сервис, подписки на него остаются со свободным названием
+func (r *CatalogRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM services WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete service", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("delete service: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *CatalogRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM services`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count services: %w", err)
+	}
+	return count, nil
+}
+
+func (r *CatalogRepository) Stream(ctx context.Context, fn func(*domain.CatalogEntry) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+catalogColumns+` FROM services`)
+	if err != nil {
+		return fmt.Errorf("list services: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e domain.CatalogEntry
+		if err := scanCatalogEntry(rows, &e); err != nil {
+			return err
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *CatalogRepository) Restore(ctx context.Context, entries []domain.CatalogEntry) error {
+	query := `
+        INSERT INTO services (id, name, aliases, search_keys, category, website, default_price, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, e := range entries {
+		_, err := tx.Exec(ctx, query, e.ID, e.Name, e.Aliases, e.SearchKeys(), e.Category, e.Website,
+			e.DefaultPrice, e.CreatedAt, e.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("restore service %s: %w", e.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCatalogRepository_Create(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCatalogRepository(mock, zaptest.NewLogger(t))
+	entry := &domain.CatalogEntry{Name: "Yandex Plus", Aliases: []string{"Яндекс Плюс", "yandex-plus"}}
+	now := time.Now()
+
+	mock.ExpectQuery("INSERT INTO services").
+		WithArgs(entry.Name, entry.Aliases, []string{"yandex plus", "яндекс плюс"}, "", "", entry.DefaultPrice).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(uuid.New(), now, now))
+
+	err = repo.Create(context.Background(), entry)
+
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, entry.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogRepository_Create_DuplicateName(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCatalogRepository(mock, zaptest.NewLogger(t))
+	entry := &domain.CatalogEntry{Name: "Yandex Plus", Aliases: []string{}}
+
+	mock.ExpectQuery("INSERT INTO services").
+		WithArgs(entry.Name, entry.Aliases, []string{"yandex plus"}, "", "", entry.DefaultPrice).
+		WillReturnError(&pgconn.PgError{Code: uniqueViolation})
+
+	err = repo.Create(context.Background(), entry)
+
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogRepository_FindByKey_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewCatalogRepository(mock, zaptest.NewLogger(t))
+
+	mock.ExpectQuery(`SELECT (.+) FROM services WHERE \$1 = ANY\(search_keys\)`).
+		WithArgs("local gym").
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repo.FindByKey(context.Background(), "local gym")
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
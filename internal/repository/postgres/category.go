@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+type CategoryRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewCategoryRepository(db PgxPool, logger *zap.Logger) *CategoryRepository {
+	return &CategoryRepository{db: db, logger: logger}
+}
+
+const categoryColumns = `id, name, created_at, updated_at`
+
+func scanCategory(row pgx.Row, c *domain.Category) error {
+	return row.Scan(&c.ID, &c.Name, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, c *domain.Category) error {
+	err := conn(ctx, r.db).QueryRow(ctx, `INSERT INTO categories (name) VALUES ($1) RETURNING id, created_at, updated_at`, c.Name).
+		Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to create category", zap.String("name", c.Name), zap.Error(err))
+		return recordError("create category", err)
+	}
+
+	r.logger.Info("category created", zap.String("id", c.ID.String()), zap.String("name", c.Name))
+	return nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	var c domain.Category
+	if err := scanCategory(conn(ctx, r.db).QueryRow(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = $1`, id), &c); err != nil {
+		return nil, recordError("get category", err)
+	}
+
+	return &c, nil
+}
+
+func (r *CategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+categoryColumns+` FROM categories ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []domain.Category{}
+	for rows.Next() {
+		var c domain.Category
+		if err := scanCategory(rows, &c); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, c *domain.Category) error {
+	err := conn(ctx, r.db).QueryRow(ctx, `UPDATE categories SET name = $1 WHERE id = $2 RETURNING created_at, updated_at`, c.Name, c.ID).
+		Scan(&c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to update category", zap.String("id", c.ID.String()), zap.Error(err))
+		return recordError("update category", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет категорию, подписки остаются без категории
+func (r *CategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM categories WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete category", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("delete category: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *CategoryRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM categories`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count categories: %w", err)
+	}
+	return count, nil
+}
+
+func (r *CategoryRepository) Stream(ctx context.Context, fn func(*domain.Category) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+categoryColumns+` FROM categories`)
+	if err != nil {
+		return fmt.Errorf("list categories: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c domain.Category
+		if err := scanCategory(rows, &c); err != nil {
+			return err
+		}
+		if err := fn(&c); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *CategoryRepository) Restore(ctx context.Context, categories []domain.Category) error {
+	query := `INSERT INTO categories (` + categoryColumns + `) VALUES ($1, $2, $3, $4)`
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, c := range categories {
+		if _, err := tx.Exec(ctx, query, c.ID, c.Name, c.CreatedAt, c.UpdatedAt); err != nil {
+			return fmt.Errorf("restore category %s: %w", c.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/cost"
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// TestOverlapMonthsSQL_MatchesBillableMonths сверяет SQL отчетов о стоимости с пакетом cost
+// на случайных подписках и периодах: выборку - с cost.ActiveRange, число месяцев -
+// с cost.BillableMonths, сумму - с cost.WholeMonths. Нужна живая база:
+//
+//	TEST_DATABASE_DSN=postgres://... go test -tags integration ./internal/repository/postgres/
+func TestOverlapMonthsSQL_MatchesBillableMonths(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	query := `
+        SELECT start_date <= $2 AND (end_date IS NULL OR end_date >= $1),
+            ` + overlapMonthsSQL + `::INTEGER
+        FROM (SELECT $3::DATE AS start_date, $4::DATE AS end_date) subscriptions`
+
+	epoch := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	for i := 0; i < 2000; i++ {
+		sub := domain.Subscription{StartDate: epoch.AddDate(0, 0, r.Intn(3650))}
+		if r.Intn(3) > 0 {
+			end := sub.StartDate.AddDate(0, 0, r.Intn(1500))
+			sub.EndDate = &end
+		}
+		from := epoch.AddDate(0, 0, r.Intn(3650))
+		to := from.AddDate(0, 0, r.Intn(1500))
+
+		var selected bool
+		var months int
+		require.NoError(t, pool.QueryRow(ctx, query, from, to, sub.StartDate, sub.EndDate).Scan(&selected, &months))
+
+		start, end, ok := cost.ActiveRange(&sub, from, to)
+		require.Equal(t, ok, selected, "start %s end %v period %s..%s", sub.StartDate, sub.EndDate, from, to)
+		if !selected {
+			continue
+		}
+		require.Equal(t, cost.BillableMonths(start, end), months, "start %s end %v period %s..%s", sub.StartDate, sub.EndDate, from, to)
+
+		units, amount := cost.WholeMonths(&sub, 100, from, to)
+		require.Equal(t, months, units)
+		require.Equal(t, 100*months, amount)
+	}
+}
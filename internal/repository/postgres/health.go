@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// PingPool - пул, доступность которого можно проверить
+type PingPool interface {
+	PgxPool
+	Ping(ctx context.Context) error
+}
+
+// HealthRepository проверяет соединение с базой и состояние миграций
+type HealthRepository struct {
+	db     PingPool
+	logger *zap.Logger
+}
+
+func NewHealthRepository(db PingPool, logger *zap.Logger) *HealthRepository {
+	return &HealthRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *HealthRepository) Ping(ctx context.Context) error {
+	if err := r.db.Ping(ctx); err != nil {
+		return fmt.Errorf("ping db: %w", err)
+	}
+	return nil
+}
+
+// MigrationVersion возвращает версию схемы из таблицы golang-migrate;
+// dirty=true, если последняя миграция не завершилась
+func (r *HealthRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := r.db.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		return 0, false, fmt.Errorf("read migration version: %w", err)
+	}
+
+	return uint(version), dirty, nil
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestHealthRepository_MigrationVersion(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewHealthRepository(mock, zaptest.NewLogger(t))
+
+	mock.ExpectPing()
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnRows(pgxmock.NewRows([]string{"version", "dirty"}).AddRow(int64(8), false))
+
+	require.NoError(t, repo.Ping(context.Background()))
+
+	version, dirty, err := repo.MigrationVersion(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, uint(8), version)
+	assert.False(t, dirty)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// QueryObserver получает длительность и результат каждого обращения репозитория к базе
+type QueryObserver interface {
+	ObserveQuery(repository, method string, duration time.Duration, err error)
+}
+
+// Instrument оборачивает пул так, что каждое обращение репозиториев и их транзакций
+// передается в observer с именем репозитория и метода, из которого оно сделано.
+// Запрос строк учитывается целиком: от отправки до закрытия курсора.
+func Instrument(db PgxPool, observer QueryObserver) PgxPool {
+	return &instrumentedPool{db: db, observer: observer}
+}
+
+type instrumentedPool struct {
+	db       PgxPool
+	observer QueryObserver
+}
+
+func (p *instrumentedPool) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	return observeRows(p.observer, func() (pgx.Rows, error) { return p.db.Query(ctx, query, args...) })
+}
+
+func (p *instrumentedPool) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	return observeRow(p.observer, func() pgx.Row { return p.db.QueryRow(ctx, query, args...) })
+}
+
+func (p *instrumentedPool) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	return observeExec(p.observer, func() (pgconn.CommandTag, error) { return p.db.Exec(ctx, query, args...) })
+}
+
+func (p *instrumentedPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	repository, method := caller()
+	start := time.Now()
+
+	tx, err := p.db.Begin(ctx)
+	p.observer.ObserveQuery(repository, method, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedTx{Tx: tx, observer: p.observer}, nil
+}
+
+// instrumentedTx учитывает запросы внутри транзакции и ее фиксацию
+type instrumentedTx struct {
+	pgx.Tx
+	observer QueryObserver
+}
+
+func (t *instrumentedTx) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	return observeRows(t.observer, func() (pgx.Rows, error) { return t.Tx.Query(ctx, query, args...) })
+}
+
+func (t *instrumentedTx) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	return observeRow(t.observer, func() pgx.Row { return t.Tx.QueryRow(ctx, query, args...) })
+}
+
+func (t *instrumentedTx) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	return observeExec(t.observer, func() (pgconn.CommandTag, error) { return t.Tx.Exec(ctx, query, args...) })
+}
+
+func (t *instrumentedTx) Commit(ctx context.Context) error {
+	repository, method := caller()
+	start := time.Now()
+
+	err := t.Tx.Commit(ctx)
+	t.observer.ObserveQuery(repository, method, time.Since(start), err)
+	return err
+}
+
+func observeExec(observer QueryObserver, exec func() (pgconn.CommandTag, error)) (pgconn.CommandTag, error) {
+	repository, method := caller()
+	start := time.Now()
+
+	tag, err := exec()
+	observer.ObserveQuery(repository, method, time.Since(start), err)
+	return tag, err
+}
+
+func observeRows(observer QueryObserver, query func() (pgx.Rows, error)) (pgx.Rows, error) {
+	repository, method := caller()
+	start := time.Now()
+
+	rows, err := query()
+	if err != nil {
+		observer.ObserveQuery(repository, method, time.Since(start), err)
+		return nil, err
+	}
+
+	return &instrumentedRows{Rows: rows, done: func(err error) {
+		observer.ObserveQuery(repository, method, time.Since(start), err)
+	}}, nil
+}
+
+func observeRow(observer QueryObserver, query func() pgx.Row) pgx.Row {
+	repository, method := caller()
+	start := time.Now()
+
+	row := query()
+	return instrumentedRow{row: row, done: func(err error) {
+		observer.ObserveQuery(repository, method, time.Since(start), err)
+	}}
+}
+
+// instrumentedRows сообщает о запросе один раз, когда курсор закрыт
+type instrumentedRows struct {
+	pgx.Rows
+	once sync.Once
+	done func(error)
+}
+
+func (r *instrumentedRows) Close() {
+	r.Rows.Close()
+	r.once.Do(func() { r.done(r.Rows.Err()) })
+}
+
+type instrumentedRow struct {
+	row  pgx.Row
+	done func(error)
+}
+
+func (r instrumentedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	r.done(err)
+	return err
+}
+
+// caller ищет в стеке ближайший метод репозитория: для
+// ".../postgres.(*SubscriptionRepository).Stream.func1" это SubscriptionRepository и Stream
+func caller() (string, string) {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+
+	for {
+		frame, more := frames.Next()
+
+		name := frame.Function
+		if i := strings.Index(name, ".(*"); i >= 0 {
+			receiver, method, ok := strings.Cut(name[i+3:], ").")
+			if ok && strings.HasSuffix(receiver, "Repository") {
+				method, _, _ = strings.Cut(method, ".")
+				return receiver, method
+			}
+		}
+
+		if !more {
+			return "unknown", "unknown"
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type observedQuery struct {
+	repository string
+	method     string
+	err        error
+}
+
+type recordingObserver struct {
+	queries []observedQuery
+}
+
+func (o *recordingObserver) ObserveQuery(repository, method string, _ time.Duration, err error) {
+	o.queries = append(o.queries, observedQuery{repository, method, err})
+}
+
+func TestInstrument_LabelsRepositoryMethod(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	observer := &recordingObserver{}
+	repo := NewSubscriptionRepository(Instrument(mock, observer), zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription()
+
+	mock.ExpectQuery("SELECT (.+) FROM subscriptions WHERE id").
+		WithArgs(sub.ID).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery("SELECT (.+) FROM subscriptions").
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "service_name", "price", "user_id",
+			"start_date", "end_date", "created_at", "updated_at", "service_id", "category_id", "billing_period", "tags", "split", "price_changes",
+		}).AddRow(sub.ID, sub.ServiceName, sub.Price, sub.UserID,
+			sub.StartDate, sub.EndDate, sub.CreatedAt, sub.UpdatedAt, sub.ServiceID, sub.CategoryID, domain.BillingMonthly, []string{}, nil, nil))
+	mock.ExpectExec("DELETE FROM subscriptions").
+		WithArgs(sub.ID).
+		WillReturnError(errors.New("connection reset"))
+
+	_, err = repo.GetByID(ctx, sub.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	err = repo.Stream(ctx, domain.SubscriptionFilter{}, func(*domain.Subscription) error { return nil })
+	require.NoError(t, err)
+
+	assert.Error(t, repo.Delete(ctx, sub.ID))
+
+	require.Len(t, observer.queries, 3)
+	assert.Equal(t, observedQuery{"SubscriptionRepository", "GetByID", pgx.ErrNoRows}, observer.queries[0])
+	assert.Equal(t, observedQuery{"SubscriptionRepository", "Stream", nil}, observer.queries[1])
+	assert.Equal(t, "Delete", observer.queries[2].method)
+	assert.Error(t, observer.queries[2].err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
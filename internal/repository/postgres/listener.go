@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// outboxChannel - канал NOTIFY, в который триггер outbox_events пишет id нового события
+const outboxChannel = "outbox_events"
+
+// EventListener получает уведомления о новых событиях outbox от всех экземпляров
+// сервиса через LISTEN. Пока слушает, держит отдельное соединение, взятое из пула.
+type EventListener struct {
+	pool   *pgxpool.Pool
+	logger *zap.Logger
+}
+
+func NewEventListener(pool *pgxpool.Pool, logger *zap.Logger) *EventListener {
+	return &EventListener{pool: pool, logger: logger}
+}
+
+// Listen подписывается на уведомления, вызывает ready и затем notify с id каждого
+// нового события. Уведомления, пришедшие во время ready, не теряются. Возвращается
+// при отмене ctx, обрыве соединения или ошибке ready и notify; уведомления, отправленные,
+// пока соединения нет, не доходят, поэтому после переподключения ready должен
+// дочитать пропущенное.
+func (l *EventListener) Listen(ctx context.Context, ready func(ctx context.Context) error, notify func(ctx context.Context, id int64) error) error {
+	pooled, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire listener connection: %w", err)
+	}
+	// соединение после LISTEN не возвращается в пул: его состояние не сбросить
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+outboxChannel); err != nil {
+		return fmt.Errorf("listen %s: %w", outboxChannel, err)
+	}
+	l.logger.Info("listening for outbox events")
+
+	if err := ready(ctx); err != nil {
+		return err
+	}
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+
+		id, err := strconv.ParseInt(n.Payload, 10, 64)
+		if err != nil {
+			l.logger.Warn("invalid outbox notification", zap.String("payload", n.Payload))
+			continue
+		}
+		if err := notify(ctx, id); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+// OutboxRepository пишет события в outbox_events в транзакции изменения данных
+type OutboxRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewOutboxRepository(db PgxPool, logger *zap.Logger) *OutboxRepository {
+	return &OutboxRepository{db: db, logger: logger}
+}
+
+// WithinTx выполняет fn в транзакции: репозитории, получившие ее контекст, пишут в нее же.
+// Вложенный вызов присоединяется к внешней транзакции.
+func (r *OutboxRepository) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, r.db, "", fn)
+}
+
+// Append записывает события; событие с уже записанным DedupKey пропускается
+func (r *OutboxRepository) Append(ctx context.Context, events ...domain.Event) error {
+	for _, e := range events {
+		var dedupKey *string
+		if e.DedupKey != "" {
+			dedupKey = &e.DedupKey
+		}
+
+		_, err := conn(ctx, r.db).Exec(ctx, `
+            INSERT INTO outbox_events (type, subscription_id, data, dedup_key)
+            VALUES ($1, $2, $3, $4)
+            ON CONFLICT (dedup_key) DO NOTHING`,
+			e.Type, e.SubscriptionID, e.Data, dedupKey)
+		if err != nil {
+			r.logger.Error("failed to append event", zap.String("type", string(e.Type)), zap.Error(err))
+			return fmt.Errorf("append event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// publisherEventSQL - событие e относится к издателю p: его id больше видимых при регистрации
+// издателя или его транзакция тогда еще не была закоммичена. id выдаются до коммита, поэтому
+// одного start_id мало: событие с меньшим id может закоммититься после регистрации.
+const publisherEventSQL = `(e.id > p.start_id OR NOT pg_visible_in_snapshot(e.txid, p.start_snapshot))`
+
+// RegisterPublisher добавляет издателя событий; ему достаются события, закоммиченные после регистрации
+func (r *OutboxRepository) RegisterPublisher(ctx context.Context, name string) error {
+	query := `
+        INSERT INTO event_publishers (name, start_id, start_snapshot)
+        SELECT $1, COALESCE(MAX(id), 0), pg_current_snapshot() FROM outbox_events
+        ON CONFLICT (name) DO NOTHING
+    `
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, name); err != nil {
+		return fmt.Errorf("register publisher %s: %w", name, err)
+	}
+	return nil
+}
+
+// ClaimPublisher берет издателя в аренду на lease или продлевает свою аренду. false -
+// издателя обрабатывает другой экземпляр сервиса: тогда публиковать нельзя, иначе нарушится
+// порядок. Аренда не держит транзакцию, поэтому публикация идет вне транзакции базы.
+// Взятая аренда отмечает издателя живым для PrunePublishers.
+func (r *OutboxRepository) ClaimPublisher(ctx context.Context, name string, owner uuid.UUID, lease time.Duration) (bool, error) {
+	query := `
+        UPDATE event_publishers
+        SET locked_by = $2, locked_until = CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond',
+            seen_at = CURRENT_TIMESTAMP
+        WHERE name = $1 AND (locked_by IS NULL OR locked_by = $2 OR locked_until < CURRENT_TIMESTAMP)
+    `
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query, name, owner, lease.Milliseconds())
+	if err != nil {
+		return false, fmt.Errorf("claim publisher %s: %w", name, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleasePublisher снимает аренду owner, чтобы другой экземпляр не ждал ее истечения
+func (r *OutboxRepository) ReleasePublisher(ctx context.Context, name string, owner uuid.UUID) error {
+	query := `UPDATE event_publishers SET locked_by = NULL, locked_until = NULL WHERE name = $1 AND locked_by = $2`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, name, owner); err != nil {
+		return fmt.Errorf("release publisher %s: %w", name, err)
+	}
+	return nil
+}
+
+// Unpublished возвращает до limit событий, которые издателю пора опубликовать, в порядке
+// записи: новые и неудачные, у которых подошло время повтора. События подписки после ее
+// неудачного события не возвращаются, пока оно не опубликовано или не получило статус dead,
+// поэтому заблокированные подписки не занимают пачку.
+func (r *OutboxRepository) Unpublished(ctx context.Context, publisher string, limit int) ([]domain.PendingPublication, error) {
+	query := `
+        SELECT e.id, e.type, e.subscription_id, e.data, e.created_at, COALESCE(o.attempts, 0)
+        FROM outbox_events e
+        JOIN event_publishers p ON p.name = $1
+        LEFT JOIN outbox_publications o ON o.publisher = p.name AND o.event_id = e.id
+        WHERE ` + publisherEventSQL + `
+          AND (o.event_id IS NULL OR (o.status = 'failed' AND o.next_attempt_at <= CURRENT_TIMESTAMP))
+          AND NOT EXISTS (
+              SELECT 1 FROM outbox_publications f
+              JOIN outbox_events fe ON fe.id = f.event_id
+              WHERE f.publisher = p.name AND f.status = 'failed'
+                AND fe.subscription_id = e.subscription_id AND fe.id < e.id
+          )
+        ORDER BY e.id
+        LIMIT $2
+    `
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, publisher, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list unpublished events: %w", err)
+	}
+	defer rows.Close()
+
+	pending := []domain.PendingPublication{}
+	for rows.Next() {
+		var p domain.PendingPublication
+		e := &p.Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.SubscriptionID, &e.Data, &e.CreatedAt, &p.Attempts); err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+
+	return pending, rows.Err()
+}
+
+func scanEvents(rows pgx.Rows) ([]domain.Event, error) {
+	defer rows.Close()
+
+	events := []domain.Event{}
+	for rows.Next() {
+		var e domain.Event
+		if err := rows.Scan(&e.ID, &e.Type, &e.SubscriptionID, &e.Data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+// MarkPublished отмечает события опубликованными издателем, в том числе после неудачных попыток
+func (r *OutboxRepository) MarkPublished(ctx context.Context, publisher string, ids ...int64) error {
+	query := `
+        INSERT INTO outbox_publications (publisher, event_id, status, published_at)
+        SELECT $1, unnest($2::bigint[]), 'published', CURRENT_TIMESTAMP
+        ON CONFLICT (publisher, event_id) DO UPDATE
+        SET status = 'published', published_at = CURRENT_TIMESTAMP, next_attempt_at = NULL
+    `
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, publisher, ids); err != nil {
+		return fmt.Errorf("mark events published: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure сохраняет неудачную попытку публикации. Без NextAttemptAt событие получает
+// статус dead: издатель его больше не публикует, а события подписки идут дальше.
+func (r *OutboxRepository) RecordFailure(ctx context.Context, publisher string, eventID int64, failure domain.PublicationFailure) error {
+	query := `
+        INSERT INTO outbox_publications (publisher, event_id, status, attempts, next_attempt_at, last_error)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (publisher, event_id) DO UPDATE
+        SET status = EXCLUDED.status, attempts = EXCLUDED.attempts,
+            next_attempt_at = EXCLUDED.next_attempt_at, last_error = EXCLUDED.last_error
+    `
+
+	status := "failed"
+	if failure.NextAttemptAt == nil {
+		status = "dead"
+	}
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, publisher, eventID, status, failure.Attempts, failure.NextAttemptAt, failure.Error)
+	if err != nil {
+		return fmt.Errorf("record publication failure: %w", err)
+	}
+	return nil
+}
+
+// PurgeEvents удаляет события, записанные до before и уже обработанные: опубликованные
+// всеми издателями или получившие у них статус dead, без доставок webhook в ожидании и, если requireDispatched, разосланные
+// по webhook. Журнал их доставок и публикаций удаляется вместе с ними.
+func (r *OutboxRepository) PurgeEvents(ctx context.Context, before time.Time, requireDispatched bool) (int, error) {
+	query := `
+        DELETE FROM outbox_events e
+        WHERE e.created_at < $1
+          AND (NOT $2 OR e.dispatched_at IS NOT NULL)
+          AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.event_id = e.id AND d.status = 'pending')
+          AND NOT EXISTS (
+              SELECT 1 FROM event_publishers p
+              WHERE ` + publisherEventSQL + `
+                AND NOT EXISTS (
+                    SELECT 1 FROM outbox_publications o
+                    WHERE o.publisher = p.name AND o.event_id = e.id AND o.status <> 'failed'
+                )
+          )
+    `
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query, before, requireDispatched)
+	if err != nil {
+		return 0, fmt.Errorf("purge events: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// PrunePublishers удаляет издателей, которых ни один экземпляр сервиса не брал в аренду
+// с before, вместе с их публикациями. Такого издателя убрали из конфига, и без удаления
+// его неопубликованные события никогда не попали бы под очистку. Если издателя вернут,
+// он зарегистрируется заново и получит события, записанные после этого.
+func (r *OutboxRepository) PrunePublishers(ctx context.Context, before time.Time) (int, error) {
+	query := `DELETE FROM event_publishers WHERE seen_at < $1`
+
+	tag, err := conn(ctx, r.db).Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("prune publishers: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// RecentEvents возвращает последние limit событий видов types в порядке записи
+func (r *OutboxRepository) RecentEvents(ctx context.Context, types []domain.EventType, limit int) ([]domain.Event, error) {
+	query := `
+        SELECT id, type, subscription_id, data, created_at FROM (
+            SELECT id, type, subscription_id, data, created_at FROM outbox_events
+            WHERE type = ANY($1)
+            ORDER BY id DESC
+            LIMIT $2
+        ) recent
+        ORDER BY id
+    `
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, eventNames(types), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list recent events: %w", err)
+	}
+	return scanEvents(rows)
+}
+
+// EventsAfter возвращает до limit событий видов types, записанных после события afterID
+func (r *OutboxRepository) EventsAfter(ctx context.Context, afterID int64, types []domain.EventType, limit int) ([]domain.Event, error) {
+	query := `
+        SELECT id, type, subscription_id, data, created_at FROM outbox_events
+        WHERE id > $1 AND type = ANY($2)
+        ORDER BY id
+        LIMIT $3
+    `
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, afterID, eventNames(types), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	return scanEvents(rows)
+}
+
+func (r *OutboxRepository) EventByID(ctx context.Context, id int64) (*domain.Event, error) {
+	query := `SELECT id, type, subscription_id, data, created_at FROM outbox_events WHERE id = $1`
+
+	var e domain.Event
+	err := conn(ctx, r.db).QueryRow(ctx, query, id).Scan(&e.ID, &e.Type, &e.SubscriptionID, &e.Data, &e.CreatedAt)
+	if err != nil {
+		return nil, recordError("get event", err)
+	}
+	return &e, nil
+}
@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+func TestOutboxRepository_WithinTx_WritesDataAndEventsInOneTx(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := zaptest.NewLogger(t)
+	outbox := NewOutboxRepository(mock, logger)
+	subs := NewSubscriptionRepository(mock, logger)
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM subscriptions WHERE id = \$1`).
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`INSERT INTO outbox_events`).
+		WithArgs(domain.EventSubscriptionDeleted, id, json.RawMessage(`{}`), (*string)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = outbox.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := subs.Delete(ctx, id); err != nil {
+			return err
+		}
+		// вложенный вызов не открывает новую транзакцию
+		return outbox.WithinTx(ctx, func(ctx context.Context) error {
+			return outbox.Append(ctx, domain.Event{Type: domain.EventSubscriptionDeleted, SubscriptionID: id, Data: json.RawMessage(`{}`)})
+		})
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_WithinTx_RollsBackOnError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	outbox := NewOutboxRepository(mock, zaptest.NewLogger(t))
+	failure := errors.New("boom")
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = outbox.WithinTx(context.Background(), func(ctx context.Context) error { return failure })
+
+	assert.ErrorIs(t, err, failure)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Append_PassesDedupKey(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	outbox := NewOutboxRepository(mock, zaptest.NewLogger(t))
+	id := uuid.New()
+	key := "subscription.ending_soon:" + id.String() + ":2025-03-14"
+
+	mock.ExpectExec(`INSERT INTO outbox_events .* ON CONFLICT \(dedup_key\) DO NOTHING`).
+		WithArgs(domain.EventSubscriptionEndingSoon, id, json.RawMessage(`{}`), &key).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+	err = outbox.Append(context.Background(), domain.Event{
+		Type: domain.EventSubscriptionEndingSoon, SubscriptionID: id, Data: json.RawMessage(`{}`), DedupKey: key,
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_RegisterPublisher_StoresSnapshot(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+
+	// одного MAX(id) мало: событие с меньшим id может закоммититься после регистрации
+	mock.ExpectExec(`INSERT INTO event_publishers \(name, start_id, start_snapshot\)\s+SELECT \$1, COALESCE\(MAX\(id\), 0\), pg_current_snapshot\(\) FROM outbox_events\s+ON CONFLICT \(name\) DO NOTHING`).
+		WithArgs("audit").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	require.NoError(t, repo.RegisterPublisher(context.Background(), "audit"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_ClaimPublisher(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+	owner := uuid.New()
+
+	mock.ExpectExec(`(?s)UPDATE event_publishers\s+SET locked_by = \$2.+seen_at = CURRENT_TIMESTAMP\s+WHERE name = \$1 AND \(locked_by IS NULL OR locked_by = \$2 OR locked_until < CURRENT_TIMESTAMP\)`).
+		WithArgs("audit", owner, int64(300000)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(`UPDATE event_publishers`).
+		WithArgs("audit", owner, int64(300000)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	claimed, err := repo.ClaimPublisher(context.Background(), "audit", owner, 5*time.Minute)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	// аренду держит другой экземпляр
+	claimed, err = repo.ClaimPublisher(context.Background(), "audit", owner, 5*time.Minute)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_Unpublished(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+	subID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`(?s)FROM outbox_events e\s+JOIN event_publishers p ON p.name = \$1.+WHERE \(e.id > p.start_id OR NOT pg_visible_in_snapshot\(e.txid, p.start_snapshot\)\).+o.status = 'failed' AND o.next_attempt_at <= CURRENT_TIMESTAMP.+f.status = 'failed'\s+AND fe.subscription_id = e.subscription_id AND fe.id < e.id`).
+		WithArgs("audit", 100).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "type", "subscription_id", "data", "created_at", "attempts"}).
+			AddRow(int64(5), domain.EventSubscriptionCreated, subID, json.RawMessage(`{}`), now, 2).
+			AddRow(int64(6), domain.EventSubscriptionUpdated, subID, json.RawMessage(`{}`), now, 0))
+
+	pending, err := repo.Unpublished(context.Background(), "audit", 100)
+
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, int64(5), pending[0].Event.ID)
+	assert.Equal(t, 2, pending[0].Attempts)
+	assert.Equal(t, domain.EventSubscriptionUpdated, pending[1].Event.Type)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_MarkPublished(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+
+	mock.ExpectExec(`INSERT INTO outbox_publications.+ON CONFLICT \(publisher, event_id\) DO UPDATE\s+SET status = 'published'`).
+		WithArgs("audit", []int64{5, 6}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+	require.NoError(t, repo.MarkPublished(context.Background(), "audit", 5, 6))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_RecordFailure(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+	next := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`INSERT INTO outbox_publications`).
+		WithArgs("audit", int64(5), "failed", 1, &next, "timeout").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectExec(`INSERT INTO outbox_publications`).
+		WithArgs("audit", int64(6), "dead", 10, (*time.Time)(nil), "timeout").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	ctx := context.Background()
+	require.NoError(t, repo.RecordFailure(ctx, "audit", 5, domain.PublicationFailure{Attempts: 1, Error: "timeout", NextAttemptAt: &next}))
+	require.NoError(t, repo.RecordFailure(ctx, "audit", 6, domain.PublicationFailure{Attempts: 10, Error: "timeout"}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_RecentEvents(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+	subID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`WHERE type = ANY\(\$1\)\s+ORDER BY id DESC\s+LIMIT \$2\s+\) recent\s+ORDER BY id`).
+		WithArgs([]string{"subscription.created", "subscription.deleted"}, 1000).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "type", "subscription_id", "data", "created_at"}).
+			AddRow(int64(8), domain.EventSubscriptionCreated, subID, json.RawMessage(`{}`), now).
+			AddRow(int64(9), domain.EventSubscriptionDeleted, subID, json.RawMessage(`{}`), now))
+
+	events, err := repo.RecentEvents(context.Background(),
+		[]domain.EventType{domain.EventSubscriptionCreated, domain.EventSubscriptionDeleted}, 1000)
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(8), events[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_EventByID_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+
+	mock.ExpectQuery(`FROM outbox_events WHERE id = \$1`).
+		WithArgs(int64(42)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "type", "subscription_id", "data", "created_at"}))
+
+	_, err = repo.EventByID(context.Background(), 42)
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_PurgeEvents(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+	before := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`DELETE FROM outbox_events e\s+WHERE e.created_at < \$1\s+AND \(NOT \$2 OR e.dispatched_at IS NOT NULL\)`).
+		WithArgs(before, false).
+		WillReturnResult(pgxmock.NewResult("DELETE", 5))
+
+	n, err := repo.PurgeEvents(context.Background(), before, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_PrunePublishers(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewOutboxRepository(mock, zaptest.NewLogger(t))
+	before := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`DELETE FROM event_publishers WHERE seen_at < \$1`).
+		WithArgs(before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	n, err := repo.PrunePublishers(context.Background(), before)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
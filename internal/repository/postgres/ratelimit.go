@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/ratelimit"
+)
+
+// RateLimitRepository хранит корзины ограничения частоты в базе, чтобы
+// все экземпляры сервиса делили один лимит
+type RateLimitRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewRateLimitRepository(db PgxPool, logger *zap.Logger) *RateLimitRepository {
+	return &RateLimitRepository{db: db, logger: logger}
+}
+
+// refilledTokensSQL - токены корзины b, пополненной на момент $3 со скоростью $4, но не больше
+// емкости $2; время назад, как при расхождении часов экземпляров, корзину не пополняет
+const refilledTokensSQL = `LEAST($2::DOUBLE PRECISION,
+            b.tokens + GREATEST(EXTRACT(EPOCH FROM ($3::TIMESTAMPTZ - b.updated_at)), 0) * $4::DOUBLE PRECISION)`
+
+// takeTokenQuery - limit.Take одним запросом: новая корзина создается полной без одного
+// токена, существующая пополняется и отдает токен, если он есть. UPDATE в ON CONFLICT
+// блокирует строку, поэтому параллельные запросы одного клиента не возьмут лишнего.
+const takeTokenQuery = `
+        INSERT INTO rate_limit_buckets AS b (key, tokens, updated_at, allowed)
+        VALUES ($1, $2::DOUBLE PRECISION - 1, $3, TRUE)
+        ON CONFLICT (key) DO UPDATE SET
+            tokens = CASE WHEN ` + refilledTokensSQL + ` >= 1
+                          THEN ` + refilledTokensSQL + ` - 1
+                          ELSE ` + refilledTokensSQL + ` END,
+            allowed = ` + refilledTokensSQL + ` >= 1,
+            updated_at = GREATEST(b.updated_at, $3)
+        RETURNING tokens, allowed`
+
+// Take берет токен из корзины key за один запрос к базе
+func (r *RateLimitRepository) Take(ctx context.Context, key string, limit ratelimit.Limit, now time.Time) (ratelimit.Decision, error) {
+	var tokens float64
+	var allowed bool
+	err := r.db.QueryRow(ctx, takeTokenQuery, key, float64(limit.Burst), now, limit.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		return ratelimit.Decision{}, fmt.Errorf("take token: %w", err)
+	}
+
+	return limit.Decide(tokens, allowed), nil
+}
+
+// Sweep удаляет корзины, к которым не обращались с before
+func (r *RateLimitRepository) Sweep(ctx context.Context, before time.Time) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, before)
+	if err != nil {
+		return fmt.Errorf("sweep buckets: %w", err)
+	}
+
+	r.logger.Debug("rate limit buckets swept", zap.Int64("deleted", tag.RowsAffected()))
+	return nil
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/SoulStalker/subscribes_api/internal/ratelimit"
+)
+
+func TestRateLimitRepository_Take(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewRateLimitRepository(mock, zaptest.NewLogger(t))
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	limit := ratelimit.Limit{Rate: 1, Burst: 5}
+
+	// корзина пополнилась до 1.5 токена и отдала один
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets AS b (.+) ON CONFLICT \(key\) DO UPDATE SET (.+) RETURNING tokens, allowed`).
+		WithArgs("|ip:10.0.0.1", 5.0, now, 1.0).
+		WillReturnRows(pgxmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.5, true))
+
+	decision, err := repo.Take(context.Background(), "|ip:10.0.0.1", limit, now)
+
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 5, decision.Limit)
+	assert.Zero(t, decision.Remaining)
+	assert.Equal(t, 4500*time.Millisecond, decision.Reset)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitRepository_Take_Denied(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewRateLimitRepository(mock, zaptest.NewLogger(t))
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	limit := ratelimit.Limit{Rate: 2, Burst: 3}
+
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets`).
+		WithArgs("|ip:10.0.0.1", 3.0, now, 2.0).
+		WillReturnRows(pgxmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.0, false))
+
+	decision, err := repo.Take(context.Background(), "|ip:10.0.0.1", limit, now)
+
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 500*time.Millisecond, decision.RetryAfter)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitRepository_Sweep(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewRateLimitRepository(mock, zaptest.NewLogger(t))
+	before := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectExec(`DELETE FROM rate_limit_buckets WHERE updated_at < \$1`).
+		WithArgs(before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	require.NoError(t, repo.Sweep(context.Background(), before))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,7 +2,10 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -10,6 +13,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
 )
 
 // PgxPool интерфейс для возможности тестов через pgxmock
@@ -17,6 +21,18 @@ type PgxPool interface {
 	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
 	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Коды ошибок PostgreSQL, которые репозитории переводят в доменные
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
+// rowQuerier - общее у пула и транзакции для запросов с одной строкой результата
+type rowQuerier interface {
+	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
 }
 
 type SubscriptionRepository struct {
@@ -28,46 +44,270 @@ func NewSubscriptionRepository(db PgxPool, logger *zap.Logger) *SubscriptionRepo
 	return &SubscriptionRepository{db: db, logger: logger}
 }
 
-func (r *SubscriptionRepository) Create(ctx context.Context, sub *domain.Subscription) error {
-	query := `
-		 INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-        VALUES ($1, $2, $3, $4, $5)
+// log - логгер текущего запроса, а вне запроса - логгер репозитория
+func (r *SubscriptionRepository) log(ctx context.Context) *zap.Logger {
+	return applogger.FromContext(ctx, r.logger)
+}
+
+const createSubscriptionQuery = `
+		 INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date, service_id, category_id, billing_period)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
         RETURNING id, created_at, updated_at 
 	`
 
-	r.logger.Debug("creating subscription", zap.String("service", sub.ServiceName))
+// subscriptionColumns - колонки таблицы subscriptions в порядке scanSubscription
+const subscriptionColumns = `id, service_name, price, user_id, start_date, end_date, created_at, updated_at, service_id, category_id, billing_period`
+
+// subscriptionSelect добавляет к колонкам теги подписки, отсортированные по имени,
+// деление стоимости (NULL, если участников нет) и запланированные изменения цены
+// по возрастанию даты (NULL, если их нет)
+const subscriptionSelect = subscriptionColumns + `,
+    ARRAY(SELECT t.name FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+          WHERE st.subscription_id = subscriptions.id ORDER BY t.name) AS tags,
+    (SELECT json_build_object('mode', MIN(m.mode), 'members',
+            json_agg(json_build_object('user_id', m.user_id, 'share', m.share, 'amount', m.amount) ORDER BY m.user_id))
+     FROM subscription_members m WHERE m.subscription_id = subscriptions.id
+     HAVING COUNT(*) > 0) AS split,
+    (SELECT json_agg(json_build_object('effective_from', to_char(c.effective_from, 'YYYY-MM-DD"T00:00:00Z"'), 'price', c.price)
+            ORDER BY c.effective_from)
+     FROM subscription_price_changes c WHERE c.subscription_id = subscriptions.id) AS price_changes`
+
+func scanSubscription(row pgx.Row, sub *domain.Subscription, extra ...any) error {
+	dest := append([]any{&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
+		&sub.StartDate, &sub.EndDate, &sub.CreatedAt, &sub.UpdatedAt, &sub.ServiceID, &sub.CategoryID, &sub.BillingPeriod,
+		&sub.Tags, &sub.Split, &sub.PriceChanges}, extra...)
+	return row.Scan(dest...)
+}
 
-	err := r.db.QueryRow(ctx, query, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate).Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+// recordError приводит ошибки чтения и записи к доменным: отсутствие строки,
+// нарушение уникальности и ссылку на несуществующую запись
+func recordError(op string, err error) error {
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return domain.ErrNotFound
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+		return domain.ErrConflict
+	case errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation:
+		// subscriptions_category_id_fkey -> category_id
+		column := strings.TrimSuffix(strings.TrimPrefix(pgErr.ConstraintName, pgErr.TableName+"_"), "_fkey")
+		return fmt.Errorf("%w: %s", domain.ErrReferenceNotFound, column)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// replaceTags заменяет теги подписки, создавая недостающие. Теги уже нормализованы сервисом.
+func replaceTags(ctx context.Context, tx pgx.Tx, subscriptionID uuid.UUID, tags []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM subscription_tags WHERE subscription_id = $1`, subscriptionID); err != nil {
+		return fmt.Errorf("delete subscription tags: %w", err)
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `INSERT INTO tags (name) SELECT unnest($1::TEXT[]) ON CONFLICT (name) DO NOTHING`, tags); err != nil {
+		return fmt.Errorf("create tags: %w", err)
+	}
+
+	query := `
+        INSERT INTO subscription_tags (subscription_id, tag_id)
+        SELECT $1, id FROM tags WHERE name = ANY($2)
+    `
+	if _, err := tx.Exec(ctx, query, subscriptionID, tags); err != nil {
+		return fmt.Errorf("link subscription tags: %w", err)
+	}
+
+	return nil
+}
+
+// billingPeriod - период оплаты для записи; пустой, как у подписок из старых копий, - ежемесячный
+func billingPeriod(sub *domain.Subscription) string {
+	if sub.BillingPeriod == "" {
+		return domain.BillingMonthly
+	}
+	return sub.BillingPeriod
+}
+
+// replacePriceChanges заменяет запланированные изменения цены подписки
+func replacePriceChanges(ctx context.Context, tx pgx.Tx, subscriptionID uuid.UUID, changes []domain.PriceChange) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM subscription_price_changes WHERE subscription_id = $1`, subscriptionID); err != nil {
+		return fmt.Errorf("delete price changes: %w", err)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	dates := make([]time.Time, len(changes))
+	prices := make([]int, len(changes))
+	for i, c := range changes {
+		dates[i], prices[i] = c.EffectiveFrom, c.Price
+	}
+
+	query := `
+        INSERT INTO subscription_price_changes (subscription_id, effective_from, price)
+        SELECT $1, d, p FROM unnest($2::DATE[], $3::INTEGER[]) AS t(d, p)
+    `
+	if _, err := tx.Exec(ctx, query, subscriptionID, dates, prices); err != nil {
+		return recordError("add price changes", err)
+	}
+
+	return nil
+}
+
+// replaceMembers заменяет участников совместной подписки; доли уже посчитаны сервисом
+func replaceMembers(ctx context.Context, tx pgx.Tx, subscriptionID uuid.UUID, split *domain.Split) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM subscription_members WHERE subscription_id = $1`, subscriptionID); err != nil {
+		return fmt.Errorf("delete subscription members: %w", err)
+	}
+
+	if !split.Shared() {
+		return nil
+	}
+
+	userIDs := make([]uuid.UUID, len(split.Members))
+	shares := make([]int, len(split.Members))
+	amounts := make([]int, len(split.Members))
+	for i, m := range split.Members {
+		userIDs[i], shares[i], amounts[i] = m.UserID, m.Share, m.Amount
+	}
+
+	query := `
+        INSERT INTO subscription_members (subscription_id, mode, user_id, share, amount)
+        SELECT $1, $2, u, s, a FROM unnest($3::UUID[], $4::INTEGER[], $5::INTEGER[]) AS t(u, s, a)
+    `
+	if _, err := tx.Exec(ctx, query, subscriptionID, split.Mode, userIDs, shares, amounts); err != nil {
+		return recordError("add subscription members", err)
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepository) Create(ctx context.Context, sub *domain.Subscription) error {
+	r.log(ctx).Debug("creating subscription", zap.String("service", sub.ServiceName))
+
+	// теги, участники и изменения цены пишутся в отдельные таблицы, поэтому нужна транзакция
+	if len(sub.Tags) > 0 || sub.Split.Shared() || len(sub.PriceChanges) > 0 {
+		return r.CreateBatch(ctx, []*domain.Subscription{sub})
+	}
+
+	err := conn(ctx, r.db).QueryRow(ctx, createSubscriptionQuery, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, billingPeriod(sub)).Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
 
 	if err != nil {
-		r.logger.Error("failed to create subscription", zap.Error(err))
-		return fmt.Errorf("create subscription: %w", err)
+		r.log(ctx).Error("failed to create subscription", zap.Error(err))
+		return recordError("create subscription", err)
 	}
 
-	r.logger.Info("subscription created", zap.String("id", sub.ID.String()))
+	r.log(ctx).Info("subscription created", zap.String("id", sub.ID.String()))
+	return nil
+}
+
+// CreateBatch создает подписки в одной транзакции: либо все, либо ни одной
+func (r *SubscriptionRepository) CreateBatch(ctx context.Context, subs []*domain.Subscription) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, sub := range subs {
+		err := tx.QueryRow(ctx, createSubscriptionQuery, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, billingPeriod(sub)).
+			Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+		if err != nil {
+			r.log(ctx).Error("failed to create subscription in batch", zap.Error(err))
+			return recordError("create subscription", err)
+		}
+
+		if len(sub.Tags) > 0 {
+			if err := replaceTags(ctx, tx, sub.ID, sub.Tags); err != nil {
+				return err
+			}
+		}
+
+		if sub.Split.Shared() {
+			if err := replaceMembers(ctx, tx, sub.ID, sub.Split); err != nil {
+				return err
+			}
+		}
+
+		if len(sub.PriceChanges) > 0 {
+			if err := replacePriceChanges(ctx, tx, sub.ID, sub.PriceChanges); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	r.log(ctx).Info("subscriptions created", zap.Int("count", len(subs)))
+	return nil
+}
+
+// Count возвращает общее число подписок
+func (r *SubscriptionRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM subscriptions`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count subscriptions: %w", err)
+	}
+	return count, nil
+}
+
+// Restore вставляет подписки с исходными id и временными метками в одной транзакции.
+// Участники совместных подписок и изменения цены восстанавливаются вместе с подпиской.
+func (r *SubscriptionRepository) Restore(ctx context.Context, subs []domain.Subscription) error {
+	query := `
+        INSERT INTO subscriptions (` + subscriptionColumns + `)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    `
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, sub := range subs {
+		_, err := tx.Exec(ctx, query, sub.ID, sub.ServiceName, sub.Price, sub.UserID,
+			sub.StartDate, sub.EndDate, sub.CreatedAt, sub.UpdatedAt, sub.ServiceID, sub.CategoryID, billingPeriod(&sub))
+		if err != nil {
+			return fmt.Errorf("restore subscription %s: %w", sub.ID, err)
+		}
+
+		if sub.Split.Shared() {
+			if err := replaceMembers(ctx, tx, sub.ID, sub.Split); err != nil {
+				return fmt.Errorf("restore subscription %s: %w", sub.ID, err)
+			}
+		}
+
+		if len(sub.PriceChanges) > 0 {
+			if err := replacePriceChanges(ctx, tx, sub.ID, sub.PriceChanges); err != nil {
+				return fmt.Errorf("restore subscription %s: %w", sub.ID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	r.log(ctx).Info("subscriptions restored", zap.Int("count", len(subs)))
 	return nil
 }
 
 func (r *SubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
 	query := `
-        SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+        SELECT ` + subscriptionSelect + `
         FROM subscriptions
         WHERE id = $1
     `
 	var sub domain.Subscription
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&sub.ID,
-		&sub.ServiceName,
-		&sub.Price,
-		&sub.UserID,
-		&sub.StartDate,
-		&sub.EndDate,
-		&sub.CreatedAt,
-		&sub.UpdatedAt,
-	)
-
-	if err != nil {
-		r.logger.Error("subscription not found", zap.String("id", id.String()), zap.Error(err))
+	err := scanSubscription(conn(ctx, r.db).QueryRow(ctx, query, id), &sub)
+
+	if err != nil {
+		r.log(ctx).Error("subscription not found", zap.String("id", id.String()), zap.Error(err))
 		return nil, fmt.Errorf("get subscription: %w", err)
 	}
 
@@ -75,77 +315,279 @@ func (r *SubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 }
 
 func (r *SubscriptionRepository) List(ctx context.Context, filter domain.SubscriptionFilter) ([]domain.Subscription, error) {
-	query := `SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at FROM subscriptions WHERE 1=1`
-	args := []any{}
-	argID := 1
+	var subs []domain.Subscription
+	err := r.Stream(ctx, filter, func(sub *domain.Subscription) error {
+		subs = append(subs, *sub)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	if filter.UserID != nil {
-		query += fmt.Sprintf(" AND user_id = $%d", argID)
-		args = append(args, *filter.UserID)
-		argID++
+	r.log(ctx).Debug("subscriptions list", zap.Int("count", len(subs)))
+	return subs, nil
+}
+
+// Stream построчно передает подписки в fn, не загружая выборку в память.
+// Ошибка из fn прерывает чтение и возвращается как есть.
+func (r *SubscriptionRepository) Stream(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.Subscription) error) error {
+	query, args := listQuery(filter)
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("List subscriptions: %w", err)
 	}
+	defer rows.Close()
 
-	if filter.ServiceName != nil {
-		query += fmt.Sprintf(" AND service_name ILIKE $%d", argID)
-		args = append(args, "%"+*filter.ServiceName+"%")
-		argID++
+	for rows.Next() {
+		var sub domain.Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return err
+		}
+		if err := fn(&sub); err != nil {
+			return err
+		}
 	}
 
-	rows, err := r.db.Query(ctx, query, args...)
+	return rows.Err()
+}
+
+// EndingBetween возвращает подписки, последний день которых попадает в [from, to].
+// end_date - первое число последнего оплаченного месяца, поэтому последний день действия -
+// конец этого месяца, как в отчетах о стоимости.
+func (r *SubscriptionRepository) EndingBetween(ctx context.Context, from, to time.Time) ([]domain.Subscription, error) {
+	query := `
+        SELECT ` + subscriptionSelect + `
+        FROM subscriptions
+        WHERE (DATE_TRUNC('month', end_date) + INTERVAL '1 month - 1 day')::DATE BETWEEN $1 AND $2
+        ORDER BY end_date, id
+    `
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, from, to)
 	if err != nil {
-		return nil, fmt.Errorf("List subscriptions: %w", err)
+		return nil, fmt.Errorf("list ending subscriptions: %w", err)
 	}
 	defer rows.Close()
 
-	var subs []domain.Subscription
+	subs := []domain.Subscription{}
 	for rows.Next() {
 		var sub domain.Subscription
-		if err := rows.Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
-			&sub.StartDate, &sub.EndDate, &sub.CreatedAt, &sub.UpdatedAt); err != nil {
+		if err := scanSubscription(rows, &sub); err != nil {
 			return nil, err
 		}
 		subs = append(subs, sub)
 	}
-	r.logger.Debug("subscriptions list", zap.Int("count", len(subs)))
-	return subs, nil
+
+	return subs, rows.Err()
+}
+
+// LockUser блокирует запись подписок пользователя до конца транзакции ctx: параллельная
+// транзакция с той же блокировкой ждет ее коммита. Вне транзакции блокировка сразу снимается.
+func (r *SubscriptionRepository) LockUser(ctx context.Context, userID uuid.UUID) error {
+	query := `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`
+
+	if _, err := conn(ctx, r.db).Exec(ctx, query, "subscriptions:"+userID.String()); err != nil {
+		r.log(ctx).Error("failed to lock user subscriptions", zap.Error(err))
+		return fmt.Errorf("lock user subscriptions: %w", err)
+	}
+	return nil
+}
+
+// SharedWith возвращает чужие подписки, в которых пользователь участвует в делении стоимости
+func (r *SubscriptionRepository) SharedWith(ctx context.Context, userID uuid.UUID) ([]domain.Subscription, error) {
+	query := `
+        SELECT ` + subscriptionSelect + `
+        FROM subscriptions
+        WHERE EXISTS (SELECT 1 FROM subscription_members m
+                      WHERE m.subscription_id = subscriptions.id AND m.user_id = $1)
+        ORDER BY id
+    `
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list shared subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []domain.Subscription{}
+	for rows.Next() {
+		var sub domain.Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
 }
 
+func listQuery(filter domain.SubscriptionFilter) (string, []any) {
+	query, args := appendFilter(`SELECT `+subscriptionSelect+` FROM subscriptions WHERE 1=1`, nil, filter)
+
+	if filter.EndPeriod != nil {
+		args = append(args, *filter.EndPeriod)
+		query += fmt.Sprintf(" AND start_date <= $%d", len(args))
+	}
+
+	if filter.StartPeriod != nil {
+		args = append(args, *filter.StartPeriod)
+		query += fmt.Sprintf(" AND (end_date IS NULL OR end_date >= $%d)", len(args))
+	}
+
+	return query, args
+}
+
+// appendFilter дописывает условия по пользователю, сервису, категории и тегам;
+// номера параметров продолжают уже собранные args
+func appendFilter(query string, args []any, filter domain.SubscriptionFilter) (string, []any) {
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		query += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+
+	if filter.ServiceName != nil {
+		args = append(args, "%"+*filter.ServiceName+"%")
+		query += fmt.Sprintf(" AND service_name ILIKE $%d", len(args))
+	}
+
+	if filter.ServiceID != nil {
+		args = append(args, *filter.ServiceID)
+		query += fmt.Sprintf(" AND service_id = $%d", len(args))
+	}
+
+	if filter.Category != nil {
+		args = append(args, *filter.Category)
+		query += fmt.Sprintf(" AND category_id IN (SELECT id FROM categories WHERE lower(name) = lower($%d))", len(args))
+	}
+
+	if filter.CategoryID != nil {
+		args = append(args, *filter.CategoryID)
+		query += fmt.Sprintf(" AND category_id = $%d", len(args))
+	}
+
+	if tags := domain.NormalizeTags(filter.Tags); len(tags) > 0 {
+		args = append(args, tags)
+		matched := fmt.Sprintf(`SELECT COUNT(*) FROM subscription_tags st JOIN tags t ON t.id = st.tag_id
+            WHERE st.subscription_id = subscriptions.id AND t.name = ANY($%d)`, len(args))
+
+		if filter.TagMatch == domain.TagMatchAll {
+			query += fmt.Sprintf(" AND (%s) = %d", matched, len(tags))
+		} else {
+			query += fmt.Sprintf(" AND (%s) > 0", matched)
+		}
+	}
+
+	return query, args
+}
+
+// Update сохраняет поля подписки. Теги заменяются, только если sub.Tags != nil,
+// участники - только если sub.Split != nil (пустой Split убирает деление),
+// изменения цены - только если sub.PriceChanges != nil.
 func (r *SubscriptionRepository) Update(ctx context.Context, sub *domain.Subscription) error {
+	if sub.Tags == nil && sub.Split == nil && sub.PriceChanges == nil {
+		return r.update(ctx, conn(ctx, r.db), sub)
+	}
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := r.update(ctx, tx, sub); err != nil {
+		return err
+	}
+
+	if sub.Tags != nil {
+		if err := replaceTags(ctx, tx, sub.ID, sub.Tags); err != nil {
+			return err
+		}
+	}
+
+	if sub.Split != nil {
+		if err := replaceMembers(ctx, tx, sub.ID, sub.Split); err != nil {
+			return err
+		}
+	}
+
+	if sub.PriceChanges != nil {
+		if err := replacePriceChanges(ctx, tx, sub.ID, sub.PriceChanges); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SubscriptionRepository) update(ctx context.Context, db rowQuerier, sub *domain.Subscription) error {
 	query := `
         UPDATE subscriptions
-        SET service_name = $1, price = $2, start_date = $3, end_date = $4
-        WHERE id = $5
+        SET service_name = $1, price = $2, start_date = $3, end_date = $4, service_id = $5, category_id = $6,
+            billing_period = $7
+        WHERE id = $8
         RETURNING updated_at
     `
 
-	err := r.db.QueryRow(ctx, query, sub.ServiceName, sub.Price, sub.StartDate, sub.EndDate, sub.ID).Scan(&sub.UpdatedAt)
+	err := db.QueryRow(ctx, query, sub.ServiceName, sub.Price, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID,
+		billingPeriod(sub), sub.ID).Scan(&sub.UpdatedAt)
 
 	if err != nil {
-		r.logger.Error("failed to update subscription", zap.String("id", sub.ID.String()), zap.Error(err))
-		return fmt.Errorf("update subscription: %w", err)
+		r.log(ctx).Error("failed to update subscription", zap.String("id", sub.ID.String()), zap.Error(err))
+		return recordError("update subscription", err)
 	}
 
-	r.logger.Info("subscription updated", zap.String("id", sub.ID.String()))
+	r.log(ctx).Info("subscription updated", zap.String("id", sub.ID.String()))
+	return nil
+}
+
+// SetTags заменяет теги подписки; domain.ErrNotFound, если подписки нет
+func (r *SubscriptionRepository) SetTags(ctx context.Context, id uuid.UUID, tags []string) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT true FROM subscriptions WHERE id = $1 FOR UPDATE`, id).Scan(&exists); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrNotFound
+		}
+		return fmt.Errorf("lock subscription: %w", err)
+	}
+
+	if err := replaceTags(ctx, tx, id, tags); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	r.log(ctx).Info("subscription tags updated", zap.String("id", id.String()), zap.Strings("tags", tags))
 	return nil
 }
 
 func (r *SubscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM subscriptions WHERE id = $1`
 
-	_, err := r.db.Exec(ctx, query, id)
+	_, err := conn(ctx, r.db).Exec(ctx, query, id)
 	if err != nil {
-		r.logger.Error("failed to delete subscription", zap.String("id", id.String()), zap.Error(err))
+		r.log(ctx).Error("failed to delete subscription", zap.String("id", id.String()), zap.Error(err))
 		return fmt.Errorf("delete subscription")
 	}
 
-	r.logger.Info("subscription deleted", zap.String("id", id.String()))
+	r.log(ctx).Info("subscription deleted", zap.String("id", id.String()))
 	return nil
 }
 
-func (r *SubscriptionRepository) TotalCost(ctx context.Context, filter domain.SubscriptionFilter) (int, error) {
-	query := `
-	        SELECT COALESCE(SUM(
-            price * (
+// overlapMonthsSQL - число оплачиваемых месяцев подписки в периоде [$1, $2].
+// Go-версия - cost.WholeMonths; их совпадение проверяет cost_sql_test.go.
+const overlapMonthsSQL = `(
                 EXTRACT(YEAR FROM AGE(
                     LEAST(COALESCE(end_date, $2), $2),
                     GREATEST(start_date, $1)
@@ -154,34 +596,190 @@ func (r *SubscriptionRepository) TotalCost(ctx context.Context, filter domain.Su
                     LEAST(COALESCE(end_date, $2), $2),
                     GREATEST(start_date, $1)
                 )) + 1
-            )
-        ), 0)::INTEGER
-        FROM subscriptions
+            )`
+
+// userShareSQL - ежемесячная доля пользователя $3 в подписке: владелец платит
+// остаток цены после долей участников, участник - свою долю
+const userShareSQL = `(CASE WHEN user_id = $3
+                THEN price - COALESCE((SELECT SUM(m.amount) FROM subscription_members m
+                                       WHERE m.subscription_id = subscriptions.id), 0)
+                ELSE (SELECT m.amount FROM subscription_members m
+                      WHERE m.subscription_id = subscriptions.id AND m.user_id = $3)
+            END)`
+
+// costScope возвращает цену подписки и условия выборки для отчетов о стоимости за период [$1, $2].
+// С фильтром по пользователю учитываются и подписки, где он участник, а цена заменяется его долей.
+// Режимы кроме whole_months пересчитываются в cost и оплачивают месяц end_date целиком,
+// поэтому для них выбираются подписки, последний месяц которых пересекается с периодом.
+func costScope(filter domain.SubscriptionFilter) (string, string, []any) {
+	price := "price"
+	endBound := "$1"
+	if filter.CostMode != "" && filter.CostMode != domain.CostModeWholeMonths {
+		endBound = "DATE_TRUNC('month', $1::DATE)"
+	}
+	where := `
         WHERE start_date <= $2
-          AND (end_date IS NULL OR end_date >= $1)
-	`
-
+          AND (end_date IS NULL OR end_date >= ` + endBound + `)`
 	args := []any{filter.StartPeriod, filter.EndPeriod}
-	argID := 3
 
 	if filter.UserID != nil {
-		query += fmt.Sprintf(" AND user_id = $%d", argID)
 		args = append(args, *filter.UserID)
-		argID++
+		price = userShareSQL
+		where += `
+          AND (user_id = $3 OR EXISTS (SELECT 1 FROM subscription_members m
+                                      WHERE m.subscription_id = subscriptions.id AND m.user_id = $3))`
+		filter.UserID = nil
 	}
 
-	if filter.ServiceName != nil {
-		query += fmt.Sprintf(" AND service_name ILIKE $%d", argID)
-		args = append(args, "%"+*filter.ServiceName+"%")
-	}
+	where, args = appendFilter(where, args, filter)
+	return price, where, args
+}
+
+func (r *SubscriptionRepository) TotalCost(ctx context.Context, filter domain.SubscriptionFilter) (int, error) {
+	price, where, args := costScope(filter)
+	query := `
+	        SELECT COALESCE(SUM(` + price + ` * ` + overlapMonthsSQL + `), 0)::INTEGER
+        FROM subscriptions` + where
 
 	var total int
-	err := r.db.QueryRow(ctx, query, args...).Scan(&total)
+	err := conn(ctx, r.db).QueryRow(ctx, query, args...).Scan(&total)
 	if err != nil {
-		r.logger.Error("failed to calculate total", zap.Error(err))
+		r.log(ctx).Error("failed to calculate total", zap.Error(err))
 		return 0, fmt.Errorf("calculate total: %w", err)
 	}
 
-	r.logger.Info("total cost calculated", zap.Int("total", total))
+	r.log(ctx).Info("total cost calculated", zap.Int("total", total))
 	return total, nil
 }
+
+// costLineColumns - колонки подписки, нужные строке отчета о стоимости. Теги и деление
+// не выбираются: доля пользователя уже посчитана в share.
+const costLineColumns = `id, service_name, price, user_id, start_date, end_date, billing_period`
+
+// StreamCostBreakdown построчно передает в fn стоимость каждой подписки за период;
+// с фильтром по пользователю стоимость считается по его доле
+func (r *SubscriptionRepository) StreamCostBreakdown(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.CostLine) error) error {
+	price, where, args := costScope(filter)
+	query := `
+        SELECT ` + costLineColumns + `,
+            ` + price + ` AS share,
+            ` + overlapMonthsSQL + `::INTEGER AS months
+        FROM subscriptions` + where
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		r.log(ctx).Error("failed to calculate cost breakdown", zap.Error(err))
+		return fmt.Errorf("cost breakdown: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var line domain.CostLine
+		sub := &line.Subscription
+		err := rows.Scan(&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID, &sub.StartDate, &sub.EndDate, &sub.BillingPeriod,
+			&line.Share, &line.Months)
+		if err != nil {
+			return err
+		}
+		line.Cost = line.Share * line.Months
+		if err := fn(&line); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// StreamCharges построчно передает в fn подписки, у которых могут быть списания в периоде
+// filter, вместе с делением и изменениями цены. С фильтром по пользователю выбираются
+// и подписки, где он участник; сумму его доли считает domain.Subscription.ChargeAmount.
+func (r *SubscriptionRepository) StreamCharges(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.Subscription) error) error {
+	filter.CostMode = domain.CostModeChargeEvents
+	_, where, args := costScope(filter)
+	query := `SELECT ` + subscriptionSelect + ` FROM subscriptions` + where
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		r.log(ctx).Error("failed to list charges", zap.Error(err))
+		return fmt.Errorf("list charges: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sub domain.Subscription
+		if err := scanSubscription(rows, &sub); err != nil {
+			return err
+		}
+		if err := fn(&sub); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Stats считает подписки, которые уже начались к дню on и оплачиваются в его месяце
+func (r *SubscriptionRepository) Stats(ctx context.Context, on time.Time) (domain.SubscriptionStats, error) {
+	query := `
+        SELECT COUNT(*), COALESCE(SUM(price), 0)::INTEGER
+        FROM subscriptions
+        WHERE start_date <= $1
+          AND (end_date IS NULL OR end_date >= DATE_TRUNC('month', $1::DATE))
+    `
+
+	var stats domain.SubscriptionStats
+	if err := conn(ctx, r.db).QueryRow(ctx, query, on).Scan(&stats.Active, &stats.MonthlySpend); err != nil {
+		r.log(ctx).Error("failed to calculate subscription stats", zap.Error(err))
+		return domain.SubscriptionStats{}, fmt.Errorf("subscription stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// groupJoins - как подписка попадает в группу отчета. По тегам подписка
+// учитывается в каждой своей группе, поэтому сумма групп может превышать итог.
+var groupJoins = map[string]string{
+	domain.GroupByCategory: `LEFT JOIN categories g ON g.id = s.category_id`,
+	domain.GroupByTag: `LEFT JOIN subscription_tags st ON st.subscription_id = s.id
+        LEFT JOIN tags g ON g.id = st.tag_id`,
+}
+
+// TotalCostByGroup считает стоимость за период по категориям или тегам,
+// группы отсортированы по убыванию суммы
+func (r *SubscriptionRepository) TotalCostByGroup(ctx context.Context, filter domain.SubscriptionFilter, groupBy string) ([]domain.CostGroup, error) {
+	join, ok := groupJoins[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown group_by %q", groupBy)
+	}
+
+	price, where, args := costScope(filter)
+	inner := `
+        SELECT id, category_id, ` + price + ` * ` + overlapMonthsSQL + ` AS cost
+        FROM subscriptions` + where
+
+	query := `
+        SELECT g.name, SUM(s.cost)::INTEGER AS total
+        FROM (` + inner + `) s
+        ` + join + `
+        GROUP BY g.name
+        ORDER BY total DESC, g.name
+    `
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, args...)
+	if err != nil {
+		r.log(ctx).Error("failed to calculate grouped total", zap.String("group_by", groupBy), zap.Error(err))
+		return nil, fmt.Errorf("calculate grouped total: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []domain.CostGroup{}
+	for rows.Next() {
+		var group domain.CostGroup
+		if err := rows.Scan(&group.Key, &group.Total); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
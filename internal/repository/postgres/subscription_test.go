@@ -6,11 +6,15 @@ import (
 	"time"
 
 	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
 	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestSubscriptionRepository_Create(t *testing.T) {
@@ -28,7 +32,7 @@ func TestSubscriptionRepository_Create(t *testing.T) {
 		AddRow(sub.ID, sub.CreatedAt, sub.UpdatedAt)
 
 	mock.ExpectQuery("INSERT INTO subscriptions").
-		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate).
+		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingMonthly).
 		WillReturnRows(rows)
 	err = repo.Create(ctx, sub)
 
@@ -48,7 +52,7 @@ func TestSubscriptionRepository_Create_Error(t *testing.T) {
 	sub := testutil.FixtureSubscription()
 
 	mock.ExpectQuery("INSERT INTO subscriptions").
-		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate).
+		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingMonthly).
 		WillReturnError(assert.AnError)
 
 	err = repo.Create(ctx, sub)
@@ -70,10 +74,10 @@ func TestSubscriptionRepository_GetByID(t *testing.T) {
 
 	rows := pgxmock.NewRows([]string{
 		"id", "service_name", "price", "user_id",
-		"start_date", "end_date", "created_at", "updated_at",
+		"start_date", "end_date", "created_at", "updated_at", "service_id", "category_id", "billing_period", "tags", "split", "price_changes",
 	}).AddRow(
 		expectedSub.ID, expectedSub.ServiceName, expectedSub.Price, expectedSub.UserID,
-		expectedSub.StartDate, expectedSub.EndDate, expectedSub.CreatedAt, expectedSub.UpdatedAt,
+		expectedSub.StartDate, expectedSub.EndDate, expectedSub.CreatedAt, expectedSub.UpdatedAt, expectedSub.ServiceID, expectedSub.CategoryID, domain.BillingMonthly, []string{}, nil, nil,
 	)
 
 	mock.ExpectQuery("SELECT (.+) FROM subscriptions WHERE id").
@@ -105,12 +109,12 @@ func TestSubscriptionRepository_List(t *testing.T) {
 
 	rows := pgxmock.NewRows([]string{
 		"id", "service_name", "price", "user_id",
-		"start_date", "end_date", "created_at", "updated_at",
+		"start_date", "end_date", "created_at", "updated_at", "service_id", "category_id", "billing_period", "tags", "split", "price_changes",
 	}).
 		AddRow(sub1.ID, sub1.ServiceName, sub1.Price, sub1.UserID,
-			sub1.StartDate, sub1.EndDate, sub1.CreatedAt, sub1.UpdatedAt).
+			sub1.StartDate, sub1.EndDate, sub1.CreatedAt, sub1.UpdatedAt, sub1.ServiceID, sub1.CategoryID, domain.BillingMonthly, []string{}, nil, nil).
 		AddRow(sub2.ID, sub2.ServiceName, sub2.Price, sub2.UserID,
-			sub2.StartDate, sub2.EndDate, sub2.CreatedAt, sub2.UpdatedAt)
+			sub2.StartDate, sub2.EndDate, sub2.CreatedAt, sub2.UpdatedAt, sub2.ServiceID, sub2.CategoryID, domain.BillingMonthly, []string{}, nil, nil)
 
 	filter := domain.SubscriptionFilter{
 		UserID: &userID,
@@ -142,7 +146,7 @@ func TestSubscriptionRepository_Update(t *testing.T) {
 	rows := pgxmock.NewRows([]string{"updated_at"}).AddRow(newUpdatedAt)
 
 	mock.ExpectQuery("UPDATE subscriptions SET").
-		WithArgs(sub.ServiceName, sub.Price, sub.StartDate, sub.EndDate, sub.ID).
+		WithArgs(sub.ServiceName, sub.Price, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingMonthly, sub.ID).
 		WillReturnRows(rows)
 
 	err = repo.Update(ctx, sub)
@@ -173,6 +177,47 @@ func TestSubscriptionRepository_Delete(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestSubscriptionRepository_LockUser(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+	userID := testutil.FixtureUserID()
+
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(hashtextextended\(\$1, 0\)\)`).
+		WithArgs("subscriptions:" + userID.String()).
+		WillReturnResult(pgxmock.NewResult("SELECT", 1))
+
+	require.NoError(t, repo.LockUser(context.Background(), userID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Delete_LogsThroughRequestLogger(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repoCore, repoLogs := observer.New(zap.DebugLevel)
+	repo := NewSubscriptionRepository(mock, zap.New(repoCore))
+
+	requestCore, requestLogs := observer.New(zap.DebugLevel)
+	ctx := applogger.WithContext(context.Background(), zap.New(requestCore).With(zap.String("request_id", "req-1")))
+	id := testutil.FixtureSubscriptionID()
+
+	mock.ExpectExec("DELETE FROM subscriptions WHERE id").
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	err = repo.Delete(ctx, id)
+
+	require.NoError(t, err)
+	assert.Zero(t, repoLogs.Len())
+	entries := requestLogs.FilterMessage("subscription deleted").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "req-1", entries[0].ContextMap()["request_id"])
+}
+
 func TestSubscriptionRepository_TotalCost(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
@@ -204,3 +249,427 @@ func TestSubscriptionRepository_TotalCost(t *testing.T) {
 	assert.Equal(t, expectedTotal, total)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestSubscriptionRepository_List_Period(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := NewSubscriptionRepository(mock, logger)
+
+	ctx := context.Background()
+	startPeriod := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	endPeriod := time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC)
+
+	filter := domain.SubscriptionFilter{
+		StartPeriod: &startPeriod,
+		EndPeriod:   &endPeriod,
+	}
+
+	rows := pgxmock.NewRows([]string{
+		"id", "service_name", "price", "user_id",
+		"start_date", "end_date", "created_at", "updated_at", "service_id", "category_id", "billing_period", "tags", "split", "price_changes",
+	})
+
+	mock.ExpectQuery(`SELECT (.+) FROM subscriptions WHERE 1=1 AND start_date <= \$1 AND \(end_date IS NULL OR end_date >= \$2\)`).
+		WithArgs(endPeriod, startPeriod).
+		WillReturnRows(rows)
+
+	subs, err := repo.List(ctx, filter)
+
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_CreateBatch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := NewSubscriptionRepository(mock, logger)
+
+	ctx := context.Background()
+	sub1 := testutil.FixtureSubscription()
+	sub2 := testutil.FixtureSubscription()
+
+	mock.ExpectBegin()
+	for _, sub := range []*domain.Subscription{sub1, sub2} {
+		mock.ExpectQuery("INSERT INTO subscriptions").
+			WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingMonthly).
+			WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).
+				AddRow(sub.ID, sub.CreatedAt, sub.UpdatedAt))
+	}
+	mock.ExpectCommit()
+
+	err = repo.CreateBatch(ctx, []*domain.Subscription{sub1, sub2})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_CreateBatch_Rollback(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := NewSubscriptionRepository(mock, logger)
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO subscriptions").
+		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingMonthly).
+		WillReturnError(assert.AnError)
+	mock.ExpectRollback()
+
+	err = repo.CreateBatch(ctx, []*domain.Subscription{sub})
+
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_StreamCostBreakdown(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := NewSubscriptionRepository(mock, logger)
+
+	ctx := context.Background()
+	startPeriod := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	endPeriod := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	userID := testutil.FixtureUserID()
+	sub := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithPrice(400))
+
+	filter := domain.SubscriptionFilter{
+		UserID:      &userID,
+		StartPeriod: &startPeriod,
+		EndPeriod:   &endPeriod,
+	}
+
+	rows := pgxmock.NewRows([]string{
+		"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_period", "share", "months",
+	}).AddRow(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, domain.BillingMonthly, 300, 6)
+
+	// совместная подписка: пользователь платит только свою долю; теги и деление не выбираются
+	mock.ExpectQuery(`SELECT id, service_name, price, user_id, start_date, end_date, billing_period,\s+(.+) AS share, (.+) AS months FROM subscriptions WHERE (.+) AND \(user_id = \$3 OR EXISTS (.+)m.user_id = \$3\)\)`).
+		WithArgs(&startPeriod, &endPeriod, userID).
+		WillReturnRows(rows)
+
+	var lines []domain.CostLine
+	err = repo.StreamCostBreakdown(ctx, filter, func(line *domain.CostLine) error {
+		lines = append(lines, *line)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, sub.ID, lines[0].Subscription.ID)
+	assert.Equal(t, 300, lines[0].Share)
+	assert.Equal(t, 6, lines[0].Months)
+	assert.Equal(t, 1800, lines[0].Cost)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_StreamCostBreakdown_RecalculatedModeSelectsEndMonth(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	startPeriod := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	endPeriod := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	filter := domain.SubscriptionFilter{
+		StartPeriod: &startPeriod,
+		EndPeriod:   &endPeriod,
+		CostMode:    domain.CostModeChargeEvents,
+	}
+
+	// подписка с end_date 1 марта оплачена за март, хотя end_date раньше начала периода
+	mock.ExpectQuery(`WHERE start_date <= \$2\s+AND \(end_date IS NULL OR end_date >= DATE_TRUNC\('month', \$1::DATE\)\)`).
+		WithArgs(&startPeriod, &endPeriod).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "service_name", "price", "user_id", "start_date", "end_date", "billing_period", "share", "months",
+		}))
+
+	err = repo.StreamCostBreakdown(context.Background(), filter, func(*domain.CostLine) error { return nil })
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_EndingBetween_ComparesLastDayOfEndMonth(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	from := time.Date(2025, 3, 26, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`WHERE \(DATE_TRUNC\('month', end_date\) \+ INTERVAL '1 month - 1 day'\)::DATE BETWEEN \$1 AND \$2`).
+		WithArgs(from, to).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "service_name", "price", "user_id",
+			"start_date", "end_date", "created_at", "updated_at", "service_id", "category_id", "billing_period", "tags", "split", "price_changes",
+		}))
+
+	subs, err := repo.EndingBetween(context.Background(), from, to)
+
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Restore(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	logger := zaptest.NewLogger(t)
+	repo := NewSubscriptionRepository(mock, logger)
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO subscriptions \(id, (.+), billing_period\)`).
+		WithArgs(sub.ID, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.CreatedAt, sub.UpdatedAt, sub.ServiceID, sub.CategoryID, domain.BillingMonthly).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = repo.Restore(ctx, []domain.Subscription{*sub})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Create_WithTags(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription()
+	sub.Tags = []string{"music", "family"}
+	newID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO subscriptions").
+		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingMonthly).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(newID, sub.CreatedAt, sub.UpdatedAt))
+	mock.ExpectExec("DELETE FROM subscription_tags").WithArgs(newID).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec("INSERT INTO tags").WithArgs(sub.Tags).WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	mock.ExpectExec("INSERT INTO subscription_tags").WithArgs(newID, sub.Tags).WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	mock.ExpectCommit()
+
+	err = repo.Create(ctx, sub)
+
+	require.NoError(t, err)
+	assert.Equal(t, newID, sub.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Create_Shared(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	member := uuid.New()
+	sub := testutil.FixtureSubscription(testutil.WithPrice(600))
+	sub.Split = &domain.Split{
+		Mode:    domain.SplitEqual,
+		Members: []domain.Member{{UserID: member, Amount: 300}},
+	}
+	newID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO subscriptions").
+		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingMonthly).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(newID, sub.CreatedAt, sub.UpdatedAt))
+	mock.ExpectExec("DELETE FROM subscription_members").WithArgs(newID).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec("INSERT INTO subscription_members").
+		WithArgs(newID, domain.SplitEqual, []uuid.UUID{member}, []int{0}, []int{300}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = repo.Create(ctx, sub)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Create_PriceChanges(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	sub := testutil.FixtureSubscription(testutil.WithPrice(400))
+	sub.BillingPeriod = domain.BillingYearly
+	change := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sub.PriceChanges = []domain.PriceChange{{EffectiveFrom: change, Price: 500}}
+	newID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO subscriptions").
+		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingYearly).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(newID, sub.CreatedAt, sub.UpdatedAt))
+	mock.ExpectExec("DELETE FROM subscription_price_changes").WithArgs(newID).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec("INSERT INTO subscription_price_changes").
+		WithArgs(newID, []time.Time{change}, []int{500}).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = repo.Create(context.Background(), sub)
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_StreamCharges_SelectsMemberSubscriptions(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC)
+	userID := testutil.FixtureUserID()
+	sub := testutil.FixtureSubscription()
+	changes := []domain.PriceChange{{EffectiveFrom: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Price: 900}}
+
+	// месяц end_date оплачен, поэтому граница - начало месяца from
+	mock.ExpectQuery(`FROM subscriptions\s+WHERE start_date <= \$2\s+AND \(end_date IS NULL OR end_date >= DATE_TRUNC\('month', \$1::DATE\)\)\s+AND \(user_id = \$3 OR EXISTS`).
+		WithArgs(&from, &to, userID).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "service_name", "price", "user_id",
+			"start_date", "end_date", "created_at", "updated_at", "service_id", "category_id", "billing_period", "tags", "split", "price_changes",
+		}).AddRow(sub.ID, sub.ServiceName, sub.Price, sub.UserID,
+			sub.StartDate, sub.EndDate, sub.CreatedAt, sub.UpdatedAt, sub.ServiceID, sub.CategoryID, domain.BillingQuarterly, []string{}, nil, changes))
+
+	var subs []domain.Subscription
+	err = repo.StreamCharges(context.Background(), domain.SubscriptionFilter{UserID: &userID, StartPeriod: &from, EndPeriod: &to},
+		func(sub *domain.Subscription) error {
+			subs = append(subs, *sub)
+			return nil
+		})
+
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, domain.BillingQuarterly, subs[0].BillingPeriod)
+	assert.Equal(t, changes, subs[0].PriceChanges)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_List_AllTags(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	category := "Streaming"
+	filter := domain.SubscriptionFilter{
+		Category: &category,
+		Tags:     []string{"Family", "music", "family"},
+		TagMatch: domain.TagMatchAll,
+	}
+
+	mock.ExpectQuery(`FROM subscriptions WHERE 1=1 AND category_id IN \(SELECT id FROM categories WHERE lower\(name\) = lower\(\$1\)\) AND \((.+)t.name = ANY\(\$2\)\) = 2`).
+		WithArgs(category, []string{"family", "music"}).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}))
+
+	subs, err := repo.List(context.Background(), filter)
+
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_TotalCostByGroup(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	startPeriod := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	endPeriod := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	filter := domain.SubscriptionFilter{StartPeriod: &startPeriod, EndPeriod: &endPeriod}
+	streaming := "streaming"
+
+	mock.ExpectQuery(`SELECT g.name, (.+) LEFT JOIN categories g ON g.id = s.category_id GROUP BY g.name`).
+		WithArgs(&startPeriod, &endPeriod).
+		WillReturnRows(pgxmock.NewRows([]string{"name", "total"}).
+			AddRow(&streaming, 9600).
+			AddRow(nil, 1200))
+
+	groups, err := repo.TotalCostByGroup(context.Background(), filter, domain.GroupByCategory)
+
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "streaming", *groups[0].Key)
+	assert.Equal(t, 9600, groups[0].Total)
+	assert.Nil(t, groups[1].Key)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Stats(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+	on := time.Date(2025, 8, 28, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(SUM\(price\), 0\)(.+)DATE_TRUNC\('month'`).
+		WithArgs(on).
+		WillReturnRows(pgxmock.NewRows([]string{"count", "sum"}).AddRow(3, 1200))
+
+	stats, err := repo.Stats(context.Background(), on)
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.SubscriptionStats{Active: 3, MonthlySpend: 1200}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_SharedWith(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+	userID := testutil.FixtureUserID()
+	sub := testutil.FixtureSubscription()
+
+	mock.ExpectQuery(`FROM subscriptions\s+WHERE EXISTS \(SELECT 1 FROM subscription_members m\s+WHERE m.subscription_id = subscriptions.id AND m.user_id = \$1\)`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "service_name", "price", "user_id",
+			"start_date", "end_date", "created_at", "updated_at", "service_id", "category_id", "billing_period", "tags", "split", "price_changes",
+		}).AddRow(sub.ID, sub.ServiceName, sub.Price, sub.UserID,
+			sub.StartDate, sub.EndDate, sub.CreatedAt, sub.UpdatedAt, sub.ServiceID, sub.CategoryID, domain.BillingMonthly, []string{}, nil, nil))
+
+	subs, err := repo.SharedWith(context.Background(), userID)
+
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, sub.ID, subs[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
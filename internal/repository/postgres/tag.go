@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+// TagRepository управляет справочником тегов. Теги создаются при назначении
+// подпискам (см. SubscriptionRepository.SetTags), здесь - переименование и удаление.
+type TagRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewTagRepository(db PgxPool, logger *zap.Logger) *TagRepository {
+	return &TagRepository{db: db, logger: logger}
+}
+
+// List возвращает теги с числом подписок, отсортированные по имени
+func (r *TagRepository) List(ctx context.Context) ([]domain.Tag, error) {
+	query := `
+        SELECT t.id, t.name, COUNT(st.subscription_id)::INTEGER, t.created_at
+        FROM tags t
+        LEFT JOIN subscription_tags st ON st.tag_id = t.id
+        GROUP BY t.id
+        ORDER BY t.name
+    `
+
+	rows, err := conn(ctx, r.db).Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []domain.Tag{}
+	for rows.Next() {
+		var t domain.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Subscriptions, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	return tags, rows.Err()
+}
+
+func (r *TagRepository) Rename(ctx context.Context, id uuid.UUID, name string) (*domain.Tag, error) {
+	query := `
+        UPDATE tags SET name = $1 WHERE id = $2
+        RETURNING id, name, (SELECT COUNT(*)::INTEGER FROM subscription_tags WHERE tag_id = $2), created_at
+    `
+
+	var t domain.Tag
+	if err := conn(ctx, r.db).QueryRow(ctx, query, name, id).Scan(&t.ID, &t.Name, &t.Subscriptions, &t.CreatedAt); err != nil {
+		r.logger.Error("failed to rename tag", zap.String("id", id.String()), zap.Error(err))
+		return nil, recordError("rename tag", err)
+	}
+
+	return &t, nil
+}
+
+// Delete удаляет тег вместе с его назначениями подпискам
+func (r *TagRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM tags WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete tag", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("delete tag: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *TagRepository) CountTags(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM tags`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count tags: %w", err)
+	}
+	return count, nil
+}
+
+func (r *TagRepository) StreamTags(ctx context.Context, fn func(*domain.Tag) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT id, name, created_at FROM tags`)
+	if err != nil {
+		return fmt.Errorf("list tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t domain.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt); err != nil {
+			return err
+		}
+		if err := fn(&t); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *TagRepository) RestoreTags(ctx context.Context, tags []domain.Tag) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, t := range tags {
+		if _, err := tx.Exec(ctx, `INSERT INTO tags (id, name, created_at) VALUES ($1, $2, $3)`, t.ID, t.Name, t.CreatedAt); err != nil {
+			return fmt.Errorf("restore tag %s: %w", t.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TagRepository) CountLinks(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM subscription_tags`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count subscription tags: %w", err)
+	}
+	return count, nil
+}
+
+func (r *TagRepository) StreamLinks(ctx context.Context, fn func(*domain.SubscriptionTag) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT subscription_id, tag_id FROM subscription_tags`)
+	if err != nil {
+		return fmt.Errorf("list subscription tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var link domain.SubscriptionTag
+		if err := rows.Scan(&link.SubscriptionID, &link.TagID); err != nil {
+			return err
+		}
+		if err := fn(&link); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *TagRepository) RestoreLinks(ctx context.Context, links []domain.SubscriptionTag) error {
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, link := range links {
+		_, err := tx.Exec(ctx, `INSERT INTO subscription_tags (subscription_id, tag_id) VALUES ($1, $2)`, link.SubscriptionID, link.TagID)
+		if err != nil {
+			return fmt.Errorf("restore subscription tag %s/%s: %w", link.SubscriptionID, link.TagID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
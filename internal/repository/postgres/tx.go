@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type txKey struct{}
+
+// conn - транзакция, открытая withinTx, или пул, если ее нет.
+// Так запросы репозиториев внутри WithinTx попадают в одну транзакцию.
+func conn(ctx context.Context, db PgxPool) PgxPool {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// withinTx выполняет fn в транзакции, которую видят репозитории через conn. mode -
+// параметры SET TRANSACTION, пустая строка - по умолчанию. Вложенный вызов присоединяется
+// к внешней транзакции, и ее параметры не меняются.
+func withinTx(ctx context.Context, db PgxPool, mode string, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if mode != "" {
+		if _, err := tx.Exec(ctx, "SET TRANSACTION "+mode); err != nil {
+			return fmt.Errorf("set transaction: %w", err)
+		}
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// Transactor открывает транзакции для сервисов, которым нужно несколько репозиториев сразу
+type Transactor struct {
+	db PgxPool
+}
+
+func NewTransactor(db PgxPool) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTx выполняет fn в транзакции; репозитории, получившие ее контекст, работают в ней
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, t.db, "", fn)
+}
+
+// WithinSnapshot выполняет fn в транзакции только для чтения с уровнем REPEATABLE READ:
+// все запросы видят один снимок базы
+func (t *Transactor) WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, t.db, "ISOLATION LEVEL REPEATABLE READ, READ ONLY", fn)
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestTransactor_WithinSnapshot(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	tx := NewTransactor(mock)
+	users := NewUserRepository(mock, zaptest.NewLogger(t))
+	webhooks := NewWebhookRepository(mock, zaptest.NewLogger(t))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY`).
+		WillReturnResult(pgxmock.NewResult("SET", 0))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM webhooks`).WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err = tx.WithinSnapshot(context.Background(), func(ctx context.Context) error {
+		if _, err := users.Count(ctx); err != nil {
+			return err
+		}
+		_, err := webhooks.Count(ctx)
+		return err
+	})
+
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactor_WithinTx_RollbackOnError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	tx := NewTransactor(mock)
+	failure := errors.New("archive is truncated")
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = tx.WithinTx(context.Background(), func(ctx context.Context) error {
+		// вложенный вызов присоединяется к внешней транзакции
+		return tx.WithinTx(ctx, func(context.Context) error { return failure })
+	})
+
+	assert.ErrorIs(t, err, failure)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
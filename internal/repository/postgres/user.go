@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+type UserRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewUserRepository(db PgxPool, logger *zap.Logger) *UserRepository {
+	return &UserRepository{db: db, logger: logger}
+}
+
+const userColumns = `id, name, email, currency, timezone, created_at, updated_at`
+
+func scanUser(row pgx.Row, u *domain.User) error {
+	return row.Scan(&u.ID, &u.Name, &u.Email, &u.Currency, &u.Timezone, &u.CreatedAt, &u.UpdatedAt)
+}
+
+func (r *UserRepository) Create(ctx context.Context, u *domain.User) error {
+	query := `
+        INSERT INTO users (name, email, currency, timezone)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at, updated_at
+    `
+	err := conn(ctx, r.db).QueryRow(ctx, query, u.Name, u.Email, u.Currency, u.Timezone).Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to create user", zap.Error(err))
+		return recordError("create user", err)
+	}
+
+	r.logger.Info("user created", zap.String("id", u.ID.String()))
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	var u domain.User
+	if err := scanUser(conn(ctx, r.db).QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id), &u); err != nil {
+		return nil, recordError("get user", err)
+	}
+
+	return &u, nil
+}
+
+func (r *UserRepository) List(ctx context.Context) ([]domain.User, error) {
+	users := []domain.User{}
+	err := r.Stream(ctx, func(u *domain.User) error {
+		users = append(users, *u)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) Update(ctx context.Context, u *domain.User) error {
+	query := `
+        UPDATE users
+        SET name = $1, email = $2, currency = $3, timezone = $4
+        WHERE id = $5
+        RETURNING created_at, updated_at
+    `
+	err := conn(ctx, r.db).QueryRow(ctx, query, u.Name, u.Email, u.Currency, u.Timezone, u.ID).Scan(&u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		r.logger.Error("failed to update user", zap.String("id", u.ID.String()), zap.Error(err))
+		return recordError("update user", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет пользователя вместе с его подписками, бюджетами и календарем
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM users WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete user", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	r.logger.Info("user deleted", zap.String("id", id.String()))
+	return nil
+}
+
+func (r *UserRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count users: %w", err)
+	}
+	return count, nil
+}
+
+// Stream передает пользователей в fn по одному в порядке имени
+func (r *UserRepository) Stream(ctx context.Context, fn func(*domain.User) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+userColumns+` FROM users ORDER BY name, id`)
+	if err != nil {
+		return fmt.Errorf("list users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u domain.User
+		if err := scanUser(rows, &u); err != nil {
+			return err
+		}
+		if err := fn(&u); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *UserRepository) Restore(ctx context.Context, users []domain.User) error {
+	query := `INSERT INTO users (` + userColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, u := range users {
+		if _, err := tx.Exec(ctx, query, u.ID, u.Name, u.Email, u.Currency, u.Timezone, u.CreatedAt, u.UpdatedAt); err != nil {
+			return fmt.Errorf("restore user %s: %w", u.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
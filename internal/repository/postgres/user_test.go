@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestUserRepository_Create_EmailTaken(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(mock, zaptest.NewLogger(t))
+
+	email := "ivan@example.com"
+	user := &domain.User{Name: "Иван", Email: &email, Currency: "RUB", Timezone: "UTC"}
+
+	mock.ExpectQuery("INSERT INTO users").
+		WithArgs(user.Name, user.Email, user.Currency, user.Timezone).
+		WillReturnError(&pgconn.PgError{Code: uniqueViolation})
+
+	err = repo.Create(context.Background(), user)
+
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_GetByID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewUserRepository(mock, zaptest.NewLogger(t))
+
+	id := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT (.+) FROM users WHERE id").
+		WithArgs(id).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "email", "currency", "timezone", "created_at", "updated_at"}).
+			AddRow(id, "Иван", nil, "RUB", "Europe/Moscow", now, now))
+
+	user, err := repo.GetByID(context.Background(), id)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Moscow", user.Timezone)
+	assert.Nil(t, user.Email)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSubscriptionRepository_Create_UnknownUser(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewSubscriptionRepository(mock, zaptest.NewLogger(t))
+
+	sub := &domain.Subscription{ServiceName: "Yandex Plus", Price: 400, UserID: uuid.New(), StartDate: time.Now()}
+
+	mock.ExpectQuery("INSERT INTO subscriptions").
+		WithArgs(sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate, sub.ServiceID, sub.CategoryID, domain.BillingMonthly).
+		WillReturnError(&pgconn.PgError{Code: foreignKeyViolation, TableName: "subscriptions", ConstraintName: "subscriptions_user_id_fkey"})
+
+	err = repo.Create(context.Background(), sub)
+
+	assert.ErrorIs(t, err, domain.ErrReferenceNotFound)
+	assert.ErrorContains(t, err, "user_id")
+}
@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+type WebhookRepository struct {
+	db     PgxPool
+	logger *zap.Logger
+}
+
+func NewWebhookRepository(db PgxPool, logger *zap.Logger) *WebhookRepository {
+	return &WebhookRepository{db: db, logger: logger}
+}
+
+const webhookColumns = `id, url, secret, events, active, created_at`
+
+func scanWebhook(row pgx.Row, w *domain.Webhook) error {
+	var events []string
+	if err := row.Scan(&w.ID, &w.URL, &w.Secret, &events, &w.Active, &w.CreatedAt); err != nil {
+		return err
+	}
+
+	w.Events = make([]domain.EventType, len(events))
+	for i, e := range events {
+		w.Events[i] = domain.EventType(e)
+	}
+	return nil
+}
+
+func eventNames(events []domain.EventType) []string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = string(e)
+	}
+	return names
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, w *domain.Webhook) error {
+	query := `
+        INSERT INTO webhooks (url, secret, events)
+        VALUES ($1, $2, $3)
+        RETURNING id, active, created_at
+    `
+
+	err := conn(ctx, r.db).QueryRow(ctx, query, w.URL, w.Secret, eventNames(w.Events)).Scan(&w.ID, &w.Active, &w.CreatedAt)
+	if err != nil {
+		r.logger.Error("failed to create webhook", zap.Error(err))
+		return recordError("create webhook", err)
+	}
+
+	r.logger.Info("webhook created", zap.String("id", w.ID.String()))
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	var w domain.Webhook
+	if err := scanWebhook(conn(ctx, r.db).QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, id), &w); err != nil {
+		return nil, recordError("get webhook", err)
+	}
+
+	return &w, nil
+}
+
+func (r *WebhookRepository) List(ctx context.Context) ([]domain.Webhook, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []domain.Webhook{}
+	for rows.Next() {
+		var w domain.Webhook
+		if err := scanWebhook(rows, &w); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := conn(ctx, r.db).Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		r.logger.Error("failed to delete webhook", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return domain.ErrNotFound
+	}
+
+	r.logger.Info("webhook deleted", zap.String("id", id.String()))
+	return nil
+}
+
+func (r *WebhookRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := conn(ctx, r.db).QueryRow(ctx, `SELECT COUNT(*) FROM webhooks`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count webhooks: %w", err)
+	}
+	return count, nil
+}
+
+// Stream передает webhook в fn по одному вместе с секретами
+func (r *WebhookRepository) Stream(ctx context.Context, fn func(*domain.Webhook) error) error {
+	rows, err := conn(ctx, r.db).Query(ctx, `SELECT `+webhookColumns+` FROM webhooks ORDER BY created_at, id`)
+	if err != nil {
+		return fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var w domain.Webhook
+		if err := scanWebhook(rows, &w); err != nil {
+			return err
+		}
+		if err := fn(&w); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *WebhookRepository) Restore(ctx context.Context, webhooks []domain.Webhook) error {
+	query := `INSERT INTO webhooks (` + webhookColumns + `) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	tx, err := conn(ctx, r.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, w := range webhooks {
+		if _, err := tx.Exec(ctx, query, w.ID, w.URL, w.Secret, eventNames(w.Events), w.Active, w.CreatedAt); err != nil {
+			return fmt.Errorf("restore webhook %s: %w", w.ID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// Deliveries возвращает журнал доставок webhook, новые первыми
+func (r *WebhookRepository) Deliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]domain.WebhookDelivery, error) {
+	query := `
+        SELECT d.id, d.webhook_id, d.event_id, e.type, d.status, d.attempts,
+               CASE WHEN d.status = 'pending' THEN d.next_attempt_at END,
+               d.response_code, d.last_error, d.created_at, d.delivered_at
+        FROM webhook_deliveries d
+        JOIN outbox_events e ON e.id = d.event_id
+        WHERE d.webhook_id = $1
+        ORDER BY d.id DESC
+        LIMIT $2
+    `
+
+	rows, err := conn(ctx, r.db).Query(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []domain.WebhookDelivery{}
+	for rows.Next() {
+		var d domain.WebhookDelivery
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.ResponseCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// dispatchEventsQuery берет пачку неразосланных событий, создает по доставке на каждый
+// активный webhook, подписанный на вид события, и отмечает события разосланными.
+// Событие и его доставки фиксируются одной командой, поэтому ни одно не теряется.
+const dispatchEventsQuery = `
+    WITH batch AS (
+        SELECT id, type FROM outbox_events
+        WHERE dispatched_at IS NULL
+        ORDER BY id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    ), deliveries AS (
+        INSERT INTO webhook_deliveries (webhook_id, event_id)
+        SELECT w.id, b.id FROM batch b
+        JOIN webhooks w ON w.active AND b.type = ANY(w.events)
+        ON CONFLICT (webhook_id, event_id) DO NOTHING
+    )
+    UPDATE outbox_events e SET dispatched_at = CURRENT_TIMESTAMP
+    FROM batch b WHERE e.id = b.id
+`
+
+// DispatchEvents раскладывает до limit событий outbox по доставкам и возвращает число событий
+func (r *WebhookRepository) DispatchEvents(ctx context.Context, limit int) (int, error) {
+	tag, err := conn(ctx, r.db).Exec(ctx, dispatchEventsQuery, limit)
+	if err != nil {
+		return 0, fmt.Errorf("dispatch events: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// claimDeliveriesQuery откладывает выбранные доставки на время аренды: пока
+// обработчик отправляет их, другие экземпляры сервиса эти доставки не возьмут
+const claimDeliveriesQuery = `
+    WITH claimed AS (
+        UPDATE webhook_deliveries d SET next_attempt_at = $2
+        WHERE d.id IN (
+            SELECT id FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= $1
+            ORDER BY id
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING d.id, d.attempts, d.webhook_id, d.event_id
+    )
+    SELECT c.id, c.attempts, w.url, w.secret, e.id, e.type, e.subscription_id, e.data, e.created_at
+    FROM claimed c
+    JOIN webhooks w ON w.id = c.webhook_id
+    JOIN outbox_events e ON e.id = c.event_id
+    ORDER BY c.id
+`
+
+// ClaimDeliveries берет до limit доставок, срок которых наступил к now, на время lease
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.PendingDelivery, error) {
+	rows, err := conn(ctx, r.db).Query(ctx, claimDeliveriesQuery, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []domain.PendingDelivery{}
+	for rows.Next() {
+		var d domain.PendingDelivery
+		err := rows.Scan(&d.ID, &d.Attempts, &d.URL, &d.Secret,
+			&d.Event.ID, &d.Event.Type, &d.Event.SubscriptionID, &d.Event.Data, &d.Event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt сохраняет исход попытки доставки, сделанной в at
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, id int64, result domain.DeliveryResult, at time.Time) error {
+	status := domain.DeliveryPending
+	var deliveredAt *time.Time
+	switch {
+	case result.Delivered:
+		status = domain.DeliveryDelivered
+		deliveredAt = &at
+	case result.NextAttemptAt == nil:
+		status = domain.DeliveryFailed
+	}
+
+	query := `
+        UPDATE webhook_deliveries
+        SET status = $2, attempts = attempts + 1, next_attempt_at = $3,
+            response_code = $4, last_error = $5, delivered_at = $6
+        WHERE id = $1
+    `
+
+	_, err := conn(ctx, r.db).Exec(ctx, query, id, status, result.NextAttemptAt, result.ResponseCode, result.Error, deliveredAt)
+	if err != nil {
+		return fmt.Errorf("record delivery attempt: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+func TestWebhookRepository_Create(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewWebhookRepository(mock, zaptest.NewLogger(t))
+	id := uuid.New()
+	now := time.Now()
+	w := &domain.Webhook{
+		URL:    "https://example.com/hook",
+		Secret: "0123456789abcdef",
+		Events: []domain.EventType{domain.EventSubscriptionCreated, domain.EventSubscriptionDeleted},
+	}
+
+	mock.ExpectQuery(`INSERT INTO webhooks`).
+		WithArgs(w.URL, w.Secret, []string{"subscription.created", "subscription.deleted"}).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "active", "created_at"}).AddRow(id, true, now))
+
+	require.NoError(t, repo.Create(context.Background(), w))
+	assert.Equal(t, id, w.ID)
+	assert.True(t, w.Active)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_Delete_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewWebhookRepository(mock, zaptest.NewLogger(t))
+	id := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM webhooks WHERE id = \$1`).
+		WithArgs(id).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	assert.ErrorIs(t, repo.Delete(context.Background(), id), domain.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_DispatchEvents(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewWebhookRepository(mock, zaptest.NewLogger(t))
+
+	mock.ExpectExec(`FOR UPDATE SKIP LOCKED.*INSERT INTO webhook_deliveries.*UPDATE outbox_events e SET dispatched_at`).
+		WithArgs(100).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+	n, err := repo.DispatchEvents(context.Background(), 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_ClaimDeliveries(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	repo := NewWebhookRepository(mock, zaptest.NewLogger(t))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	subID := uuid.New()
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries d SET next_attempt_at = \$2`).
+		WithArgs(now, now.Add(5*time.Minute), 20).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "attempts", "url", "secret", "event_id", "type", "subscription_id", "data", "created_at"}).
+			AddRow(int64(7), 2, "https://example.com/hook", "0123456789abcdef",
+				int64(42), domain.EventSubscriptionUpdated, subID, json.RawMessage(`{"price":400}`), now))
+
+	deliveries, err := repo.ClaimDeliveries(context.Background(), now, 5*time.Minute, 20)
+
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, int64(7), deliveries[0].ID)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.Equal(t, domain.EventSubscriptionUpdated, deliveries[0].Event.Type)
+	assert.Equal(t, subID, deliveries[0].Event.SubscriptionID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_RecordAttempt(t *testing.T) {
+	at := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	next := at.Add(time.Minute)
+	code := 503
+	msg := "unexpected status 503"
+
+	tests := []struct {
+		name   string
+		result domain.DeliveryResult
+		status string
+	}{
+		{"delivered", domain.DeliveryResult{Delivered: true}, domain.DeliveryDelivered},
+		{"retry", domain.DeliveryResult{ResponseCode: &code, Error: &msg, NextAttemptAt: &next}, domain.DeliveryPending},
+		{"gave up", domain.DeliveryResult{ResponseCode: &code, Error: &msg}, domain.DeliveryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			repo := NewWebhookRepository(mock, zaptest.NewLogger(t))
+
+			var deliveredAt *time.Time
+			if tt.result.Delivered {
+				deliveredAt = &at
+			}
+
+			mock.ExpectExec(`UPDATE webhook_deliveries`).
+				WithArgs(int64(7), tt.status, tt.result.NextAttemptAt, tt.result.ResponseCode, tt.result.Error, deliveredAt).
+				WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+			require.NoError(t, repo.RecordAttempt(context.Background(), 7, tt.result, at))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
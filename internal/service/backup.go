@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"go.uber.org/zap"
+)
+
+const (
+	backupFormat    = "subscribes_api/backup"
+	backupVersion   = 1
+	backupEndMarker = "_end"
+	restoreBatch    = 500
+)
+
+// ErrDatabaseNotEmpty - восстановление возможно только в пустую базу
+var ErrDatabaseNotEmpty = errors.New("database is not empty")
+
+// BackupTable описывает таблицу архива. Порядок таблиц в BackupService задает
+// порядок восстановления, поэтому зависимые таблицы идут после основных.
+type BackupTable struct {
+	Name    string
+	Count   func(ctx context.Context) (int, error)
+	Dump    func(ctx context.Context, emit func(row any) error) error
+	Restore func(ctx context.Context, rows []json.RawMessage) error
+}
+
+// NewBackupTable связывает таблицу архива с методами репозитория
+func NewBackupTable[T any](
+	name string,
+	count func(ctx context.Context) (int, error),
+	stream func(ctx context.Context, fn func(*T) error) error,
+	restore func(ctx context.Context, rows []T) error,
+) BackupTable {
+	return BackupTable{
+		Name:  name,
+		Count: count,
+		Dump: func(ctx context.Context, emit func(row any) error) error {
+			return stream(ctx, func(row *T) error { return emit(row) })
+		},
+		Restore: func(ctx context.Context, raws []json.RawMessage) error {
+			rows := make([]T, len(raws))
+			for i, raw := range raws {
+				if err := json.Unmarshal(raw, &rows[i]); err != nil {
+					return fmt.Errorf("decode %s row: %w", name, err)
+				}
+			}
+			return restore(ctx, rows)
+		},
+	}
+}
+
+type SubscriptionBackupRepository interface {
+	Count(ctx context.Context) (int, error)
+	Stream(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.Subscription) error) error
+	Restore(ctx context.Context, subs []domain.Subscription) error
+}
+
+func SubscriptionsBackupTable(repo SubscriptionBackupRepository) BackupTable {
+	stream := func(ctx context.Context, fn func(*domain.Subscription) error) error {
+		return repo.Stream(ctx, domain.SubscriptionFilter{}, fn)
+	}
+	return NewBackupTable("subscriptions", repo.Count, stream, repo.Restore)
+}
+
+type backupHeader struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Tables    []string  `json:"tables"`
+}
+
+// backupRecord - строка архива. Последняя строка с Table == "_end" содержит
+// количество строк по таблицам и позволяет обнаружить обрезанный файл.
+type backupRecord struct {
+	Table  string          `json:"table"`
+	Row    json.RawMessage `json:"row,omitempty"`
+	Counts map[string]int  `json:"counts,omitempty"`
+}
+
+// BackupTransactor выполняет чтение и запись таблиц архива в одной транзакции
+type BackupTransactor interface {
+	Transactor
+	// WithinSnapshot - транзакция только для чтения, все запросы которой видят один снимок базы
+	WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// BackupService выгружает данные в NDJSON-архив и восстанавливает их через репозитории
+type BackupService struct {
+	tx     BackupTransactor
+	tables []BackupTable
+	logger *zap.Logger
+}
+
+func NewBackupService(tx BackupTransactor, logger *zap.Logger, tables ...BackupTable) *BackupService {
+	return &BackupService{
+		tx:     tx,
+		tables: tables,
+		logger: logger,
+	}
+}
+
+// Backup пишет архив: заголовок, строки таблиц и завершающую запись со счетчиками.
+// Таблицы читаются из одного снимка, поэтому архив согласован при параллельной записи.
+func (s *BackupService) Backup(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	header := backupHeader{
+		Format:    backupFormat,
+		Version:   backupVersion,
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, table := range s.tables {
+		header.Tables = append(header.Tables, table.Name)
+	}
+	if err := enc.Encode(header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+
+	counts := make(map[string]int, len(s.tables))
+	err := s.tx.WithinSnapshot(ctx, func(ctx context.Context) error {
+		for _, table := range s.tables {
+			err := table.Dump(ctx, func(row any) error {
+				raw, err := json.Marshal(row)
+				if err != nil {
+					return err
+				}
+				counts[table.Name]++
+				return enc.Encode(backupRecord{Table: table.Name, Row: raw})
+			})
+			if err != nil {
+				return fmt.Errorf("dump %s: %w", table.Name, err)
+			}
+			s.logger.Info("table dumped", zap.String("table", table.Name), zap.Int("rows", counts[table.Name]))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := enc.Encode(backupRecord{Table: backupEndMarker, Counts: counts}); err != nil {
+		return fmt.Errorf("write trailer: %w", err)
+	}
+
+	return bw.Flush()
+}
+
+// Restore загружает архив в пустую базу с сохранением id и временных меток.
+// Все восстановление - одна транзакция: при ошибке или обрезанном архиве база
+// остается пустой. Строки читаются и вставляются пачками, чтобы не держать архив в памяти.
+func (s *BackupService) Restore(ctx context.Context, r io.Reader) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.restore(ctx, r)
+	})
+}
+
+func (s *BackupService) restore(ctx context.Context, r io.Reader) error {
+	for _, table := range s.tables {
+		count, err := table.Count(ctx)
+		if err != nil {
+			return fmt.Errorf("count %s: %w", table.Name, err)
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %s has %d rows", ErrDatabaseNotEmpty, table.Name, count)
+		}
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	var header backupHeader
+	if err := dec.Decode(&header); err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	if header.Format != backupFormat {
+		return fmt.Errorf("unknown archive format %q", header.Format)
+	}
+	if header.Version > backupVersion {
+		return fmt.Errorf("unsupported archive version %d", header.Version)
+	}
+
+	tables := make(map[string]BackupTable, len(s.tables))
+	order := make(map[string]int, len(s.tables))
+	for i, table := range s.tables {
+		tables[table.Name] = table
+		order[table.Name] = i
+	}
+
+	counts := make(map[string]int)
+	current := -1
+	var batch []json.RawMessage
+	var batchTable BackupTable
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := batchTable.Restore(ctx, batch); err != nil {
+			return fmt.Errorf("restore %s: %w", batchTable.Name, err)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		var rec backupRecord
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				return errors.New("archive is truncated: trailer not found")
+			}
+			return fmt.Errorf("read record: %w", err)
+		}
+
+		if rec.Table == backupEndMarker {
+			if err := flush(); err != nil {
+				return err
+			}
+			for name, expected := range rec.Counts {
+				if counts[name] != expected {
+					return fmt.Errorf("table %s: expected %d rows, restored %d", name, expected, counts[name])
+				}
+			}
+			s.logger.Info("backup restored", zap.Any("counts", counts))
+			return nil
+		}
+
+		table, ok := tables[rec.Table]
+		if !ok {
+			return fmt.Errorf("unknown table %q in archive", rec.Table)
+		}
+		if order[rec.Table] < current {
+			return fmt.Errorf("table %s is out of order", rec.Table)
+		}
+
+		if order[rec.Table] != current || len(batch) >= restoreBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+			current = order[rec.Table]
+			batchTable = table
+		}
+
+		batch = append(batch, rec.Row)
+		counts[rec.Table]++
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// memoryTable - таблица в памяти для проверки архива без базы
+type memoryTable[T any] struct {
+	rows []T
+}
+
+func (m *memoryTable[T]) table(name string) BackupTable {
+	return NewBackupTable(name,
+		func(context.Context) (int, error) { return len(m.rows), nil },
+		func(_ context.Context, fn func(*T) error) error {
+			for i := range m.rows {
+				if err := fn(&m.rows[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		func(_ context.Context, rows []T) error {
+			m.rows = append(m.rows, rows...)
+			return nil
+		},
+	)
+}
+
+// memoryTx запоминает, чем закончилась транзакция
+type memoryTx struct {
+	snapshots int
+	commits   int
+	rollbacks int
+}
+
+func (m *memoryTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := fn(ctx); err != nil {
+		m.rollbacks++
+		return err
+	}
+	m.commits++
+	return nil
+}
+
+func (m *memoryTx) WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.snapshots++
+	return fn(ctx)
+}
+
+func TestBackupService_RoundTrip(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	source := &memoryTable[domain.Subscription]{}
+	for i := 0; i < restoreBatch+3; i++ {
+		source.rows = append(source.rows, *testutil.FixtureSubscription())
+	}
+	tokens := &memoryTable[domain.CalendarToken]{rows: []domain.CalendarToken{
+		{UserID: testutil.FixtureUserID(), TokenHash: strings.Repeat("a", 64)},
+	}}
+
+	var archive bytes.Buffer
+	backupTx := &memoryTx{}
+	err := NewBackupService(backupTx, logger, source.table("subscriptions"), tokens.table("calendar_tokens")).
+		Backup(context.Background(), &archive)
+	require.NoError(t, err)
+	assert.Equal(t, 1, backupTx.snapshots, "all tables are read from one snapshot")
+
+	restoredSubs := &memoryTable[domain.Subscription]{}
+	restoredTokens := &memoryTable[domain.CalendarToken]{}
+	restoreTx := &memoryTx{}
+	err = NewBackupService(restoreTx, logger, restoredSubs.table("subscriptions"), restoredTokens.table("calendar_tokens")).
+		Restore(context.Background(), bytes.NewReader(archive.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 1, restoreTx.commits, "all batches are restored in one transaction")
+
+	require.Len(t, restoredSubs.rows, len(source.rows))
+	for i := range source.rows {
+		assert.Equal(t, source.rows[i].ID, restoredSubs.rows[i].ID)
+		assert.True(t, source.rows[i].CreatedAt.Equal(restoredSubs.rows[i].CreatedAt))
+	}
+	assert.Equal(t, tokens.rows, restoredTokens.rows)
+}
+
+func TestBackupService_Restore_Truncated(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	// больше одной пачки, чтобы часть строк была вставлена до обнаружения обрыва
+	source := &memoryTable[domain.Subscription]{}
+	for i := 0; i < restoreBatch+3; i++ {
+		source.rows = append(source.rows, *testutil.FixtureSubscription())
+	}
+	var archive bytes.Buffer
+	require.NoError(t, NewBackupService(&memoryTx{}, logger, source.table("subscriptions")).Backup(context.Background(), &archive))
+
+	lines := strings.SplitAfter(archive.String(), "\n")
+	truncated := strings.Join(lines[:len(lines)-2], "")
+
+	target := &memoryTable[domain.Subscription]{}
+	tx := &memoryTx{}
+	err := NewBackupService(tx, logger, target.table("subscriptions")).Restore(context.Background(), strings.NewReader(truncated))
+
+	assert.ErrorContains(t, err, "truncated")
+	assert.Equal(t, 1, tx.rollbacks, "inserted batches are rolled back")
+	assert.Zero(t, tx.commits)
+}
+
+func TestBackupService_Restore_NotEmpty(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	target := &memoryTable[domain.Subscription]{rows: []domain.Subscription{*testutil.FixtureSubscription()}}
+	err := NewBackupService(&memoryTx{}, logger, target.table("subscriptions")).Restore(context.Background(), strings.NewReader(""))
+
+	assert.ErrorIs(t, err, ErrDatabaseNotEmpty)
+}
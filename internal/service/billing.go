@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+// ErrInvalidBilling - период оплаты или запланированные изменения цены не прошли проверку
+var ErrInvalidBilling = errors.New("invalid billing")
+
+// prepareBilling проверяет период оплаты и изменения цены подписки. Изменения
+// приводятся к первому числу месяца и сортируются по дате, как их читает база.
+func prepareBilling(sub *domain.Subscription) error {
+	if sub.BillingPeriod == "" {
+		sub.BillingPeriod = domain.BillingMonthly
+	}
+	if !domain.ValidBillingPeriod(sub.BillingPeriod) {
+		return fmt.Errorf("%w: billing_period must be %s, %s, %s or %s", ErrInvalidBilling,
+			domain.BillingMonthly, domain.BillingQuarterly, domain.BillingSemiannual, domain.BillingYearly)
+	}
+
+	seen := map[time.Time]bool{}
+	for i := range sub.PriceChanges {
+		c := &sub.PriceChanges[i]
+		c.EffectiveFrom = time.Date(c.EffectiveFrom.Year(), c.EffectiveFrom.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+		switch {
+		case c.Price < 0:
+			return fmt.Errorf("%w: price change cannot be negative", ErrInvalidBilling)
+		case !c.EffectiveFrom.After(sub.StartDate):
+			return fmt.Errorf("%w: price change must take effect after start_date", ErrInvalidBilling)
+		case seen[c.EffectiveFrom]:
+			return fmt.Errorf("%w: duplicate price change for %s", ErrInvalidBilling, c.EffectiveFrom.Format("01-2006"))
+		}
+		seen[c.EffectiveFrom] = true
+	}
+
+	sort.Slice(sub.PriceChanges, func(i, j int) bool {
+		return sub.PriceChanges[i].EffectiveFrom.Before(sub.PriceChanges[j].EffectiveFrom)
+	})
+
+	return nil
+}
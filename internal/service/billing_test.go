@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPrepareBilling_NormalizesPriceChanges(t *testing.T) {
+	sub := testutil.FixtureSubscription(testutil.WithDates(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), time.Time{}))
+	sub.PriceChanges = []domain.PriceChange{
+		{EffectiveFrom: time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), Price: 600},
+		{EffectiveFrom: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), Price: 500},
+	}
+
+	require.NoError(t, prepareBilling(sub))
+
+	assert.Equal(t, domain.BillingMonthly, sub.BillingPeriod)
+	assert.Equal(t, []domain.PriceChange{
+		{EffectiveFrom: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), Price: 500},
+		{EffectiveFrom: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Price: 600},
+	}, sub.PriceChanges)
+}
+
+func TestPrepareBilling_Invalid(t *testing.T) {
+	start := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		period  string
+		changes []domain.PriceChange
+	}{
+		{"unknown period", "weekly", nil},
+		{"negative price", "", []domain.PriceChange{{EffectiveFrom: start.AddDate(0, 1, 0), Price: -1}}},
+		{"before start", "", []domain.PriceChange{{EffectiveFrom: start, Price: 500}}},
+		{"duplicate month", "", []domain.PriceChange{
+			{EffectiveFrom: start.AddDate(0, 2, 0), Price: 500},
+			{EffectiveFrom: start.AddDate(0, 2, 10), Price: 600},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := testutil.FixtureSubscription(testutil.WithDates(start, time.Time{}))
+			sub.BillingPeriod = tt.period
+			sub.PriceChanges = tt.changes
+
+			assert.ErrorIs(t, prepareBilling(sub), ErrInvalidBilling)
+		})
+	}
+}
+
+func TestSubscriptionService_Update_KeepsBilling(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+	ctx := context.Background()
+
+	existing := testutil.FixtureSubscription()
+	existing.BillingPeriod = domain.BillingYearly
+	existing.PriceChanges = []domain.PriceChange{{EffectiveFrom: existing.StartDate.AddDate(1, 0, 0), Price: 900}}
+
+	sub := testutil.FixtureSubscription()
+	sub.ID = existing.ID
+
+	mockRepo.On("GetByID", ctx, existing.ID).Return(existing, nil)
+	mockRepo.On("Update", ctx, sub).Return(nil)
+
+	require.NoError(t, service.Update(ctx, sub))
+
+	// без переданных полей период сохраняется, а изменения цены в базе не трогаются
+	assert.Equal(t, domain.BillingYearly, sub.BillingPeriod)
+	assert.Equal(t, existing.PriceChanges, sub.PriceChanges)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSubscription_ChargeDates_BillingPeriod(t *testing.T) {
+	sub := testutil.FixtureSubscription(testutil.WithDates(
+		time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)))
+	sub.BillingPeriod = domain.BillingSemiannual
+
+	dates := sub.ChargeDates(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, []time.Time{
+		time.Date(2024, 7, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC),
+	}, dates)
+
+	last, ok := sub.LastChargeDate()
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC), last)
+}
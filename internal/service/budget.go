@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidBudget - бюджет не прошел проверку
+var ErrInvalidBudget = errors.New("invalid budget")
+
+// defaultThresholds - пороги оповещений, если они не заданы: 80% и 100% лимита
+var defaultThresholds = []int{80, 100}
+
+// maxThreshold ограничивает пороги разумным превышением лимита
+const maxThreshold = 1000
+
+type BudgetRepository interface {
+	Create(ctx context.Context, b *domain.Budget) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Budget, error)
+	List(ctx context.Context, userID *uuid.UUID) ([]domain.Budget, error)
+	Update(ctx context.Context, b *domain.Budget) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	SaveAlert(ctx context.Context, a *domain.BudgetAlert) (bool, error)
+	ListAlerts(ctx context.Context, budgetID uuid.UUID) ([]domain.BudgetAlert, error)
+}
+
+// BudgetService ведет бюджеты и сверяет с ними расходы, посчитанные
+// SubscriptionService.TotalCost
+type BudgetService struct {
+	repo   BudgetRepository
+	subs   *SubscriptionService
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewBudgetService(repo BudgetRepository, subs *SubscriptionService, logger *zap.Logger) *BudgetService {
+	return &BudgetService{
+		repo:   repo,
+		subs:   subs,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (s *BudgetService) Create(ctx context.Context, b *domain.Budget) error {
+	if err := validateBudget(b); err != nil {
+		return err
+	}
+
+	return s.repo.Create(ctx, b)
+}
+
+func (s *BudgetService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Budget, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *BudgetService) List(ctx context.Context, userID *uuid.UUID) ([]domain.Budget, error) {
+	return s.repo.List(ctx, userID)
+}
+
+func (s *BudgetService) Update(ctx context.Context, b *domain.Budget) error {
+	if err := validateBudget(b); err != nil {
+		return err
+	}
+
+	return s.repo.Update(ctx, b)
+}
+
+func (s *BudgetService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *BudgetService) Alerts(ctx context.Context, budgetID uuid.UUID) ([]domain.BudgetAlert, error) {
+	if _, err := s.repo.GetByID(ctx, budgetID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ListAlerts(ctx, budgetID)
+}
+
+// validateBudget проверяет лимит и область бюджета и приводит пороги к
+// отсортированному списку без повторов
+func validateBudget(b *domain.Budget) error {
+	if b.Period != domain.BudgetMonthly && b.Period != domain.BudgetYearly {
+		return fmt.Errorf("%w: period must be %s or %s", ErrInvalidBudget, domain.BudgetMonthly, domain.BudgetYearly)
+	}
+
+	if b.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", ErrInvalidBudget)
+	}
+
+	if b.UserID == nil && b.CategoryID == nil && b.ServiceID == nil {
+		return fmt.Errorf("%w: user_id, category_id or service_id is required", ErrInvalidBudget)
+	}
+
+	if len(b.Thresholds) == 0 {
+		b.Thresholds = append([]int(nil), defaultThresholds...)
+	}
+
+	seen := map[int]bool{}
+	thresholds := []int{}
+	for _, t := range b.Thresholds {
+		if t < 1 || t > maxThreshold {
+			return fmt.Errorf("%w: thresholds must be between 1 and %d percent", ErrInvalidBudget, maxThreshold)
+		}
+		if !seen[t] {
+			seen[t] = true
+			thresholds = append(thresholds, t)
+		}
+	}
+	sort.Ints(thresholds)
+	b.Thresholds = thresholds
+
+	return nil
+}
+
+// Status считает расход по бюджету за текущий период. Spent - списания, даты которых
+// уже наступили (режим charge_events по сегодняшний день): в whole_months текущий месяц
+// оплачен целиком, и расход месячного бюджета совпадал бы с прогнозом. Projected - весь
+// период так же, как /total-cost, при сохранении действующих подписок. Период определяется
+// в часовом поясе запроса, при фоновой проверке - в UTC.
+func (s *BudgetService) Status(ctx context.Context, b *domain.Budget) (*domain.BudgetStatus, error) {
+	today := localDate(s.now(), LocationFrom(ctx))
+	start, end := b.PeriodBounds(today)
+
+	spentFilter := b.Filter(start, today)
+	spentFilter.CostMode = domain.CostModeChargeEvents
+	spent, err := s.subs.TotalCost(ctx, spentFilter)
+	if err != nil {
+		return nil, fmt.Errorf("budget spent: %w", err)
+	}
+
+	projected, err := s.subs.TotalCost(ctx, b.Filter(start, end))
+	if err != nil {
+		return nil, fmt.Errorf("budget projection: %w", err)
+	}
+
+	return &domain.BudgetStatus{
+		Budget:      *b,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Spent:       spent,
+		// режимы считают по-разному, а прогноз не может быть меньше уже списанного
+		Projected: max(projected, spent),
+	}, nil
+}
+
+func (s *BudgetService) StatusByID(ctx context.Context, id uuid.UUID) (*domain.BudgetStatus, error) {
+	b, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Status(ctx, b)
+}
+
+// Evaluate сверяет все бюджеты с расходами и возвращает новые оповещения.
+// Каждый порог дает не больше двух оповещений за период: сначала projected,
+// когда прогноз до конца периода его пересекает, затем reached. Ошибка одного
+// бюджета не мешает проверить остальные, ошибки возвращаются вместе.
+//
+// Новое оповещение сохраняется в budget_alerts в одной транзакции с событием
+// budget.threshold_crossed в outbox, поэтому его получают webhook, издатели и поток.
+func (s *BudgetService) Evaluate(ctx context.Context) ([]domain.BudgetAlert, error) {
+	budgets, err := s.repo.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []domain.BudgetAlert{}
+	var errs []error
+	for i := range budgets {
+		created, err := s.evaluate(ctx, &budgets[i])
+		alerts = append(alerts, created...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("budget %s: %w", budgets[i].ID, err))
+		}
+	}
+
+	s.logger.Info("budgets evaluated",
+		zap.Int("budgets", len(budgets)),
+		zap.Int("alerts", len(alerts)),
+		zap.Int("failed", len(errs)),
+	)
+	return alerts, errors.Join(errs...)
+}
+
+// evaluate сохраняет оповещения одного бюджета и возвращает созданные
+func (s *BudgetService) evaluate(ctx context.Context, b *domain.Budget) ([]domain.BudgetAlert, error) {
+	status, err := s.Status(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []domain.BudgetAlert
+	for _, alert := range crossedThresholds(status) {
+		var created bool
+		err := s.subs.inTx(ctx, func(ctx context.Context) error {
+			var err error
+			if created, err = s.repo.SaveAlert(ctx, &alert); err != nil || !created {
+				return err
+			}
+			return s.subs.emitBudgetAlert(ctx, b, &alert)
+		})
+		if err != nil {
+			return alerts, err
+		}
+		if !created {
+			continue
+		}
+
+		s.logger.Warn("budget threshold crossed",
+			zap.String("budget_id", alert.BudgetID.String()),
+			zap.String("kind", alert.Kind),
+			zap.Int("threshold", alert.Threshold),
+			zap.Int("amount", alert.Amount),
+			zap.Int("spent", alert.Spent),
+			zap.Int("projected", alert.Projected),
+		)
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+// crossedThresholds - пороги, которые пересекает текущий расход или прогноз
+func crossedThresholds(status *domain.BudgetStatus) []domain.BudgetAlert {
+	var alerts []domain.BudgetAlert
+
+	for _, threshold := range status.Budget.Thresholds {
+		kind := ""
+		switch limit := float64(threshold); {
+		case status.Percent(status.Spent) >= limit:
+			kind = domain.AlertReached
+		case status.Percent(status.Projected) >= limit:
+			kind = domain.AlertProjected
+		default:
+			continue
+		}
+
+		alerts = append(alerts, domain.BudgetAlert{
+			BudgetID:    status.Budget.ID,
+			PeriodStart: status.PeriodStart,
+			Threshold:   threshold,
+			Kind:        kind,
+			Amount:      status.Budget.Amount,
+			Spent:       status.Spent,
+			Projected:   status.Projected,
+		})
+	}
+
+	return alerts
+}
+
+// Run периодически вызывает Evaluate, пока не отменен ctx
+func (s *BudgetService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Evaluate(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("failed to evaluate budgets", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newBudgetTestService(t *testing.T, opts ...Option) (*BudgetService, *testutil.MockBudgetRepository, *testutil.MockSubscriptionRepository) {
+	budgetRepo := new(testutil.MockBudgetRepository)
+	subRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+
+	service := NewBudgetService(budgetRepo, NewSubscriptionService(subRepo, logger, opts...), logger)
+	service.now = func() time.Time { return time.Date(2025, time.March, 10, 15, 0, 0, 0, time.UTC) }
+
+	return service, budgetRepo, subRepo
+}
+
+func TestBudgetService_Create_DefaultThresholds(t *testing.T) {
+	service, repo, _ := newBudgetTestService(t)
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	budget := &domain.Budget{UserID: &userID, Period: domain.BudgetMonthly, Amount: 1000}
+
+	repo.On("Create", ctx, budget).Return(nil)
+
+	err := service.Create(ctx, budget)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{80, 100}, budget.Thresholds)
+}
+
+func TestBudgetService_Create_Invalid(t *testing.T) {
+	userID := testutil.FixtureUserID()
+
+	tests := []struct {
+		name   string
+		budget domain.Budget
+	}{
+		{"unknown period", domain.Budget{UserID: &userID, Period: "weekly", Amount: 1000}},
+		{"zero amount", domain.Budget{UserID: &userID, Period: domain.BudgetMonthly}},
+		{"no scope", domain.Budget{Period: domain.BudgetMonthly, Amount: 1000}},
+		{"threshold out of range", domain.Budget{UserID: &userID, Period: domain.BudgetMonthly, Amount: 1000, Thresholds: []int{0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, _ := newBudgetTestService(t)
+
+			err := service.Create(context.Background(), &tt.budget)
+
+			assert.ErrorIs(t, err, ErrInvalidBudget)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func spentFilter(b domain.Budget, from, to time.Time) domain.SubscriptionFilter {
+	filter := b.Filter(from, to)
+	filter.CostMode = domain.CostModeChargeEvents
+	return filter
+}
+
+// chargedLines - подписки со списаниями 5 и 20 числа: к 10 марта прошло только первое
+func chargedLines() []domain.CostLine {
+	return []domain.CostLine{
+		{Subscription: *testutil.FixtureSubscription(func(s *domain.Subscription) {
+			s.Price, s.StartDate = 400, time.Date(2025, time.January, 5, 0, 0, 0, 0, time.UTC)
+		}), Share: 400},
+		{Subscription: *testutil.FixtureSubscription(func(s *domain.Subscription) {
+			s.Price, s.StartDate = 500, time.Date(2025, time.January, 20, 0, 0, 0, 0, time.UTC)
+		}), Share: 500},
+	}
+}
+
+func TestBudgetService_Status_SpentCountsPastCharges(t *testing.T) {
+	service, _, subRepo := newBudgetTestService(t)
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	budget := domain.Budget{UserID: &userID, Period: domain.BudgetMonthly, Amount: 1000}
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	subRepo.On("StreamCostBreakdown", ctx, spentFilter(budget, start, today)).Return(chargedLines(), nil)
+	subRepo.On("TotalCost", ctx, budget.Filter(start, end)).Return(900, nil)
+
+	status, err := service.Status(ctx, &budget)
+
+	require.NoError(t, err)
+	assert.Equal(t, 400, status.Spent)
+	assert.Equal(t, 900, status.Projected)
+}
+
+func TestBudgetService_Evaluate_ContinuesAfterError(t *testing.T) {
+	service, budgetRepo, subRepo := newBudgetTestService(t)
+
+	ctx := context.Background()
+	categoryID := uuid.New()
+	broken := domain.Budget{ID: uuid.New(), CategoryID: &categoryID, Period: domain.BudgetMonthly, Amount: 1000, Thresholds: []int{100}}
+	userID := testutil.FixtureUserID()
+	healthy := domain.Budget{ID: uuid.New(), UserID: &userID, Period: domain.BudgetMonthly, Amount: 300, Thresholds: []int{100}}
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	budgetRepo.On("List", ctx, (*uuid.UUID)(nil)).Return([]domain.Budget{broken, healthy}, nil)
+	subRepo.On("StreamCostBreakdown", ctx, spentFilter(broken, start, today)).Return([]domain.CostLine{}, errors.New("connection reset"))
+	subRepo.On("StreamCostBreakdown", ctx, spentFilter(healthy, start, today)).Return(chargedLines(), nil)
+	subRepo.On("TotalCost", ctx, healthy.Filter(start, end)).Return(900, nil)
+	budgetRepo.On("SaveAlert", ctx, mock.Anything).Return(true, nil)
+
+	alerts, err := service.Evaluate(ctx)
+
+	assert.ErrorContains(t, err, broken.ID.String())
+	require.Len(t, alerts, 1)
+	assert.Equal(t, healthy.ID, alerts[0].BudgetID)
+	assert.Equal(t, domain.AlertReached, alerts[0].Kind)
+}
+
+func TestBudgetService_Evaluate(t *testing.T) {
+	service, budgetRepo, subRepo := newBudgetTestService(t)
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	budget := domain.Budget{
+		ID:         uuid.New(),
+		UserID:     &userID,
+		Period:     domain.BudgetMonthly,
+		Amount:     1000,
+		Thresholds: []int{50, 80, 100},
+	}
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	budgetRepo.On("List", ctx, (*uuid.UUID)(nil)).Return([]domain.Budget{budget}, nil)
+	subRepo.On("StreamCostBreakdown", ctx, spentFilter(budget, start, today)).Return(chargedLines(), nil)
+	subRepo.On("TotalCost", ctx, budget.Filter(start, end)).Return(900, nil)
+
+	// порог 50% уже пройден и был сохранен при прошлой проверке
+	budgetRepo.On("SaveAlert", ctx, mock.MatchedBy(func(a *domain.BudgetAlert) bool {
+		return a.Threshold == 50
+	})).Return(false, nil)
+	budgetRepo.On("SaveAlert", ctx, mock.MatchedBy(func(a *domain.BudgetAlert) bool {
+		return a.Threshold == 80
+	})).Return(true, nil)
+
+	alerts, err := service.Evaluate(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 80, alerts[0].Threshold)
+	assert.Equal(t, domain.AlertProjected, alerts[0].Kind)
+	assert.Equal(t, start, alerts[0].PeriodStart)
+	assert.Equal(t, 400, alerts[0].Spent)
+	assert.Equal(t, 900, alerts[0].Projected)
+
+	budgetRepo.AssertNumberOfCalls(t, "SaveAlert", 2)
+}
+
+func TestBudgetService_Evaluate_AppendsEventWithAlert(t *testing.T) {
+	outbox := new(testutil.MockOutbox)
+	service, budgetRepo, subRepo := newBudgetTestService(t, WithOutbox(outbox))
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	budget := domain.Budget{
+		ID:         uuid.New(),
+		Name:       "Развлечения",
+		UserID:     &userID,
+		Period:     domain.BudgetMonthly,
+		Amount:     1000,
+		Thresholds: []int{50, 80},
+	}
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	budgetRepo.On("List", ctx, (*uuid.UUID)(nil)).Return([]domain.Budget{budget}, nil)
+	subRepo.On("StreamCostBreakdown", ctx, spentFilter(budget, start, today)).Return(chargedLines(), nil)
+	subRepo.On("TotalCost", ctx, budget.Filter(start, end)).Return(900, nil)
+	budgetRepo.On("SaveAlert", ctx, mock.MatchedBy(func(a *domain.BudgetAlert) bool {
+		return a.Threshold == 50
+	})).Return(false, nil)
+	budgetRepo.On("SaveAlert", ctx, mock.MatchedBy(func(a *domain.BudgetAlert) bool {
+		return a.Threshold == 80
+	})).Return(true, nil)
+
+	// событие пишется только для нового оповещения
+	outbox.On("Append", ctx, mock.MatchedBy(func(events []domain.Event) bool {
+		var data domain.BudgetAlertData
+		return len(events) == 1 &&
+			events[0].Type == domain.EventBudgetThresholdCrossed &&
+			events[0].SubscriptionID == budget.ID &&
+			json.Unmarshal(events[0].Data, &data) == nil &&
+			data.Threshold == 80 && data.BudgetName == budget.Name &&
+			data.UserID != nil && *data.UserID == userID
+	})).Return(nil).Once()
+
+	alerts, err := service.Evaluate(ctx)
+
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	outbox.AssertExpectations(t)
+}
+
+func TestBudgetService_Evaluate_FailsWhenEventNotWritten(t *testing.T) {
+	outbox := new(testutil.MockOutbox)
+	service, budgetRepo, subRepo := newBudgetTestService(t, WithOutbox(outbox))
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	budget := domain.Budget{ID: uuid.New(), UserID: &userID, Period: domain.BudgetMonthly, Amount: 1000, Thresholds: []int{80}}
+
+	start := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	today := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+
+	budgetRepo.On("List", ctx, (*uuid.UUID)(nil)).Return([]domain.Budget{budget}, nil)
+	subRepo.On("StreamCostBreakdown", ctx, spentFilter(budget, start, today)).Return(chargedLines(), nil)
+	subRepo.On("TotalCost", ctx, budget.Filter(start, end)).Return(900, nil)
+	budgetRepo.On("SaveAlert", ctx, mock.Anything).Return(true, nil)
+	outbox.On("Append", ctx, mock.Anything).Return(errors.New("connection reset"))
+
+	alerts, err := service.Evaluate(ctx)
+
+	assert.ErrorContains(t, err, "connection reset")
+	assert.Empty(t, alerts)
+}
+
+func TestCrossedThresholds_ReachedWins(t *testing.T) {
+	status := &domain.BudgetStatus{
+		Budget:    domain.Budget{Amount: 1000, Thresholds: []int{100}},
+		Spent:     1000,
+		Projected: 1500,
+	}
+
+	alerts := crossedThresholds(status)
+
+	require.Len(t, alerts, 1)
+	assert.Equal(t, domain.AlertReached, alerts[0].Kind)
+}
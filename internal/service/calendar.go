@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidCalendarToken возвращается, если токен ленты не совпал с выданным
+var ErrInvalidCalendarToken = errors.New("invalid calendar token")
+
+type CalendarTokenRepository interface {
+	SaveTokenHash(ctx context.Context, userID uuid.UUID, hash string) error
+	GetTokenHash(ctx context.Context, userID uuid.UUID) (string, error)
+}
+
+// CalendarService выдает секретные токены и отдает подписки для iCalendar-ленты
+type CalendarService struct {
+	tokens CalendarTokenRepository
+	subs   SubscriptionRepository
+	logger *zap.Logger
+}
+
+func NewCalendarService(tokens CalendarTokenRepository, subs SubscriptionRepository, logger *zap.Logger) *CalendarService {
+	return &CalendarService{
+		tokens: tokens,
+		subs:   subs,
+		logger: logger,
+	}
+}
+
+// IssueToken создает новый токен пользователя, предыдущий перестает действовать.
+// В базе хранится только sha256 от токена.
+func (s *CalendarService) IssueToken(ctx context.Context, userID uuid.UUID) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	if err := s.tokens.SaveTokenHash(ctx, userID, hashToken(token)); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Feed проверяет токен и возвращает подписки пользователя
+func (s *CalendarService) Feed(ctx context.Context, userID uuid.UUID, token string) ([]domain.Subscription, error) {
+	if token == "" {
+		return nil, ErrInvalidCalendarToken
+	}
+
+	// токен не выдавался - то же, что неверный; сбой базы отдается как есть
+	stored, err := s.tokens.GetTokenHash(ctx, userID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, ErrInvalidCalendarToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get calendar token: %w", err)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(hashToken(token))) != 1 {
+		return nil, ErrInvalidCalendarToken
+	}
+
+	return s.subs.List(ctx, domain.SubscriptionFilter{UserID: &userID})
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
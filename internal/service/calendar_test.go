@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCalendarService_IssueTokenAndFeed(t *testing.T) {
+	mockTokens := new(testutil.MockCalendarTokenRepository)
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewCalendarService(mockTokens, mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+
+	var savedHash string
+	mockTokens.On("SaveTokenHash", ctx, userID, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { savedHash = args.String(2) }).
+		Return(nil)
+
+	token, err := service.IssueToken(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, token, 64)
+	assert.NotEqual(t, token, savedHash)
+
+	subs := []domain.Subscription{*testutil.FixtureSubscription(testutil.WithUserID(userID))}
+	mockTokens.On("GetTokenHash", ctx, userID).Return(savedHash, nil)
+	mockRepo.On("List", ctx, domain.SubscriptionFilter{UserID: &userID}).Return(subs, nil)
+
+	got, err := service.Feed(ctx, userID, token)
+
+	require.NoError(t, err)
+	assert.Equal(t, subs, got)
+	mockTokens.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCalendarService_Feed_InvalidToken(t *testing.T) {
+	mockTokens := new(testutil.MockCalendarTokenRepository)
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewCalendarService(mockTokens, mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+
+	mockTokens.On("GetTokenHash", ctx, userID).Return(hashToken("other"), nil).Once()
+
+	_, err := service.Feed(ctx, userID, "guess")
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+
+	mockTokens.On("GetTokenHash", ctx, userID).Return("", domain.ErrNotFound).Once()
+
+	_, err = service.Feed(ctx, userID, "guess")
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+
+	mockRepo.AssertNotCalled(t, "List")
+}
+
+func TestCalendarService_Feed_LookupError(t *testing.T) {
+	mockTokens := new(testutil.MockCalendarTokenRepository)
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewCalendarService(mockTokens, mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	dbErr := errors.New("connection reset")
+
+	mockTokens.On("GetTokenHash", ctx, userID).Return("", dbErr)
+
+	// сбой базы - не неверный токен: обработчик ответит 500, а не 404
+	_, err := service.Feed(ctx, userID, "guess")
+
+	assert.ErrorIs(t, err, dbErr)
+	assert.NotErrorIs(t, err, ErrInvalidCalendarToken)
+	mockRepo.AssertNotCalled(t, "List")
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/internal/importer"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrCandidateProcessed - предложение уже принято или отклонено
+var ErrCandidateProcessed = errors.New("candidate already processed")
+
+// minCandidateConfidence - ниже этого порога находки не предлагаются пользователю
+const minCandidateConfidence = 0.5
+
+type CandidateRepository interface {
+	ReplacePending(ctx context.Context, userID uuid.UUID, candidates []*domain.SubscriptionCandidate) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.SubscriptionCandidate, error)
+	ListByUser(ctx context.Context, userID uuid.UUID, status string) ([]domain.SubscriptionCandidate, error)
+	SetStatus(ctx context.Context, id uuid.UUID, status string, subscriptionID *uuid.UUID) (bool, error)
+	LinkSubscription(ctx context.Context, id, subscriptionID uuid.UUID) error
+}
+
+// CandidateService ищет регулярные списания в выписках и превращает принятые
+// предложения в подписки через SubscriptionService.Create
+type CandidateService struct {
+	repo   CandidateRepository
+	subs   *SubscriptionService
+	tx     Transactor
+	logger *zap.Logger
+}
+
+// NewCandidateService - tx объединяет принятие предложения и создание подписки в одну транзакцию
+func NewCandidateService(repo CandidateRepository, subs *SubscriptionService, tx Transactor, logger *zap.Logger) *CandidateService {
+	return &CandidateService{
+		repo:   repo,
+		subs:   subs,
+		tx:     tx,
+		logger: logger,
+	}
+}
+
+// Analyze находит регулярные списания и заменяет ими необработанные предложения пользователя
+func (s *CandidateService) Analyze(ctx context.Context, userID uuid.UUID, txs []importer.Transaction) ([]*domain.SubscriptionCandidate, error) {
+	candidates := []*domain.SubscriptionCandidate{}
+
+	for _, rec := range importer.DetectRecurring(txs) {
+		if rec.Confidence < minCandidateConfidence {
+			continue
+		}
+
+		candidate := &domain.SubscriptionCandidate{
+			UserID:      userID,
+			ServiceName: rec.Merchant,
+			Price:       rec.Amount,
+			StartDate:   monthStart(rec.FirstDate),
+			Occurrences: rec.Occurrences,
+			Confidence:  rec.Confidence,
+		}
+		if !rec.Active {
+			end := monthStart(rec.LastDate)
+			candidate.EndDate = &end
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	if err := s.repo.ReplacePending(ctx, userID, candidates); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("bank statement analyzed",
+		zap.String("user_id", userID.String()),
+		zap.Int("transactions", len(txs)),
+		zap.Int("candidates", len(candidates)),
+	)
+	return candidates, nil
+}
+
+func (s *CandidateService) List(ctx context.Context, userID uuid.UUID, status string) ([]domain.SubscriptionCandidate, error) {
+	return s.repo.ListByUser(ctx, userID, status)
+}
+
+// Accept создает подписку из предложения; overrides позволяет поправить название и цену.
+// Предложение сначала занимается условным переводом из pending, затем в той же транзакции
+// создается подписка и привязывается к нему: из параллельных запросов подписку создаст
+// только один, остальные получат ErrCandidateProcessed, а при ошибке предложение останется pending.
+func (s *CandidateService) Accept(ctx context.Context, id uuid.UUID, serviceName *string, price *int) (*domain.Subscription, error) {
+	candidate, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("candidate not found: %w", err)
+	}
+	if candidate.Status != domain.CandidatePending {
+		return nil, ErrCandidateProcessed
+	}
+
+	sub := candidate.ToSubscription()
+	if serviceName != nil {
+		sub.ServiceName = *serviceName
+	}
+	if price != nil {
+		sub.Price = *price
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// UPDATE блокирует строку до конца транзакции: параллельный Accept дождется
+		// ее и уже не найдет предложение в pending
+		claimed, err := s.repo.SetStatus(ctx, id, domain.CandidateAccepted, nil)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrCandidateProcessed
+		}
+
+		if err := s.subs.Create(ctx, sub); err != nil {
+			return err
+		}
+
+		return s.repo.LinkSubscription(ctx, id, sub.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (s *CandidateService) Dismiss(ctx context.Context, id uuid.UUID) error {
+	candidate, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("candidate not found: %w", err)
+	}
+	if candidate.Status != domain.CandidatePending {
+		return ErrCandidateProcessed
+	}
+
+	ok, err := s.repo.SetStatus(ctx, id, domain.CandidateDismissed, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCandidateProcessed
+	}
+	return nil
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/internal/importer"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCandidateService_Analyze(t *testing.T) {
+	mockCandidates := new(testutil.MockCandidateRepository)
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewCandidateService(mockCandidates, NewSubscriptionService(mockRepo, logger), new(testutil.MockTransactor), logger)
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+
+	txs := []importer.Transaction{
+		{Date: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), Amount: -299, Payee: "KINOPOISK"},
+		{Date: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC), Amount: -299, Payee: "KINOPOISK"},
+		{Date: time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC), Amount: -299, Payee: "KINOPOISK"},
+		{Date: time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), Amount: -5000, Payee: "DNS SHOP"},
+	}
+
+	mockCandidates.On("ReplacePending", ctx, userID, mock.Anything).Return(nil)
+
+	candidates, err := service.Analyze(ctx, userID, txs)
+
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "KINOPOISK", candidates[0].ServiceName)
+	assert.Equal(t, 299, candidates[0].Price)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), candidates[0].StartDate)
+	require.NotNil(t, candidates[0].EndDate)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), *candidates[0].EndDate)
+	mockCandidates.AssertExpectations(t)
+}
+
+func TestCandidateService_Accept(t *testing.T) {
+	mockCandidates := new(testutil.MockCandidateRepository)
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewCandidateService(mockCandidates, NewSubscriptionService(mockRepo, logger), new(testutil.MockTransactor), logger)
+
+	ctx := context.Background()
+	candidate := &domain.SubscriptionCandidate{
+		ID:          uuid.New(),
+		UserID:      testutil.FixtureUserID(),
+		ServiceName: "YANDEX*PLUS",
+		Price:       399,
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Status:      domain.CandidatePending,
+	}
+	name := "Yandex Plus"
+
+	mockCandidates.On("GetByID", ctx, candidate.ID).Return(candidate, nil)
+	mockRepo.On("Create", ctx, mock.MatchedBy(func(sub *domain.Subscription) bool {
+		return sub.ServiceName == name && sub.Price == 399 && sub.UserID == candidate.UserID
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Subscription).ID = testutil.FixtureSubscriptionID()
+	}).Return(nil)
+	subID := testutil.FixtureSubscriptionID()
+	mockCandidates.On("SetStatus", ctx, candidate.ID, domain.CandidateAccepted, (*uuid.UUID)(nil)).Return(true, nil)
+	mockCandidates.On("LinkSubscription", ctx, candidate.ID, subID).Return(nil)
+
+	sub, err := service.Accept(ctx, candidate.ID, &name, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, subID, sub.ID)
+	mockCandidates.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCandidateService_Accept_AlreadyProcessed(t *testing.T) {
+	mockCandidates := new(testutil.MockCandidateRepository)
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewCandidateService(mockCandidates, NewSubscriptionService(mockRepo, logger), new(testutil.MockTransactor), logger)
+
+	ctx := context.Background()
+	candidate := &domain.SubscriptionCandidate{ID: uuid.New(), Status: domain.CandidateDismissed}
+
+	mockCandidates.On("GetByID", ctx, candidate.ID).Return(candidate, nil)
+
+	_, err := service.Accept(ctx, candidate.ID, nil, nil)
+
+	assert.ErrorIs(t, err, ErrCandidateProcessed)
+	mockRepo.AssertNotCalled(t, "Create")
+}
+
+func TestCandidateService_Accept_ClaimedConcurrently(t *testing.T) {
+	mockCandidates := new(testutil.MockCandidateRepository)
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewCandidateService(mockCandidates, NewSubscriptionService(mockRepo, logger), new(testutil.MockTransactor), logger)
+
+	ctx := context.Background()
+	candidate := &domain.SubscriptionCandidate{ID: uuid.New(), ServiceName: "NETFLIX", Price: 799, Status: domain.CandidatePending}
+
+	// между чтением и условным обновлением предложение принял другой запрос
+	mockCandidates.On("GetByID", ctx, candidate.ID).Return(candidate, nil)
+	mockCandidates.On("SetStatus", ctx, candidate.ID, domain.CandidateAccepted, (*uuid.UUID)(nil)).Return(false, nil)
+
+	_, err := service.Accept(ctx, candidate.ID, nil, nil)
+
+	assert.ErrorIs(t, err, ErrCandidateProcessed)
+	mockRepo.AssertNotCalled(t, "Create")
+	mockCandidates.AssertNotCalled(t, "LinkSubscription")
+}
+
+func TestCandidateService_Accept_CreateFails(t *testing.T) {
+	mockCandidates := new(testutil.MockCandidateRepository)
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewCandidateService(mockCandidates, NewSubscriptionService(mockRepo, logger), new(testutil.MockTransactor), logger)
+
+	ctx := context.Background()
+	candidate := &domain.SubscriptionCandidate{ID: uuid.New(), ServiceName: "NETFLIX", Price: 799, Status: domain.CandidatePending}
+
+	mockCandidates.On("GetByID", ctx, candidate.ID).Return(candidate, nil)
+	mockCandidates.On("SetStatus", ctx, candidate.ID, domain.CandidateAccepted, (*uuid.UUID)(nil)).Return(true, nil)
+	mockRepo.On("Create", ctx, mock.Anything).Return(errors.New("connection reset"))
+
+	_, err := service.Accept(ctx, candidate.ID, nil, nil)
+
+	// ошибка возвращается из транзакции, поэтому перевод в accepted откатывается
+	assert.ErrorContains(t, err, "connection reset")
+	mockCandidates.AssertNotCalled(t, "LinkSubscription")
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrAliasConflict - название или синоним уже принадлежат другому сервису каталога
+var ErrAliasConflict = errors.New("service name or alias is already used in catalog")
+
+// ErrInvalidCatalogEntry - запись каталога не прошла проверку
+var ErrInvalidCatalogEntry = errors.New("invalid catalog entry")
+
+type CatalogRepository interface {
+	Create(ctx context.Context, e *domain.CatalogEntry) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.CatalogEntry, error)
+	FindByKey(ctx context.Context, key string) (*domain.CatalogEntry, error)
+	FindConflict(ctx context.Context, keys []string, exceptID uuid.UUID) (*domain.CatalogEntry, error)
+	List(ctx context.Context, category string) ([]domain.CatalogEntry, error)
+	Update(ctx context.Context, e *domain.CatalogEntry) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// CatalogService ведет справочник сервисов и сопоставляет свободные названия
+// подписок с каноническими
+type CatalogService struct {
+	repo CatalogRepository
+	// reports - кеш отчетов, который сбрасывается при изменении и удалении сервиса; может быть nil
+	reports ReportCache
+	logger  *zap.Logger
+}
+
+func NewCatalogService(repo CatalogRepository, reports ReportCache, logger *zap.Logger) *CatalogService {
+	return &CatalogService{
+		repo:    repo,
+		reports: reports,
+		logger:  logger,
+	}
+}
+
+func (s *CatalogService) Create(ctx context.Context, e *domain.CatalogEntry) error {
+	if err := s.prepare(ctx, e); err != nil {
+		return err
+	}
+
+	if err := s.repo.Create(ctx, e); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return ErrAliasConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *CatalogService) GetByID(ctx context.Context, id uuid.UUID) (*domain.CatalogEntry, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *CatalogService) List(ctx context.Context, category string) ([]domain.CatalogEntry, error) {
+	return s.repo.List(ctx, category)
+}
+
+func (s *CatalogService) Update(ctx context.Context, e *domain.CatalogEntry) error {
+	if err := s.prepare(ctx, e); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, e); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return ErrAliasConflict
+		}
+		return err
+	}
+
+	invalidateCatalogReports(ctx, s.reports, s.logger)
+	return nil
+}
+
+// Delete удаляет сервис; подписки на него теряют service_id, поэтому отчеты сбрасываются
+func (s *CatalogService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	invalidateCatalogReports(ctx, s.reports, s.logger)
+	return nil
+}
+
+// Resolve находит сервис по свободному названию с точностью до регистра и
+// пунктуации. Если совпадения нет, возвращает domain.ErrNotFound.
+func (s *CatalogService) Resolve(ctx context.Context, name string) (*domain.CatalogEntry, error) {
+	key := domain.NormalizeServiceName(name)
+	if key == "" {
+		return nil, domain.ErrNotFound
+	}
+
+	return s.repo.FindByKey(ctx, key)
+}
+
+// prepare проверяет запись и следит, чтобы синонимы не пересекались с другими сервисами,
+// иначе одно название разрешалось бы неоднозначно
+func (s *CatalogService) prepare(ctx context.Context, e *domain.CatalogEntry) error {
+	e.Name = strings.TrimSpace(e.Name)
+	if domain.NormalizeServiceName(e.Name) == "" {
+		return fmt.Errorf("%w: name must contain letters or digits", ErrInvalidCatalogEntry)
+	}
+
+	if e.DefaultPrice != nil && *e.DefaultPrice < 0 {
+		return fmt.Errorf("%w: default_price cannot be negative", ErrInvalidCatalogEntry)
+	}
+
+	aliases := []string{}
+	for _, alias := range e.Aliases {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	e.Aliases = aliases
+
+	other, err := s.repo.FindConflict(ctx, e.SearchKeys(), e.ID)
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	s.logger.Warn("catalog alias conflict", zap.String("name", e.Name), zap.String("other", other.Name))
+	return fmt.Errorf("%w: %s", ErrAliasConflict, other.Name)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func fixtureCatalogEntry() *domain.CatalogEntry {
+	return &domain.CatalogEntry{
+		ID:       uuid.MustParse("5b1c2f0e-7a3d-4c8e-9f61-2d4b8a0c6e13"),
+		Name:     "Yandex Plus",
+		Aliases:  []string{"Яндекс Плюс", "YANDEX*PLUS"},
+		Category: "music",
+	}
+}
+
+func TestCatalogService_Create(t *testing.T) {
+	mockCatalog := new(testutil.MockCatalogRepository)
+	service := NewCatalogService(mockCatalog, nil, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	entry := &domain.CatalogEntry{Name: " Yandex Plus ", Aliases: []string{"Яндекс Плюс", " "}}
+
+	mockCatalog.On("FindConflict", ctx, []string{"yandex plus", "яндекс плюс"}, uuid.Nil).Return(nil, domain.ErrNotFound)
+	mockCatalog.On("Create", ctx, entry).Return(nil)
+
+	err := service.Create(ctx, entry)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Yandex Plus", entry.Name)
+	assert.Equal(t, []string{"Яндекс Плюс"}, entry.Aliases)
+	mockCatalog.AssertExpectations(t)
+}
+
+func TestCatalogService_Create_AliasConflict(t *testing.T) {
+	mockCatalog := new(testutil.MockCatalogRepository)
+	service := NewCatalogService(mockCatalog, nil, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	entry := &domain.CatalogEntry{Name: "Plus", Aliases: []string{"yandex-plus"}}
+
+	mockCatalog.On("FindConflict", ctx, mock.Anything, uuid.Nil).Return(fixtureCatalogEntry(), nil)
+
+	err := service.Create(ctx, entry)
+
+	assert.ErrorIs(t, err, ErrAliasConflict)
+	mockCatalog.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSubscriptionService_Create_ResolvesCatalogAlias(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	mockCatalog := new(testutil.MockCatalogRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewSubscriptionService(mockRepo, logger, WithCatalog(NewCatalogService(mockCatalog, nil, logger)))
+
+	ctx := context.Background()
+	entry := fixtureCatalogEntry()
+	sub := testutil.FixtureSubscription(testutil.WithServiceName("  яндекс  ПЛЮС!"))
+
+	mockCatalog.On("FindByKey", ctx, "яндекс плюс").Return(entry, nil)
+	mockRepo.On("Create", ctx, sub).Return(nil)
+
+	err := service.Create(ctx, sub)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Yandex Plus", sub.ServiceName)
+	assert.Equal(t, &entry.ID, sub.ServiceID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_Create_UnknownNameKeepsFreeText(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	mockCatalog := new(testutil.MockCatalogRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewSubscriptionService(mockRepo, logger, WithCatalog(NewCatalogService(mockCatalog, nil, logger)))
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription(testutil.WithServiceName("Local Gym"))
+
+	mockCatalog.On("FindByKey", ctx, "local gym").Return(nil, domain.ErrNotFound)
+	mockRepo.On("Create", ctx, sub).Return(nil)
+
+	err := service.Create(ctx, sub)
+
+	require.NoError(t, err)
+	assert.Equal(t, "Local Gym", sub.ServiceName)
+	assert.Nil(t, sub.ServiceID)
+}
+
+func TestSubscriptionService_Create_UnknownServiceID(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	mockCatalog := new(testutil.MockCatalogRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewSubscriptionService(mockRepo, logger, WithCatalog(NewCatalogService(mockCatalog, nil, logger)))
+
+	ctx := context.Background()
+	serviceID := uuid.New()
+	sub := testutil.FixtureSubscription()
+	sub.ServiceID = &serviceID
+
+	mockCatalog.On("GetByID", ctx, serviceID).Return(nil, domain.ErrNotFound)
+
+	err := service.Create(ctx, sub)
+
+	assert.True(t, errors.Is(err, ErrUnknownService))
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"github.com/SoulStalker/subscribes_api/internal/cost"
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+var ErrInvalidCostMode = cost.ErrInvalidMode
+
+// costLines построчно передает в fn стоимость подписок за период в режиме filter.CostMode.
+// Целые месяцы уже посчитаны базой тем же правилом, что cost.WholeMonths,
+// для остальных режимов строки пересчитываются.
+func (s *SubscriptionService) costLines(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.CostLine) error) error {
+	calc, err := cost.For(filter.CostMode)
+	if err != nil {
+		return err
+	}
+
+	if filter.CostMode == "" || filter.CostMode == domain.CostModeWholeMonths {
+		return s.repo.StreamCostBreakdown(ctx, filter, fn)
+	}
+
+	return s.repo.StreamCostBreakdown(ctx, filter, func(line *domain.CostLine) error {
+		line.Months, line.Cost = calc(&line.Subscription, line.Share, *filter.StartPeriod, *filter.EndPeriod)
+		return fn(line)
+	})
+}
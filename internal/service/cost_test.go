@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSubscriptionService_TotalCost_DailyProrated(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	startPeriod := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	endPeriod := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	filter := domain.SubscriptionFilter{StartPeriod: &startPeriod, EndPeriod: &endPeriod, CostMode: domain.CostModeDailyProrated}
+
+	sub := testutil.FixtureSubscription(testutil.WithPrice(3100),
+		testutil.WithDates(time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC), time.Time{}))
+	// месячная цена берется из доли в строке отчета, а не из цены подписки
+	lines := []domain.CostLine{{Subscription: *sub, Share: 1550, Months: 1, Cost: 1550}}
+	mockRepo.On("StreamCostBreakdown", ctx, filter).Return(lines, nil)
+
+	total, err := service.TotalCost(ctx, filter)
+
+	require.NoError(t, err)
+	assert.Equal(t, 800, total)
+	mockRepo.AssertNotCalled(t, "TotalCost")
+}
+
+func TestSubscriptionService_TotalCost_InvalidMode(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	startPeriod := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	endPeriod := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	filter := domain.SubscriptionFilter{StartPeriod: &startPeriod, EndPeriod: &endPeriod, CostMode: "yearly"}
+
+	_, err := service.TotalCost(context.Background(), filter)
+	assert.True(t, errors.Is(err, ErrInvalidCostMode))
+
+	filter.CostMode = domain.CostModeChargeEvents
+	_, err = service.TotalCostByGroup(context.Background(), filter, domain.GroupByCategory)
+	assert.True(t, errors.Is(err, ErrInvalidCostMode))
+
+	mockRepo.AssertNotCalled(t, "StreamCostBreakdown")
+	mockRepo.AssertNotCalled(t, "TotalCostByGroup")
+}
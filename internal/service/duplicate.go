@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrDuplicateSubscription - в строгом режиме у пользователя уже есть подписка
+// на этот сервис в пересекающиеся месяцы
+var ErrDuplicateSubscription = errors.New("overlapping subscription to the same service already exists")
+
+// WithStrictDuplicates запрещает создавать и изменять подписку так, чтобы она пересекалась
+// с другой подпиской пользователя на тот же сервис. Проверка идет в транзакции записи
+// под блокировкой пользователя, поэтому без WithOutbox (без транзакции) параллельные
+// запросы могут пройти ее одновременно.
+func WithStrictDuplicates() Option {
+	return func(s *SubscriptionService) {
+		s.strictDuplicates = true
+	}
+}
+
+// Duplicates ищет подписки одного пользователя на один сервис (по каталогу или
+// нормализованному названию) с пересекающимися периодами действия
+func (s *SubscriptionService) Duplicates(ctx context.Context, filter domain.SubscriptionFilter) ([]domain.Duplicate, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.Duplicates")
+	defer span.End()
+
+	subs, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+
+	groups := map[string][]domain.Subscription{}
+	keys := []string{}
+	for _, sub := range subs {
+		key := sub.DuplicateKey()
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], sub)
+	}
+
+	currentMonth := monthStart(localDate(s.now(), LocationFrom(ctx)))
+
+	result := []domain.Duplicate{}
+	for _, key := range keys {
+		if len(groups[key]) < 2 {
+			continue
+		}
+		if dup, ok := findOverlap(groups[key], currentMonth); ok {
+			result = append(result, *dup)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Wasted > result[j].Wasted
+	})
+
+	s.log(ctx).Debug("duplicates found", zap.Int("count", len(result)))
+	return result, nil
+}
+
+// findOverlap перебирает месяцы действия подписок группы и считает переплату
+// в месяцах, где одновременно действуют две и более подписки
+func findOverlap(subs []domain.Subscription, currentMonth time.Time) (*domain.Duplicate, bool) {
+	sort.SliceStable(subs, func(i, j int) bool {
+		return subs[i].StartDate.Before(subs[j].StartDate)
+	})
+
+	month := monthStart(subs[0].StartDate)
+
+	// после последнего начала набор действующих подписок меняется только окончаниями,
+	// поэтому бессрочные пересечения достаточно проверить до этого месяца
+	horizon := monthStart(subs[len(subs)-1].StartDate)
+	if horizon.Before(currentMonth) {
+		horizon = currentMonth
+	}
+
+	var dup *domain.Duplicate
+	involved := map[int]bool{}
+
+	for ; !month.After(horizon); month = month.AddDate(0, 1, 0) {
+		active := []int{}
+		for i := range subs {
+			if subs[i].ActiveIn(month) {
+				active = append(active, i)
+			}
+		}
+		if len(active) < 2 {
+			continue
+		}
+
+		if dup == nil {
+			dup = &domain.Duplicate{
+				UserID:      subs[active[0]].UserID,
+				ServiceName: subs[active[0]].ServiceName,
+				ServiceID:   subs[active[0]].ServiceID,
+				From:        month,
+			}
+		}
+
+		total, highest := 0, 0
+		for _, i := range active {
+			involved[i] = true
+			total += subs[i].Price
+			highest = max(highest, subs[i].Price)
+		}
+
+		if month.After(currentMonth) {
+			dup.Ongoing = true
+			continue
+		}
+		if month.Equal(currentMonth) {
+			dup.Ongoing = true
+		}
+		dup.Months++
+		dup.Wasted += total - highest
+	}
+
+	if dup == nil {
+		return nil, false
+	}
+
+	for i := range subs {
+		if involved[i] {
+			dup.Subscriptions = append(dup.Subscriptions, subs[i])
+		}
+	}
+
+	return dup, true
+}
+
+// checkDuplicate в строгом режиме отклоняет подписку, если у пользователя уже есть
+// другая подписка на тот же сервис в пересекающиеся месяцы. Вызывается в транзакции
+// записи: блокировка пользователя до ее конца не дает двум запросам одновременно
+// пройти проверку и записать пересекающиеся подписки.
+func (s *SubscriptionService) checkDuplicate(ctx context.Context, sub *domain.Subscription) error {
+	if !s.strictDuplicates {
+		return nil
+	}
+
+	if err := s.repo.LockUser(ctx, sub.UserID); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.List(ctx, domain.SubscriptionFilter{UserID: &sub.UserID})
+	if err != nil {
+		return fmt.Errorf("list subscriptions: %w", err)
+	}
+
+	if i := findDuplicate(sub, existing); i >= 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateSubscription, existing[i].ID)
+	}
+
+	return nil
+}
+
+// findDuplicate - индекс подписки из existing на тот же сервис в пересекающиеся месяцы или -1.
+// Сама подписка при изменении пропускается; у еще не сохраненных подписок id пустой.
+func findDuplicate(sub *domain.Subscription, existing []domain.Subscription) int {
+	key := sub.DuplicateKey()
+	for i := range existing {
+		if sub.ID != uuid.Nil && existing[i].ID == sub.ID {
+			continue
+		}
+		if existing[i].DuplicateKey() == key && existing[i].Overlaps(sub) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSubscriptionService_Duplicates(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+	service.now = func() time.Time { return time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	filter := domain.SubscriptionFilter{UserID: &userID}
+
+	personal := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithServiceName("Yandex Plus"),
+		testutil.WithPrice(400), testutil.WithDates(month(2025, 1), time.Time{}))
+	family := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithServiceName("yandex-plus"),
+		testutil.WithPrice(600), testutil.WithDates(month(2025, 4), time.Time{}))
+	// переподписка после отмены не пересекается с прежней
+	netflix := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithServiceName("Netflix"),
+		testutil.WithPrice(300), testutil.WithDates(month(2025, 1), time.Time{}))
+	oldNetflix := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithServiceName("netflix"),
+		testutil.WithPrice(300), testutil.WithDates(month(2024, 1), month(2024, 12)))
+
+	mockRepo.On("List", ctx, filter).Return([]domain.Subscription{*personal, *family, *netflix, *oldNetflix}, nil)
+
+	duplicates, err := service.Duplicates(ctx, filter)
+
+	require.NoError(t, err)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, month(2025, 4), duplicates[0].From)
+	assert.Equal(t, 3, duplicates[0].Months)
+	assert.Equal(t, 1200, duplicates[0].Wasted)
+	assert.True(t, duplicates[0].Ongoing)
+	require.Len(t, duplicates[0].Subscriptions, 2)
+	assert.Equal(t, personal.ID, duplicates[0].Subscriptions[0].ID)
+}
+
+func TestSubscriptionService_Create_StrictDuplicates(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithStrictDuplicates())
+
+	ctx := context.Background()
+	serviceID := uuid.New()
+	userID := testutil.FixtureUserID()
+
+	existing := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithDates(month(2025, 1), month(2025, 6)))
+	existing.ServiceID = &serviceID
+
+	mockRepo.On("LockUser", ctx, userID).Return(nil)
+	mockRepo.On("List", ctx, mock.Anything).Return([]domain.Subscription{*existing}, nil)
+
+	overlapping := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithDates(month(2025, 6), time.Time{}))
+	overlapping.ID = uuid.Nil
+	overlapping.ServiceID = &serviceID
+
+	err := service.Create(ctx, overlapping)
+	assert.ErrorIs(t, err, ErrDuplicateSubscription)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	later := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithDates(month(2025, 7), time.Time{}))
+	later.ID = uuid.Nil
+	later.ServiceID = &serviceID
+	mockRepo.On("Create", ctx, later).Return(nil)
+
+	require.NoError(t, service.Create(ctx, later))
+	mockRepo.AssertNumberOfCalls(t, "LockUser", 2)
+}
+
+func TestSubscriptionService_Update_StrictDuplicates(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	outbox := new(testutil.MockOutbox)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithStrictDuplicates(), WithOutbox(outbox))
+
+	ctx := context.Background()
+	serviceID := uuid.New()
+	userID := testutil.FixtureUserID()
+
+	other := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithDates(month(2025, 1), month(2025, 6)))
+	other.ServiceID = &serviceID
+	stored := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithDates(month(2025, 7), time.Time{}))
+	stored.ServiceID = &serviceID
+
+	mockRepo.On("GetByID", ctx, stored.ID).Return(stored, nil)
+	mockRepo.On("LockUser", ctx, userID).Return(nil)
+	mockRepo.On("List", ctx, domain.SubscriptionFilter{UserID: &userID}).Return([]domain.Subscription{*other, *stored}, nil)
+
+	// перенос начала на месяц, в котором действует другая подписка, отклоняется
+	moved := testutil.FixtureSubscription(testutil.WithDates(month(2025, 5), time.Time{}))
+	moved.ID = stored.ID
+	moved.ServiceID = &serviceID
+
+	err := service.Update(ctx, moved)
+
+	assert.ErrorIs(t, err, ErrDuplicateSubscription)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	outbox.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+}
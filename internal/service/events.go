@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Transactor выполняет fn в транзакции; репозитории, получившие ctx из fn, работают в ней,
+// а вложенный WithinTx присоединяется к внешней транзакции
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Outbox записывает события в той же транзакции, что и изменение подписки:
+// событие сохраняется, только если сохранено изменение, и наоборот
+type Outbox interface {
+	// WithinTx выполняет fn в транзакции; репозитории, получившие ctx из fn, пишут в нее
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	Append(ctx context.Context, events ...domain.Event) error
+}
+
+// WithOutbox включает запись событий о подписках
+func WithOutbox(outbox Outbox) Option {
+	return func(s *SubscriptionService) {
+		s.outbox = outbox
+	}
+}
+
+// inTx выполняет fn в транзакции outbox; без outbox - как есть
+func (s *SubscriptionService) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.outbox == nil {
+		return fn(ctx)
+	}
+	return s.outbox.WithinTx(ctx, fn)
+}
+
+// emit записывает события о подписках subs; без outbox ничего не делает
+func (s *SubscriptionService) emit(ctx context.Context, eventType domain.EventType, subs ...*domain.Subscription) error {
+	if s.outbox == nil || len(subs) == 0 {
+		return nil
+	}
+
+	events := make([]domain.Event, 0, len(subs))
+	for _, sub := range subs {
+		event, err := domain.NewSubscriptionEvent(eventType, sub)
+		if err != nil {
+			return fmt.Errorf("encode %s event: %w", eventType, err)
+		}
+		events = append(events, event)
+	}
+
+	return s.outbox.Append(ctx, events...)
+}
+
+// emitBudgetAlert записывает событие о пересечении порога бюджета b; без outbox ничего не делает
+func (s *SubscriptionService) emitBudgetAlert(ctx context.Context, b *domain.Budget, alert *domain.BudgetAlert) error {
+	if s.outbox == nil {
+		return nil
+	}
+
+	event, err := domain.NewBudgetAlertEvent(b, alert)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", domain.EventBudgetThresholdCrossed, err)
+	}
+	return s.outbox.Append(ctx, event)
+}
+
+// touchedSubscription читает подписку для события и сброса кеша ее отчетов. Без кеша
+// и outbox не читает ничего. Ошибка чтения важна только для outbox: без подписки
+// событие не записать, а кеш обойдется сбросом общих отчетов.
+func (s *SubscriptionService) touchedSubscription(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	if s.cache == nil && s.outbox == nil {
+		return nil, nil
+	}
+
+	sub, err := s.repo.GetByID(ctx, id)
+	if err != nil && s.outbox != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// NotifyEndingSoon записывает subscription.ending_soon для подписок, которые закончатся
+// в ближайшие days дней (UTC). Подписка действует до конца месяца end_date, поэтому
+// событие приходит за days дней до последнего дня этого месяца, а не до end_date.
+// Событие пишется один раз на подписку и дату окончания,
+// поэтому проверку можно запускать сколько угодно часто. Возвращает число подписок в окне.
+func (s *SubscriptionService) NotifyEndingSoon(ctx context.Context, days int) (int, error) {
+	if s.outbox == nil {
+		return 0, nil
+	}
+
+	today := localDate(s.now(), time.UTC)
+	subs, err := s.repo.EndingBetween(ctx, today, today.AddDate(0, 0, days))
+	if err != nil {
+		return 0, err
+	}
+
+	events := make([]domain.Event, 0, len(subs))
+	for i := range subs {
+		event, err := domain.NewSubscriptionEvent(domain.EventSubscriptionEndingSoon, &subs[i])
+		if err != nil {
+			return 0, fmt.Errorf("encode ending_soon event: %w", err)
+		}
+		event.DedupKey = fmt.Sprintf("%s:%s:%s", event.Type, subs[i].ID, subs[i].EndDate.Format("2006-01-02"))
+		events = append(events, event)
+	}
+
+	if len(events) > 0 {
+		if err := s.outbox.Append(ctx, events...); err != nil {
+			return 0, err
+		}
+	}
+
+	s.log(ctx).Debug("ending subscriptions checked", zap.Int("count", len(subs)))
+	return len(subs), nil
+}
+
+// RunEndingSoon раз в interval проверяет подписки, заканчивающиеся в ближайшие days дней
+func (s *SubscriptionService) RunEndingSoon(ctx context.Context, interval time.Duration, days int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.NotifyEndingSoon(ctx, days); err != nil && ctx.Err() == nil {
+			s.logger.Error("failed to check ending subscriptions", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSubscriptionService_Create_AppendsEvent(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	outbox := new(testutil.MockOutbox)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithOutbox(outbox))
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription()
+
+	mockRepo.On("Create", ctx, sub).Return(nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(events []domain.Event) bool {
+		var data domain.Subscription
+		return len(events) == 1 &&
+			events[0].Type == domain.EventSubscriptionCreated &&
+			events[0].SubscriptionID == sub.ID &&
+			json.Unmarshal(events[0].Data, &data) == nil && data.Price == sub.Price
+	})).Return(nil)
+
+	require.NoError(t, service.Create(ctx, sub))
+	outbox.AssertExpectations(t)
+}
+
+func TestSubscriptionService_Create_FailsWhenEventNotWritten(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	outbox := new(testutil.MockOutbox)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithOutbox(outbox))
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription()
+
+	mockRepo.On("Create", ctx, sub).Return(nil)
+	outbox.On("Append", ctx, mock.Anything).Return(errors.New("disk full"))
+
+	// ошибка outbox откатывает транзакцию, поэтому и подписка не сохранится
+	assert.Error(t, service.Create(ctx, sub))
+}
+
+func TestSubscriptionService_Delete_AppendsEventWithLastState(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	outbox := new(testutil.MockOutbox)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithOutbox(outbox))
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription()
+
+	mockRepo.On("GetByID", ctx, sub.ID).Return(sub, nil)
+	mockRepo.On("Delete", ctx, sub.ID).Return(nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(events []domain.Event) bool {
+		return len(events) == 1 && events[0].Type == domain.EventSubscriptionDeleted && events[0].SubscriptionID == sub.ID
+	})).Return(nil)
+
+	require.NoError(t, service.Delete(ctx, sub.ID))
+	mockRepo.AssertExpectations(t)
+	outbox.AssertExpectations(t)
+}
+
+func TestSubscriptionService_NotifyEndingSoon(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	outbox := new(testutil.MockOutbox)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithOutbox(outbox))
+	service.now = func() time.Time { return time.Date(2025, 3, 26, 15, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	today := time.Date(2025, 3, 26, 0, 0, 0, 0, time.UTC)
+	// март оплачен, подписка действует до 31 марта
+	end := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	sub := testutil.FixtureSubscription(testutil.WithEndDate(&end))
+
+	mockRepo.On("EndingBetween", ctx, today, today.AddDate(0, 0, 7)).Return([]domain.Subscription{*sub}, nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(events []domain.Event) bool {
+		return len(events) == 1 &&
+			events[0].Type == domain.EventSubscriptionEndingSoon &&
+			events[0].DedupKey == "subscription.ending_soon:"+sub.ID.String()+":2025-03-01"
+	})).Return(nil)
+
+	n, err := service.NotifyEndingSoon(ctx, 7)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	outbox.AssertExpectations(t)
+}
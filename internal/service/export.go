@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+)
+
+// Export построчно передает в fn подписки по тем же фильтрам, что и List
+func (s *SubscriptionService) Export(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.Subscription) error) error {
+	ctx, span := startSpan(ctx, "SubscriptionService.Export")
+	defer span.End()
+
+	return s.repo.Stream(ctx, filter, fn)
+}
+
+// ExportCost построчно передает в fn стоимость подписок за период и возвращает итог,
+// совпадающий с TotalCost для того же фильтра и режима расчета
+func (s *SubscriptionService) ExportCost(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.CostLine) error) (int, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.ExportCost")
+	defer span.End()
+
+	if filter.StartPeriod == nil || filter.EndPeriod == nil {
+		return 0, fmt.Errorf("start_period and end_period are required")
+	}
+
+	total := 0
+	err := s.costLines(ctx, filter, func(line *domain.CostLine) error {
+		total += line.Cost
+		return fn(line)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("export cost: %w", err)
+	}
+
+	return total, nil
+}
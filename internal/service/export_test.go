@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSubscriptionService_ExportCost(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	startPeriod := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	endPeriod := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	filter := domain.SubscriptionFilter{StartPeriod: &startPeriod, EndPeriod: &endPeriod}
+
+	lines := []domain.CostLine{
+		{Subscription: *testutil.FixtureSubscription(), Months: 6, Cost: 3000},
+		{Subscription: *testutil.FixtureSubscription(), Months: 2, Cost: 1000},
+	}
+	mockRepo.On("StreamCostBreakdown", ctx, filter).Return(lines, nil)
+
+	var costs []int
+	total, err := service.ExportCost(ctx, filter, func(line *domain.CostLine) error {
+		costs = append(costs, line.Cost)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 4000, total)
+	assert.Equal(t, []int{3000, 1000}, costs)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_ExportCost_MissingPeriods(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	_, err := service.ExportCost(context.Background(), domain.SubscriptionFilter{}, func(*domain.CostLine) error { return nil })
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "StreamCostBreakdown")
+}
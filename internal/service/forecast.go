@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"go.uber.org/zap"
+)
+
+const maxForecastMonths = 60
+
+// Forecast прогнозирует расходы на months календарных месяцев, начиная с текущего.
+// Подписки списываются раз в период оплаты в день start_date до месяца end_date
+// включительно; списание стоит цену, действующую на его дату с учетом запланированных
+// изменений, за весь период. Для ежемесячных подписок без изменений цены сумма
+// за месяц совпадает с TotalCost за него. С user_id учитываются и совместные подписки,
+// где пользователь участник, по его доле от цены на дату списания.
+func (s *SubscriptionService) Forecast(ctx context.Context, filter domain.SubscriptionFilter, months int) (*domain.Forecast, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.Forecast")
+	defer span.End()
+
+	if months < 1 || months > maxForecastMonths {
+		return nil, fmt.Errorf("months must be between 1 and %d", maxForecastMonths)
+	}
+
+	from := monthStart(localDate(s.now(), LocationFrom(ctx)))
+	to := from.AddDate(0, months, 0)
+
+	lastDay := to.AddDate(0, 0, -1)
+	filter.StartPeriod = &from
+	filter.EndPeriod = &lastDay
+	// отбор подписок как у отчета по списаниям: месяц end_date тоже оплачен
+	filter.CostMode = domain.CostModeChargeEvents
+
+	return cachedReport(ctx, s, "forecast", filter, "", func() (*domain.Forecast, error) {
+		return s.forecast(ctx, filter, from, to, months)
+	})
+}
+
+// forecast раскладывает списания подписок из filter по месяцам [from, to)
+func (s *SubscriptionService) forecast(ctx context.Context, filter domain.SubscriptionFilter, from, to time.Time, months int) (*domain.Forecast, error) {
+	result := &domain.Forecast{
+		From:   from,
+		To:     to.AddDate(0, -1, 0),
+		Months: make([]domain.ForecastMonth, months),
+	}
+	for i := range result.Months {
+		result.Months[i].Month = from.AddDate(0, i, 0)
+	}
+
+	err := s.repo.StreamCharges(ctx, filter, func(sub *domain.Subscription) error {
+		for _, date := range sub.ChargeDates(from, to) {
+			amount := sub.ChargeAmount(date, filter.UserID)
+			i := (date.Year()-from.Year())*12 + int(date.Month()) - int(from.Month())
+			month := &result.Months[i]
+			month.Charges++
+			month.Total += amount
+			result.Total += amount
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("forecast: %w", err)
+	}
+
+	s.log(ctx).Debug("forecast", zap.Int("months", months), zap.Int("total", result.Total))
+	return result, nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSubscriptionService_Forecast_MonthlySeries(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+	service.now = func() time.Time { return time.Date(2025, 8, 28, 15, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+
+	active := testutil.FixtureSubscription(testutil.WithPrice(400),
+		testutil.WithDates(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), time.Time{}))
+	ending := testutil.FixtureSubscription(testutil.WithPrice(300),
+		testutil.WithDates(time.Date(2025, 5, 30, 0, 0, 0, 0, time.UTC), time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)))
+	future := testutil.FixtureSubscription(testutil.WithPrice(1000),
+		testutil.WithDates(time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC), time.Time{}))
+
+	mockRepo.On("StreamCharges", ctx, mock.MatchedBy(func(f domain.SubscriptionFilter) bool {
+		return f.StartPeriod.Equal(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)) &&
+			f.EndPeriod.Equal(time.Date(2025, 10, 31, 0, 0, 0, 0, time.UTC)) &&
+			f.CostMode == domain.CostModeChargeEvents
+	})).Return([]domain.Subscription{*active, *ending, *future}, nil)
+
+	forecast, err := service.Forecast(ctx, domain.SubscriptionFilter{}, 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), forecast.From)
+	assert.Equal(t, time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), forecast.To)
+	assert.Equal(t, []domain.ForecastMonth{
+		{Month: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Charges: 2, Total: 700},
+		{Month: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Charges: 2, Total: 700},
+		{Month: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), Charges: 2, Total: 1400},
+	}, forecast.Months)
+	assert.Equal(t, 2800, forecast.Total)
+}
+
+func TestSubscriptionService_Forecast_InvalidMonths(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	_, err := service.Forecast(context.Background(), domain.SubscriptionFilter{}, maxForecastMonths+1)
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "StreamCharges")
+}
+
+func TestSubscriptionService_Forecast_MemberShare(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+	service.now = func() time.Time { return time.Date(2025, 8, 28, 15, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	memberID := testutil.FixtureUserID()
+
+	// семейная подписка другого владельца, пользователь платит свою долю
+	family := testutil.FixtureSubscription(testutil.WithPrice(900), testutil.WithUserID(uuid.New()),
+		testutil.WithDates(time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), time.Time{}))
+	family.Split = &domain.Split{Mode: domain.SplitEqual, Members: []domain.Member{{UserID: memberID}, {UserID: uuid.New()}}}
+	family.Split.Apply(family.Price)
+
+	mockRepo.On("StreamCharges", ctx, mock.MatchedBy(func(f domain.SubscriptionFilter) bool {
+		return f.UserID != nil && *f.UserID == memberID
+	})).Return([]domain.Subscription{*family}, nil)
+
+	forecast, err := service.Forecast(ctx, domain.SubscriptionFilter{UserID: &memberID}, 2)
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ForecastMonth{
+		{Month: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Charges: 1, Total: 300},
+		{Month: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Charges: 1, Total: 300},
+	}, forecast.Months)
+	assert.Equal(t, 600, forecast.Total)
+}
+
+func TestSubscriptionService_Forecast_BillingPeriodsAndPriceChanges(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+	service.now = func() time.Time { return time.Date(2025, 8, 28, 15, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+
+	// годовая подписка с 10 сентября 2024: следующее списание 10 сентября 2025 за 12 месяцев
+	yearly := testutil.FixtureSubscription(testutil.WithPrice(100),
+		testutil.WithDates(time.Date(2024, 9, 10, 0, 0, 0, 0, time.UTC), time.Time{}))
+	yearly.BillingPeriod = domain.BillingYearly
+
+	// ежемесячная подписка дорожает с октября
+	monthly := testutil.FixtureSubscription(testutil.WithPrice(400),
+		testutil.WithDates(time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), time.Time{}))
+	monthly.PriceChanges = []domain.PriceChange{{EffectiveFrom: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), Price: 500}}
+
+	// квартальная подписка с июля: списания в июле и октябре
+	quarterly := testutil.FixtureSubscription(testutil.WithPrice(200),
+		testutil.WithDates(time.Date(2025, 7, 20, 0, 0, 0, 0, time.UTC), time.Time{}))
+	quarterly.BillingPeriod = domain.BillingQuarterly
+
+	mockRepo.On("StreamCharges", ctx, mock.Anything).
+		Return([]domain.Subscription{*yearly, *monthly, *quarterly}, nil)
+
+	forecast, err := service.Forecast(ctx, domain.SubscriptionFilter{}, 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.ForecastMonth{
+		{Month: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), Charges: 1, Total: 400},
+		{Month: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Charges: 2, Total: 1600},
+		{Month: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), Charges: 2, Total: 1100},
+	}, forecast.Months)
+	assert.Equal(t, 3100, forecast.Total)
+}
+
+func TestSubscriptionService_Forecast_MemberShareFollowsPriceChange(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+	service.now = func() time.Time { return time.Date(2025, 8, 28, 15, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	memberID := testutil.FixtureUserID()
+
+	family := testutil.FixtureSubscription(testutil.WithPrice(1000), testutil.WithUserID(uuid.New()),
+		testutil.WithDates(time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), time.Time{}))
+	family.Split = &domain.Split{Mode: domain.SplitPercentage, Members: []domain.Member{{UserID: memberID, Share: 30}}}
+	family.Split.Apply(family.Price)
+	family.PriceChanges = []domain.PriceChange{{EffectiveFrom: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), Price: 2000}}
+
+	mockRepo.On("StreamCharges", ctx, mock.Anything).Return([]domain.Subscription{*family}, nil)
+
+	forecast, err := service.Forecast(ctx, domain.SubscriptionFilter{UserID: &memberID}, 2)
+
+	require.NoError(t, err)
+	// 30% от 1000 в августе и от новой цены 2000 с сентября
+	assert.Equal(t, 300, forecast.Months[0].Total)
+	assert.Equal(t, 600, forecast.Months[1].Total)
+	assert.Equal(t, 900, forecast.Total)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"go.uber.org/zap"
+)
+
+// readinessTimeout ограничивает одну проверку готовности, чтобы зонд не ждал зависшую базу
+const readinessTimeout = 2 * time.Second
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (uint, bool, error)
+}
+
+// HealthService отвечает, готов ли сервис принимать трафик: база доступна,
+// схема не старее ожидаемой и остановка еще не началась
+type HealthService struct {
+	repo          HealthRepository
+	schemaVersion uint
+	shuttingDown  atomic.Bool
+	logger        *zap.Logger
+
+	// lastChecks - результаты предыдущей проверки; лог пишется только при их изменении,
+	// иначе зонд раз в несколько секунд повторяет одно и то же предупреждение
+	mu         sync.Mutex
+	lastChecks string
+}
+
+// NewHealthService - schemaVersion - версия схемы, до которой дошли миграции при запуске
+func NewHealthService(repo HealthRepository, schemaVersion uint, logger *zap.Logger) *HealthService {
+	return &HealthService{
+		repo:          repo,
+		schemaVersion: schemaVersion,
+		logger:        logger,
+	}
+}
+
+// StartShutdown переводит готовность в отказ, чтобы балансировщик снял трафик до остановки сервера
+func (s *HealthService) StartShutdown() {
+	s.shuttingDown.Store(true)
+}
+
+// Ready выполняет все проверки готовности
+func (s *HealthService) Ready(ctx context.Context) domain.Readiness {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	checks := map[string]error{
+		"shutdown":   nil,
+		"database":   s.repo.Ping(ctx),
+		"migrations": s.checkMigrations(ctx),
+	}
+	if s.shuttingDown.Load() {
+		checks["shutdown"] = fmt.Errorf("shutdown in progress")
+	}
+
+	readiness := domain.Readiness{Ready: true, Checks: make(map[string]string, len(checks))}
+	for name, err := range checks {
+		readiness.Checks[name] = "ok"
+		if err != nil {
+			readiness.Ready = false
+			readiness.Checks[name] = err.Error()
+		}
+	}
+
+	s.logChange(readiness)
+
+	return readiness
+}
+
+func (s *HealthService) logChange(readiness domain.Readiness) {
+	// fmt печатает ключи map по порядку, так что одинаковые результаты дают одну строку
+	checks := fmt.Sprint(readiness.Checks)
+
+	s.mu.Lock()
+	changed := checks != s.lastChecks
+	wasReady := s.lastChecks == ""
+	s.lastChecks = checks
+	if readiness.Ready {
+		s.lastChecks = ""
+	}
+	s.mu.Unlock()
+
+	switch {
+	case !readiness.Ready && changed:
+		s.logger.Warn("service is not ready", zap.Any("checks", readiness.Checks))
+	case readiness.Ready && !wasReady:
+		s.logger.Info("service is ready again")
+	}
+}
+
+func (s *HealthService) checkMigrations(ctx context.Context) error {
+	version, dirty, err := s.repo.MigrationVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	// более новая схема - нормальное состояние при выкатке: ее уже применил следующий релиз,
+	// а миграции совместимы с предыдущей версией кода
+	if version < s.schemaVersion {
+		return fmt.Errorf("schema version %d, expected at least %d", version, s.schemaVersion)
+	}
+	return nil
+}
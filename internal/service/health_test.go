@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestHealthService_Ready(t *testing.T) {
+	tests := []struct {
+		name     string
+		pingErr  error
+		version  uint
+		dirty    bool
+		shutdown bool
+		failed   string
+	}{
+		{name: "ready", version: 8},
+		{name: "database unavailable", pingErr: errors.New("connection refused"), version: 8, failed: "database"},
+		{name: "dirty migration", version: 8, dirty: true, failed: "migrations"},
+		{name: "schema behind", version: 7, failed: "migrations"},
+		{name: "schema ahead during rollout", version: 9},
+		{name: "shutdown in progress", version: 8, shutdown: true, failed: "shutdown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(testutil.MockHealthRepository)
+			service := NewHealthService(mockRepo, 8, zaptest.NewLogger(t))
+
+			mockRepo.On("Ping", mock.Anything).Return(tt.pingErr)
+			mockRepo.On("MigrationVersion", mock.Anything).Return(tt.version, tt.dirty, nil)
+
+			if tt.shutdown {
+				service.StartShutdown()
+			}
+
+			readiness := service.Ready(context.Background())
+
+			assert.Equal(t, tt.failed == "", readiness.Ready)
+			for name, result := range readiness.Checks {
+				if name == tt.failed {
+					assert.NotEqual(t, "ok", result, name)
+				} else {
+					assert.Equal(t, "ok", result, name)
+				}
+			}
+			assert.Len(t, readiness.Checks, 3)
+		})
+	}
+}
+
+func TestHealthService_Ready_LogsOnlyChanges(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	mockRepo := new(testutil.MockHealthRepository)
+	service := NewHealthService(mockRepo, 8, zap.New(core))
+
+	mockRepo.On("MigrationVersion", mock.Anything).Return(uint(8), false, nil)
+	mockRepo.On("Ping", mock.Anything).Return(errors.New("connection refused")).Times(3)
+	mockRepo.On("Ping", mock.Anything).Return(nil)
+
+	for i := 0; i < 4; i++ {
+		service.Ready(context.Background())
+	}
+
+	messages := make([]string, 0, logs.Len())
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"service is not ready", "service is ready again"}, messages)
+}
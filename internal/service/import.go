@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/internal/importer"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Import проверяет строки по правилам Create и сохраняет валидные одной транзакцией.
+// Строка отклоняется, если ее пользователя нет (WithUsers), а в строгом режиме - если
+// она пересекается с подпиской пользователя в базе или с более ранней строкой файла.
+// При dryRun ничего не сохраняется, возвращаются только ошибки по строкам. В строгом
+// режиме пользователи строк блокируются до конца транзакции, как при Create.
+func (s *SubscriptionService) Import(ctx context.Context, rows []importer.Row, dryRun bool) (*domain.ImportResult, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.Import")
+	defer span.End()
+
+	result := &domain.ImportResult{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Errors: []domain.ImportRowError{},
+	}
+
+	var valid []*domain.Subscription
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		// пользователи блокируются заранее и в одном порядке, чтобы параллельные импорты
+		// не ждали друг друга по кругу
+		if s.strictDuplicates && !dryRun {
+			if err := s.lockImportUsers(ctx, rows); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		if valid, err = s.checkImport(ctx, rows, result); err != nil {
+			return err
+		}
+		if dryRun || len(valid) == 0 {
+			return nil
+		}
+
+		if err := s.repo.CreateBatch(ctx, valid); err != nil {
+			return fmt.Errorf("import subscriptions: %w", err)
+		}
+		if err := s.emit(ctx, domain.EventSubscriptionCreated, valid...); err != nil {
+			return fmt.Errorf("import subscriptions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Valid = len(valid)
+
+	if dryRun || len(valid) == 0 {
+		return result, nil
+	}
+	result.Imported = len(valid)
+	s.invalidateReports(ctx, valid...)
+
+	s.log(ctx).Info("subscriptions imported", zap.Int("imported", result.Imported), zap.Int("rejected", len(result.Errors)))
+	return result, nil
+}
+
+// checkImport проверяет строки, дописывает ошибки строк в result и возвращает валидные.
+// Ошибка - сбой чтения из базы, а не ошибка в данных строки.
+func (s *SubscriptionService) checkImport(ctx context.Context, rows []importer.Row, result *domain.ImportResult) ([]*domain.Subscription, error) {
+	check := &importCheck{s: s, users: map[uuid.UUID]error{}, existing: map[uuid.UUID][]importedSubscription{}}
+	valid := make([]*domain.Subscription, 0, len(rows))
+	for _, row := range rows {
+		err := row.Err
+		if err == nil {
+			err = s.validate(row.Subscription)
+		}
+		if err == nil {
+			err = check.user(ctx, row.Subscription.UserID)
+		}
+		if err == nil {
+			err = s.resolveService(ctx, row.Subscription)
+		}
+		if err == nil {
+			err = check.duplicate(ctx, row)
+		}
+
+		if err != nil {
+			// ошибка базы - не ошибка строки, продолжать импорт бессмысленно
+			var rowErr *importRowError
+			if errors.As(err, &rowErr) {
+				return nil, rowErr.err
+			}
+			result.Errors = append(result.Errors, domain.ImportRowError{Line: row.Line, Error: err.Error()})
+			continue
+		}
+
+		valid = append(valid, row.Subscription)
+	}
+	return valid, nil
+}
+
+// lockImportUsers блокирует подписки пользователей строк импорта до конца транзакции,
+// как checkDuplicate при создании, в порядке id пользователей
+func (s *SubscriptionService) lockImportUsers(ctx context.Context, rows []importer.Row) error {
+	var users []uuid.UUID
+	for _, row := range rows {
+		if row.Err == nil && row.Subscription != nil {
+			users = append(users, row.Subscription.UserID)
+		}
+	}
+	slices.SortFunc(users, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+
+	for _, id := range slices.Compact(users) {
+		if err := s.repo.LockUser(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importRowError - сбой чтения при проверке строки, а не ошибка в ее данных
+type importRowError struct {
+	err error
+}
+
+func (e *importRowError) Error() string { return e.err.Error() }
+
+type importedSubscription struct {
+	sub  domain.Subscription
+	line int // 0 - подписка из базы
+}
+
+// importCheck проверяет строки импорта с учетом уже проверенных: пользователи и подписки
+// из базы читаются один раз на пользователя, принятые строки участвуют в поиске пересечений
+type importCheck struct {
+	s        *SubscriptionService
+	users    map[uuid.UUID]error
+	existing map[uuid.UUID][]importedSubscription
+}
+
+func (c *importCheck) user(ctx context.Context, id uuid.UUID) error {
+	if c.s.users == nil {
+		return nil
+	}
+
+	err, ok := c.users[id]
+	if !ok {
+		_, err = c.s.users.GetByID(ctx, id)
+		switch {
+		case errors.Is(err, domain.ErrNotFound):
+			err = fmt.Errorf("%w: user_id %s", domain.ErrReferenceNotFound, id)
+		case err != nil:
+			err = &importRowError{err: fmt.Errorf("get user: %w", err)}
+		}
+		c.users[id] = err
+	}
+	return err
+}
+
+func (c *importCheck) duplicate(ctx context.Context, row importer.Row) error {
+	if !c.s.strictDuplicates {
+		return nil
+	}
+
+	sub := row.Subscription
+	known, ok := c.existing[sub.UserID]
+	if !ok {
+		subs, err := c.s.repo.List(ctx, domain.SubscriptionFilter{UserID: &sub.UserID})
+		if err != nil {
+			return &importRowError{err: fmt.Errorf("list subscriptions: %w", err)}
+		}
+		for _, existing := range subs {
+			known = append(known, importedSubscription{sub: existing})
+		}
+	}
+
+	candidates := make([]domain.Subscription, len(known))
+	for i := range known {
+		candidates[i] = known[i].sub
+	}
+
+	if i := findDuplicate(sub, candidates); i >= 0 {
+		c.existing[sub.UserID] = known
+		if known[i].line > 0 {
+			return fmt.Errorf("%w: line %d", ErrDuplicateSubscription, known[i].line)
+		}
+		return fmt.Errorf("%w: %s", ErrDuplicateSubscription, known[i].sub.ID)
+	}
+
+	c.existing[sub.UserID] = append(known, importedSubscription{sub: *sub, line: row.Line})
+	return nil
+}
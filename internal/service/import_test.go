@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/internal/importer"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func importRows() ([]importer.Row, *domain.Subscription) {
+	valid := testutil.FixtureSubscription()
+	invalidRange := testutil.FixtureSubscription(testutil.WithDates(
+		time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+	))
+
+	return []importer.Row{
+		{Line: 2, Subscription: valid},
+		{Line: 3, Subscription: invalidRange},
+		{Line: 4, Err: errors.New("invalid price")},
+	}, valid
+}
+
+func TestSubscriptionService_Import_DryRun(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	rows, _ := importRows()
+
+	result, err := service.Import(context.Background(), rows, true)
+
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, 1, result.Valid)
+	assert.Zero(t, result.Imported)
+	assert.Equal(t, []domain.ImportRowError{
+		{Line: 3, Error: "end_date must be after start_date"},
+		{Line: 4, Error: "invalid price"},
+	}, result.Errors)
+	mockRepo.AssertNotCalled(t, "CreateBatch")
+}
+
+func TestSubscriptionService_Import_SavesValidRows(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	rows, valid := importRows()
+
+	mockRepo.On("CreateBatch", ctx, []*domain.Subscription{valid}).Return(nil)
+
+	result, err := service.Import(ctx, rows, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Imported)
+	assert.Len(t, result.Errors, 2)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_Import_BatchError(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	rows, valid := importRows()
+
+	mockRepo.On("CreateBatch", ctx, []*domain.Subscription{valid}).Return(errors.New("tx failed"))
+
+	result, err := service.Import(ctx, rows, false)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+}
+
+func TestSubscriptionService_Import_LogsThroughRequestLogger(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	serviceCore, serviceLogs := observer.New(zap.DebugLevel)
+	service := NewSubscriptionService(mockRepo, zap.New(serviceCore))
+
+	requestCore, requestLogs := observer.New(zap.DebugLevel)
+	ctx := applogger.WithContext(context.Background(), zap.New(requestCore).With(zap.String("request_id", "req-1")))
+	rows, valid := importRows()
+
+	mockRepo.On("CreateBatch", ctx, []*domain.Subscription{valid}).Return(nil)
+
+	_, err := service.Import(ctx, rows, false)
+
+	require.NoError(t, err)
+	assert.Zero(t, serviceLogs.Len())
+	entries := requestLogs.FilterMessage("subscriptions imported").All()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "req-1", entries[0].ContextMap()["request_id"])
+}
+
+func TestSubscriptionService_Import_UnknownUser(t *testing.T) {
+	for _, dryRun := range []bool{true, false} {
+		mockRepo := new(testutil.MockSubscriptionRepository)
+		mockUsers := new(testutil.MockUserRepository)
+		service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithUsers(mockUsers))
+
+		ctx := context.Background()
+		known := testutil.FixtureSubscription()
+		unknown := testutil.FixtureSubscription()
+		rows := []importer.Row{
+			{Line: 2, Subscription: known},
+			{Line: 3, Subscription: unknown},
+			{Line: 4, Subscription: testutil.FixtureSubscription(func(s *domain.Subscription) { s.UserID = unknown.UserID })},
+		}
+
+		mockUsers.On("GetByID", ctx, known.UserID).Return(&domain.User{ID: known.UserID}, nil).Once()
+		mockUsers.On("GetByID", ctx, unknown.UserID).Return(nil, domain.ErrNotFound).Once()
+		if !dryRun {
+			mockRepo.On("CreateBatch", ctx, []*domain.Subscription{known}).Return(nil)
+		}
+
+		result, err := service.Import(ctx, rows, dryRun)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Valid)
+		require.Len(t, result.Errors, 2)
+		assert.Equal(t, 3, result.Errors[0].Line)
+		assert.Contains(t, result.Errors[0].Error, "user_id")
+		mockUsers.AssertExpectations(t)
+		mockRepo.AssertExpectations(t)
+	}
+}
+
+func TestSubscriptionService_Import_StrictDuplicates(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithStrictDuplicates())
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	stored := *testutil.FixtureSubscription(func(s *domain.Subscription) {
+		s.UserID, s.ServiceName = userID, "Netflix"
+	})
+	row := func(name string, start time.Time) *domain.Subscription {
+		return testutil.FixtureSubscription(func(s *domain.Subscription) {
+			s.ID, s.UserID, s.ServiceName, s.StartDate = uuid.Nil, userID, name, start
+		})
+	}
+	rows := []importer.Row{
+		{Line: 2, Subscription: row("netflix", time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))},
+		{Line: 3, Subscription: row("Spotify", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{Line: 4, Subscription: row("spotify", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))},
+	}
+
+	// подписки пользователя читаются один раз на весь файл
+	mockRepo.On("List", ctx, domain.SubscriptionFilter{UserID: &userID}).Return([]domain.Subscription{stored}, nil).Once()
+
+	result, err := service.Import(ctx, rows, true)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Valid)
+	require.Len(t, result.Errors, 2)
+	assert.Equal(t, 2, result.Errors[0].Line)
+	assert.Contains(t, result.Errors[0].Error, stored.ID.String())
+	assert.Equal(t, 4, result.Errors[1].Line)
+	assert.Contains(t, result.Errors[1].Error, "line 3")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_Import_StrictLocksUsersInOrder(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithStrictDuplicates())
+
+	ctx := context.Background()
+	first := uuid.MustParse("11111111-1111-4111-8111-111111111111")
+	second := uuid.MustParse("22222222-2222-4222-8222-222222222222")
+	row := func(line int, userID uuid.UUID) importer.Row {
+		return importer.Row{Line: line, Subscription: testutil.FixtureSubscription(func(s *domain.Subscription) {
+			s.ID, s.UserID = uuid.Nil, userID
+		})}
+	}
+	rows := []importer.Row{row(2, second), row(3, first), row(4, second)}
+
+	var locked []uuid.UUID
+	mockRepo.On("LockUser", ctx, mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) { locked = append(locked, args.Get(1).(uuid.UUID)) })
+	mockRepo.On("List", ctx, mock.Anything).Return([]domain.Subscription{}, nil)
+	mockRepo.On("CreateBatch", ctx, mock.Anything).Return(nil)
+
+	_, err := service.Import(ctx, rows, false)
+
+	require.NoError(t, err)
+	// каждый пользователь блокируется один раз и в одном порядке для всех импортов
+	assert.Equal(t, []uuid.UUID{first, second}, locked)
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// EventPublisher публикует событие outbox во внешнюю систему (лог, файл, HTTP, брокер).
+// nil означает, что система приняла событие; при ошибке релей повторит его позже.
+// Одно событие может прийти повторно, получатель отбрасывает дубли по Event.ID.
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.Event) error
+}
+
+// relayLease - на сколько экземпляр берет издателя; аренда продлевается, пока идет публикация
+const relayLease = 5 * time.Minute
+
+// RelayRepository хранит, какие события outbox опубликовал каждый издатель
+type RelayRepository interface {
+	RegisterPublisher(ctx context.Context, name string) error
+	// ClaimPublisher берет издателя в аренду или продлевает ее; false - его обрабатывает другой экземпляр
+	ClaimPublisher(ctx context.Context, name string, owner uuid.UUID, lease time.Duration) (bool, error)
+	ReleasePublisher(ctx context.Context, name string, owner uuid.UUID) error
+	Unpublished(ctx context.Context, publisher string, limit int) ([]domain.PendingPublication, error)
+	MarkPublished(ctx context.Context, publisher string, ids ...int64) error
+	RecordFailure(ctx context.Context, publisher string, eventID int64, failure domain.PublicationFailure) error
+}
+
+type relayPublisher struct {
+	name       string
+	publisher  EventPublisher
+	registered bool
+}
+
+// EventRelay публикует события outbox через зарегистрированных издателей. Событие
+// отмечается опубликованным только после успешного Publish, поэтому доставляется
+// хотя бы один раз. События одной подписки уходят в порядке записи: после ошибки
+// остальные события этой подписки ждут повтора, другие подписки не ждут. После
+// policy.MaxAttempts неудачных попыток событие получает статус dead и больше не
+// задерживает свою подписку.
+type EventRelay struct {
+	repo       RelayRepository
+	publishers []*relayPublisher
+	batch      int
+	policy     RetryPolicy
+	// owner отличает аренды этого экземпляра от аренд других экземпляров
+	owner  uuid.UUID
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewEventRelay(repo RelayRepository, batch int, policy RetryPolicy, logger *zap.Logger) *EventRelay {
+	return &EventRelay{
+		repo:   repo,
+		batch:  max(batch, 1),
+		policy: policy,
+		owner:  uuid.New(),
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Register добавляет издателя. По name хранится, что он уже опубликовал, поэтому
+// переименованный издатель начнет с событий, записанных после переименования.
+func (r *EventRelay) Register(name string, publisher EventPublisher) {
+	r.publishers = append(r.publishers, &relayPublisher{name: name, publisher: publisher})
+}
+
+// Relay публикует накопившиеся события всеми издателями и возвращает число публикаций.
+// Ошибка одного издателя не мешает остальным.
+func (r *EventRelay) Relay(ctx context.Context) (int, error) {
+	total := 0
+	var errs []error
+	for _, p := range r.publishers {
+		n, err := r.relay(ctx, p)
+		total += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("publisher %s: %w", p.name, err))
+		}
+	}
+
+	return total, errors.Join(errs...)
+}
+
+// relay публикует пачки, пока они полные. Публикация идет вне транзакции базы: порядок
+// защищает аренда издателя, пока она не истекла, другие экземпляры его не публикуют.
+func (r *EventRelay) relay(ctx context.Context, p *relayPublisher) (int, error) {
+	if !p.registered {
+		if err := r.repo.RegisterPublisher(ctx, p.name); err != nil {
+			return 0, err
+		}
+		p.registered = true
+	}
+
+	claimed, err := r.repo.ClaimPublisher(ctx, p.name, r.owner, relayLease)
+	if err != nil || !claimed {
+		return 0, err
+	}
+	lease := &relayClaim{at: r.now()}
+	defer func() {
+		// при остановке ctx уже отменен, а аренду стоит снять, чтобы не ждать ее истечения
+		if err := r.repo.ReleasePublisher(context.WithoutCancel(ctx), p.name, r.owner); err != nil {
+			r.logger.Warn("failed to release publisher", zap.String("publisher", p.name), zap.Error(err))
+		}
+	}()
+
+	total := 0
+	for {
+		n, more, err := r.relayBatch(ctx, p, lease)
+		total += n
+		if err != nil || !more {
+			return total, err
+		}
+	}
+}
+
+// relayClaim - когда аренда издателя последний раз взята или продлена
+type relayClaim struct {
+	at   time.Time
+	lost bool
+}
+
+// hold продлевает аренду, когда прошла половина ее срока. false - аренду перехватил
+// другой экземпляр, публиковать дальше нельзя.
+func (r *EventRelay) hold(ctx context.Context, p *relayPublisher, claim *relayClaim) (bool, error) {
+	if r.now().Sub(claim.at) < relayLease/2 {
+		return true, nil
+	}
+
+	claimed, err := r.repo.ClaimPublisher(ctx, p.name, r.owner, relayLease)
+	if err != nil {
+		return false, err
+	}
+	if !claimed {
+		claim.lost = true
+		r.logger.Warn("publisher lease lost", zap.String("publisher", p.name))
+		return false, nil
+	}
+	claim.at = r.now()
+	return true, nil
+}
+
+// relayBatch публикует одну пачку. Неудачное событие сохраняется со временем повтора и
+// не попадает в следующие пачки до него, как и более поздние события его подписки.
+// more - пачка была полной, значит стоит взять следующую.
+func (r *EventRelay) relayBatch(ctx context.Context, p *relayPublisher, claim *relayClaim) (published int, more bool, err error) {
+	pending, err := r.repo.Unpublished(ctx, p.name, r.batch)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var errs []error
+	blocked := make(map[uuid.UUID]bool)
+	ids := make([]int64, 0, len(pending))
+	for _, item := range pending {
+		event := item.Event
+		if blocked[event.SubscriptionID] {
+			continue
+		}
+
+		held, err := r.hold(ctx, p, claim)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if !held {
+			break
+		}
+
+		if err := p.publisher.Publish(ctx, event); err != nil {
+			blocked[event.SubscriptionID] = true
+			if err := r.fail(ctx, p, item, err); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		ids = append(ids, event.ID)
+	}
+
+	if len(ids) > 0 {
+		if err := r.repo.MarkPublished(ctx, p.name, ids...); err != nil {
+			return 0, false, errors.Join(append(errs, err)...)
+		}
+	}
+	if len(errs) > 0 {
+		return len(ids), false, errors.Join(errs...)
+	}
+
+	return len(ids), len(pending) == r.batch && !claim.lost, nil
+}
+
+// fail сохраняет неудачную попытку: до policy.MaxAttempts событие повторяется с
+// задержкой, затем получает статус dead
+func (r *EventRelay) fail(ctx context.Context, p *relayPublisher, item domain.PendingPublication, publishErr error) error {
+	failure := domain.PublicationFailure{
+		Attempts: item.Attempts + 1,
+		Error:    publishErr.Error(),
+	}
+	if failure.Attempts < r.policy.MaxAttempts {
+		next := r.now().Add(r.policy.Delay(failure.Attempts))
+		failure.NextAttemptAt = &next
+	}
+
+	fields := []zap.Field{
+		zap.String("publisher", p.name),
+		zap.Int64("event_id", item.Event.ID),
+		zap.String("subscription_id", item.Event.SubscriptionID.String()),
+		zap.Int("attempt", failure.Attempts),
+		zap.Error(publishErr),
+	}
+	if failure.NextAttemptAt == nil {
+		r.logger.Error("event publication failed permanently, marked dead", fields...)
+	} else {
+		r.logger.Warn("failed to publish event", append(fields, zap.Time("next_attempt_at", *failure.NextAttemptAt))...)
+	}
+
+	return r.repo.RecordFailure(ctx, p.name, item.Event.ID, failure)
+}
+
+// Run раз в interval публикует накопившиеся события
+func (r *EventRelay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Relay(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("failed to relay events", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// recordingPublisher запоминает опубликованные события и отказывает на событиях из fail
+type recordingPublisher struct {
+	fail      map[int64]bool
+	published []int64
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event domain.Event) error {
+	if p.fail[event.ID] {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+var testRelayPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+
+func newTestRelay(t *testing.T, repo RelayRepository, batch int, now time.Time) *EventRelay {
+	relay := NewEventRelay(repo, batch, testRelayPolicy, zaptest.NewLogger(t))
+	relay.now = func() time.Time { return now }
+	return relay
+}
+
+func pending(events ...domain.Event) []domain.PendingPublication {
+	items := make([]domain.PendingPublication, 0, len(events))
+	for _, e := range events {
+		items = append(items, domain.PendingPublication{Event: e})
+	}
+	return items
+}
+
+func expectClaim(repo *testutil.MockOutbox, relay *EventRelay, claimed bool) {
+	repo.On("RegisterPublisher", mock.Anything, "audit").Return(nil)
+	repo.On("ClaimPublisher", mock.Anything, "audit", relay.owner, relayLease).Return(claimed, nil)
+	if claimed {
+		repo.On("ReleasePublisher", mock.Anything, "audit", relay.owner).Return(nil)
+	}
+}
+
+func TestEventRelay_KeepsOrderPerSubscription(t *testing.T) {
+	repo := new(testutil.MockOutbox)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	relay := newTestRelay(t, repo, 10, now)
+	pub := &recordingPublisher{fail: map[int64]bool{2: true}}
+	relay.Register("audit", pub)
+
+	ctx := context.Background()
+	a, b := uuid.New(), uuid.New()
+	events := pending(
+		domain.Event{ID: 1, SubscriptionID: a},
+		domain.Event{ID: 2, SubscriptionID: b},
+		domain.Event{ID: 3, SubscriptionID: a},
+		domain.Event{ID: 4, SubscriptionID: b},
+	)
+	next := now.Add(10 * time.Second)
+
+	expectClaim(repo, relay, true)
+	repo.On("Unpublished", ctx, "audit", 10).Return(events, nil)
+	repo.On("RecordFailure", ctx, "audit", int64(2), domain.PublicationFailure{Attempts: 1, Error: "broker unavailable", NextAttemptAt: &next}).Return(nil)
+	repo.On("MarkPublished", ctx, "audit", []int64{1, 3}).Return(nil)
+
+	n, err := relay.Relay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	// событие 4 ждет, пока не будет опубликовано 2 той же подписки
+	assert.Equal(t, []int64{1, 3}, pub.published)
+	repo.AssertExpectations(t)
+}
+
+func TestEventRelay_MarksDeadAfterMaxAttempts(t *testing.T) {
+	repo := new(testutil.MockOutbox)
+	relay := newTestRelay(t, repo, 10, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	relay.Register("audit", &recordingPublisher{fail: map[int64]bool{1: true}})
+
+	ctx := context.Background()
+	item := domain.PendingPublication{Event: domain.Event{ID: 1, SubscriptionID: uuid.New()}, Attempts: 2}
+
+	expectClaim(repo, relay, true)
+	repo.On("Unpublished", ctx, "audit", 10).Return([]domain.PendingPublication{item}, nil)
+	// третья попытка из трех: повтора не будет, и следующие события подписки пойдут дальше
+	repo.On("RecordFailure", ctx, "audit", int64(1), domain.PublicationFailure{Attempts: 3, Error: "broker unavailable"}).Return(nil)
+
+	n, err := relay.Relay(ctx)
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	repo.AssertExpectations(t)
+}
+
+func TestEventRelay_FailedBatchTakesNextBatch(t *testing.T) {
+	repo := new(testutil.MockOutbox)
+	relay := newTestRelay(t, repo, 2, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC))
+	pub := &recordingPublisher{fail: map[int64]bool{1: true, 2: true}}
+	relay.Register("audit", pub)
+
+	ctx := context.Background()
+
+	expectClaim(repo, relay, true)
+	// пачка целиком из неудачных событий не останавливает остальные подписки
+	repo.On("Unpublished", ctx, "audit", 2).Return(pending(
+		domain.Event{ID: 1, SubscriptionID: uuid.New()},
+		domain.Event{ID: 2, SubscriptionID: uuid.New()},
+	), nil).Once()
+	repo.On("Unpublished", ctx, "audit", 2).Return(pending(domain.Event{ID: 3, SubscriptionID: uuid.New()}), nil).Once()
+	repo.On("RecordFailure", ctx, "audit", mock.Anything, mock.Anything).Return(nil).Twice()
+	repo.On("MarkPublished", ctx, "audit", []int64{3}).Return(nil)
+
+	n, err := relay.Relay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{3}, pub.published)
+	repo.AssertExpectations(t)
+}
+
+func TestEventRelay_TakesNextBatch(t *testing.T) {
+	repo := new(testutil.MockOutbox)
+	relay := newTestRelay(t, repo, 2, time.Now())
+	pub := &recordingPublisher{}
+	relay.Register("audit", pub)
+
+	ctx := context.Background()
+	sub := uuid.New()
+
+	expectClaim(repo, relay, true)
+	repo.On("Unpublished", ctx, "audit", 2).Return(pending(domain.Event{ID: 1, SubscriptionID: sub}, domain.Event{ID: 2, SubscriptionID: sub}), nil).Once()
+	repo.On("Unpublished", ctx, "audit", 2).Return(pending(domain.Event{ID: 3, SubscriptionID: sub}), nil).Once()
+	repo.On("MarkPublished", ctx, "audit", []int64{1, 2}).Return(nil)
+	repo.On("MarkPublished", ctx, "audit", []int64{3}).Return(nil)
+
+	n, err := relay.Relay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int64{1, 2, 3}, pub.published)
+	repo.AssertExpectations(t)
+}
+
+func TestEventRelay_RenewsLeaseDuringLongBatch(t *testing.T) {
+	repo := new(testutil.MockOutbox)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	relay := newTestRelay(t, repo, 10, now)
+	pub := &recordingPublisher{}
+	relay.Register("audit", pub)
+	// каждая публикация занимает треть аренды
+	relay.now = func() time.Time {
+		now = now.Add(relayLease / 3)
+		return now
+	}
+
+	ctx := context.Background()
+
+	repo.On("RegisterPublisher", ctx, "audit").Return(nil)
+	repo.On("ClaimPublisher", ctx, "audit", relay.owner, relayLease).Return(true, nil).Once()
+	repo.On("Unpublished", ctx, "audit", 10).Return(pending(
+		domain.Event{ID: 1, SubscriptionID: uuid.New()},
+		domain.Event{ID: 2, SubscriptionID: uuid.New()},
+	), nil)
+	// аренду перехватил другой экземпляр: событие 2 не публикуется
+	repo.On("ClaimPublisher", ctx, "audit", relay.owner, relayLease).Return(false, nil).Once()
+	repo.On("MarkPublished", ctx, "audit", []int64{1}).Return(nil)
+	repo.On("ReleasePublisher", mock.Anything, "audit", relay.owner).Return(nil)
+
+	n, err := relay.Relay(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{1}, pub.published)
+	repo.AssertExpectations(t)
+}
+
+func TestEventRelay_SkipsPublisherClaimedElsewhere(t *testing.T) {
+	repo := new(testutil.MockOutbox)
+	relay := newTestRelay(t, repo, 10, time.Now())
+	pub := &recordingPublisher{}
+	relay.Register("audit", pub)
+
+	ctx := context.Background()
+
+	expectClaim(repo, relay, false)
+
+	n, err := relay.Relay(ctx)
+
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Empty(t, pub.published)
+	repo.AssertNotCalled(t, "Unpublished", ctx, "audit", 10)
+	repo.AssertNotCalled(t, "ReleasePublisher", mock.Anything, "audit", relay.owner)
+}
+
+func TestEventRelay_MarkFailureReportsNothingPublished(t *testing.T) {
+	repo := new(testutil.MockOutbox)
+	relay := newTestRelay(t, repo, 10, time.Now())
+	relay.Register("audit", &recordingPublisher{})
+
+	ctx := context.Background()
+
+	expectClaim(repo, relay, true)
+	repo.On("Unpublished", ctx, "audit", 10).Return(pending(domain.Event{ID: 1, SubscriptionID: uuid.New()}), nil)
+	repo.On("MarkPublished", ctx, "audit", []int64{1}).Return(errors.New("connection reset"))
+
+	// событие не отмечено и будет опубликовано повторно
+	n, err := relay.Relay(ctx)
+
+	assert.Error(t, err)
+	assert.Zero(t, n)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ReportCache хранит готовые отчеты. В ключ значения входит версия области отчета:
+// запись подписки увеличивает версии затронутых областей, и прежние значения
+// перестают читаться без удаления по шаблону. Поэтому версии должны жить дольше
+// значений, а Bump в общем кеше - быть атомарным.
+type ReportCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Version(ctx context.Context, scope string) (uint64, error)
+	Bump(ctx context.Context, scope string) error
+}
+
+// CacheObserver учитывает попадания и промахи кеша по видам отчетов
+type CacheObserver interface {
+	ObserveCache(report string, hit bool)
+}
+
+// WithReportCache включает кеш отчетов о стоимости и прогноза. Тот же кеш передается
+// сервисам справочников, чтобы их изменения сбрасывали отчеты. ttl ограничивает жизнь
+// значения на случай изменений в обход сервисов. observer может быть nil.
+func WithReportCache(cache ReportCache, ttl time.Duration, observer CacheObserver) Option {
+	return func(s *SubscriptionService) {
+		s.cache = cache
+		s.cacheTTL = ttl
+		s.cacheObserver = observer
+	}
+}
+
+// allUsersScope - область отчетов без фильтра по пользователю; ее сбрасывает любая запись
+const allUsersScope = "all"
+
+// catalogScope - область справочников: категорий, тегов и каталога сервисов. Ее версия
+// входит в ключ каждого отчета: фильтры ссылаются на категории и теги по имени, поэтому
+// переименование меняет отчеты всех пользователей сразу.
+const catalogScope = "catalog"
+
+func userScope(id uuid.UUID) string {
+	return "user:" + id.String()
+}
+
+// reportScope - область, от которой зависит отчет: отчет по пользователю
+// меняется только вместе с его подписками
+func reportScope(filter domain.SubscriptionFilter) string {
+	if filter.UserID != nil {
+		return userScope(*filter.UserID)
+	}
+	return allUsersScope
+}
+
+// filterKey приводит фильтр к строке, одинаковой для фильтров с одним результатом:
+// название сервиса и категория сравниваются без учета регистра, теги - как множество
+func filterKey(filter domain.SubscriptionFilter) string {
+	v := url.Values{}
+	if filter.UserID != nil {
+		v.Set("user", filter.UserID.String())
+	}
+	if filter.ServiceName != nil {
+		v.Set("service", strings.ToLower(*filter.ServiceName))
+	}
+	if filter.ServiceID != nil {
+		v.Set("service_id", filter.ServiceID.String())
+	}
+	if filter.Category != nil {
+		v.Set("category", strings.ToLower(*filter.Category))
+	}
+	if filter.CategoryID != nil {
+		v.Set("category_id", filter.CategoryID.String())
+	}
+	if tags := domain.NormalizeTags(filter.Tags); len(tags) > 0 {
+		v["tag"] = tags
+		if filter.TagMatch == domain.TagMatchAll && len(tags) > 1 {
+			v.Set("tag_match", domain.TagMatchAll)
+		}
+	}
+	if filter.StartPeriod != nil {
+		v.Set("from", filter.StartPeriod.Format("2006-01-02"))
+	}
+	if filter.EndPeriod != nil {
+		v.Set("to", filter.EndPeriod.Format("2006-01-02"))
+	}
+	if filter.CostMode != "" && filter.CostMode != domain.CostModeWholeMonths {
+		v.Set("mode", string(filter.CostMode))
+	}
+	return v.Encode()
+}
+
+// cachedReport отдает отчет из кеша или строит его через load и сохраняет.
+// Сбой кеша не ломает отчет: он пишется в журнал, а отчет строится заново.
+func cachedReport[T any](ctx context.Context, s *SubscriptionService, report string, filter domain.SubscriptionFilter, extra string, load func() (T, error)) (T, error) {
+	if s.cache == nil {
+		return load()
+	}
+
+	scope := reportScope(filter)
+	version, err := s.cache.Version(ctx, scope)
+	if err != nil {
+		s.log(ctx).Warn("report cache unavailable", zap.String("report", report), zap.Error(err))
+		return load()
+	}
+	catalogVersion, err := s.cache.Version(ctx, catalogScope)
+	if err != nil {
+		s.log(ctx).Warn("report cache unavailable", zap.String("report", report), zap.Error(err))
+		return load()
+	}
+
+	key := fmt.Sprintf("%s|%s@%d|%s@%d|%s|%s", report, scope, version, catalogScope, catalogVersion, filterKey(filter), extra)
+
+	data, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.log(ctx).Warn("failed to read report cache", zap.String("report", report), zap.Error(err))
+	}
+	if ok {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			s.observeCache(report, true)
+			return value, nil
+		}
+	}
+	s.observeCache(report, false)
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	if data, err := json.Marshal(value); err == nil {
+		if err := s.cache.Set(ctx, key, data, s.cacheTTL); err != nil {
+			s.log(ctx).Warn("failed to write report cache", zap.String("report", report), zap.Error(err))
+		}
+	}
+	return value, nil
+}
+
+func (s *SubscriptionService) observeCache(report string, hit bool) {
+	if s.cacheObserver != nil {
+		s.cacheObserver.ObserveCache(report, hit)
+	}
+}
+
+// invalidateReports сбрасывает отчеты по всем пользователям и по владельцам
+// и участникам subs. Вызывается после записи: иначе отчет, построенный до
+// фиксации, попал бы в кеш уже под новой версией.
+func (s *SubscriptionService) invalidateReports(ctx context.Context, subs ...*domain.Subscription) {
+	if s.cache == nil {
+		return
+	}
+
+	scopes := []string{allUsersScope}
+	seen := map[string]bool{allUsersScope: true}
+	add := func(id uuid.UUID) {
+		if scope := userScope(id); !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	for _, sub := range subs {
+		if sub == nil {
+			continue
+		}
+		add(sub.UserID)
+		if sub.Split != nil {
+			for _, m := range sub.Split.Members {
+				add(m.UserID)
+			}
+		}
+	}
+
+	for _, scope := range scopes {
+		if err := s.cache.Bump(ctx, scope); err != nil {
+			s.log(ctx).Error("failed to invalidate report cache", zap.String("scope", scope), zap.Error(err))
+		}
+	}
+}
+
+// invalidateCatalogReports сбрасывает отчеты всех пользователей после изменения
+// справочника; без кеша ничего не делает
+func invalidateCatalogReports(ctx context.Context, cache ReportCache, logger *zap.Logger) {
+	if cache == nil {
+		return
+	}
+	if err := cache.Bump(ctx, catalogScope); err != nil {
+		applogger.FromContext(ctx, logger).Error("failed to invalidate report cache", zap.String("scope", catalogScope), zap.Error(err))
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/cache"
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type cacheCounts struct {
+	hits, misses int
+}
+
+func (c *cacheCounts) ObserveCache(_ string, hit bool) {
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+func costFilter(userID *uuid.UUID, service string, tags ...string) domain.SubscriptionFilter {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+	return domain.SubscriptionFilter{UserID: userID, ServiceName: &service, Tags: tags, StartPeriod: &from, EndPeriod: &to}
+}
+
+func TestSubscriptionService_TotalCost_Cached(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	counts := &cacheCounts{}
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithReportCache(cache.NewLRU(16), time.Minute, counts))
+	ctx := context.Background()
+
+	mockRepo.On("TotalCost", mock.Anything, mock.Anything).Return(1200, nil).Once()
+
+	first, err := service.TotalCost(ctx, costFilter(nil, "Netflix", "Video", "family"))
+	require.NoError(t, err)
+
+	// тот же фильтр в другой записи попадает в кеш
+	second, err := service.TotalCost(ctx, costFilter(nil, "netflix", "family", "video", "Family"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1200, first)
+	assert.Equal(t, 1200, second)
+	assert.Equal(t, cacheCounts{hits: 1, misses: 1}, *counts)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_Create_InvalidatesAffectedReports(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithReportCache(cache.NewLRU(16), time.Minute, nil))
+	ctx := context.Background()
+
+	owner := testutil.FixtureUserID()
+	other := uuid.MustParse("6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f")
+	ownerFilter := costFilter(&owner, "Netflix")
+	otherFilter := costFilter(&other, "Netflix")
+	allFilter := costFilter(nil, "Netflix")
+
+	mockRepo.On("TotalCost", mock.Anything, ownerFilter).Return(100, nil).Twice()
+	mockRepo.On("TotalCost", mock.Anything, otherFilter).Return(200, nil).Once()
+	mockRepo.On("TotalCost", mock.Anything, allFilter).Return(300, nil).Twice()
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	for _, filter := range []domain.SubscriptionFilter{ownerFilter, otherFilter, allFilter} {
+		_, err := service.TotalCost(ctx, filter)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, service.Create(ctx, testutil.FixtureSubscription(testutil.WithUserID(owner))))
+
+	// отчеты владельца и общий считаются заново, чужой остается в кеше
+	for _, filter := range []domain.SubscriptionFilter{ownerFilter, otherFilter, allFilter} {
+		_, err := service.TotalCost(ctx, filter)
+		require.NoError(t, err)
+	}
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestFilterKey_DistinguishesFilters(t *testing.T) {
+	user := testutil.FixtureUserID()
+	base := costFilter(&user, "Netflix", "video", "family")
+
+	all := base
+	all.TagMatch = domain.TagMatchAll
+	prorated := base
+	prorated.CostMode = domain.CostModeDailyProrated
+	whole := base
+	whole.CostMode = domain.CostModeWholeMonths
+
+	assert.NotEqual(t, filterKey(base), filterKey(all))
+	assert.NotEqual(t, filterKey(base), filterKey(prorated))
+	assert.Equal(t, filterKey(base), filterKey(whole))
+}
+
+func TestSubscriptionService_RemoveUser_InvalidatesSharedOwnerReports(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t), WithReportCache(cache.NewLRU(16), time.Minute, nil))
+	ctx := context.Background()
+
+	member := testutil.FixtureUserID()
+	owner := uuid.MustParse("6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f")
+	shared := testutil.FixtureSubscription(testutil.WithUserID(owner))
+	shared.Split = &domain.Split{Mode: domain.SplitEqual, Members: []domain.Member{{UserID: member}}}
+	ownerFilter := costFilter(&owner, "Netflix")
+
+	mockRepo.On("TotalCost", mock.Anything, ownerFilter).Return(100, nil).Twice()
+	mockRepo.On("List", mock.Anything, domain.SubscriptionFilter{UserID: &member}).Return([]domain.Subscription{}, nil)
+	mockRepo.On("SharedWith", mock.Anything, member).Return([]domain.Subscription{*shared}, nil)
+	mockRepo.On("GetByID", mock.Anything, shared.ID).Return(shared, nil)
+
+	_, err := service.TotalCost(ctx, ownerFilter)
+	require.NoError(t, err)
+
+	require.NoError(t, service.RemoveUser(ctx, member, func(context.Context) error { return nil }))
+
+	// владелец теперь платит и долю удаленного участника
+	_, err = service.TotalCost(ctx, ownerFilter)
+	require.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCategoryService_Update_InvalidatesAllReports(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	mockCategories := new(testutil.MockCategoryRepository)
+	reports := cache.NewLRU(16)
+	logger := zaptest.NewLogger(t)
+	subs := NewSubscriptionService(mockRepo, logger, WithReportCache(reports, time.Minute, nil))
+	categories := NewCategoryService(mockCategories, reports, logger)
+	ctx := context.Background()
+
+	owner := testutil.FixtureUserID()
+	video := "Video"
+	userFilter := costFilter(&owner, "Netflix")
+	userFilter.Category = &video
+
+	// отчет по имени категории после переименования строится заново
+	mockRepo.On("TotalCost", mock.Anything, userFilter).Return(100, nil).Twice()
+	category := &domain.Category{ID: uuid.New(), Name: "Video"}
+	mockCategories.On("Update", ctx, category).Return(nil)
+
+	_, err := subs.TotalCost(ctx, userFilter)
+	require.NoError(t, err)
+	require.NoError(t, categories.Update(ctx, category))
+	_, err = subs.TotalCost(ctx, userFilter)
+	require.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCatalogService_Delete_InvalidatesAllReports(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	mockCatalog := new(testutil.MockCatalogRepository)
+	reports := cache.NewLRU(16)
+	logger := zaptest.NewLogger(t)
+	subs := NewSubscriptionService(mockRepo, logger, WithReportCache(reports, time.Minute, nil))
+	catalog := NewCatalogService(mockCatalog, reports, logger)
+	ctx := context.Background()
+
+	serviceID := uuid.New()
+	filter := costFilter(nil, "Netflix")
+	filter.ServiceID = &serviceID
+
+	mockRepo.On("TotalCost", mock.Anything, filter).Return(300, nil).Twice()
+	mockCatalog.On("Delete", ctx, serviceID).Return(nil)
+
+	_, err := subs.TotalCost(ctx, filter)
+	require.NoError(t, err)
+	require.NoError(t, catalog.Delete(ctx, serviceID))
+	_, err = subs.TotalCost(ctx, filter)
+	require.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RetentionRepository удаляет обработанные события outbox и брошенных издателей
+type RetentionRepository interface {
+	PurgeEvents(ctx context.Context, before time.Time, requireDispatched bool) (int, error)
+	// PrunePublishers удаляет издателей, которых не брали в аренду с before
+	PrunePublishers(ctx context.Context, before time.Time) (int, error)
+}
+
+// OutboxRetention ограничивает рост outbox: удаляет события старше retention, которые
+// уже обработаны всеми потребителями. Работает отдельно от доставки webhook и
+// публикации, поэтому события удаляются, даже если какой-то из них отключен.
+type OutboxRetention struct {
+	repo      RetentionRepository
+	retention time.Duration
+	// webhooks - включена ли рассылка webhook; без нее dispatched_at не заполняется
+	webhooks bool
+	logger   *zap.Logger
+	now      func() time.Time
+}
+
+func NewOutboxRetention(repo RetentionRepository, retention time.Duration, webhooks bool, logger *zap.Logger) *OutboxRetention {
+	return &OutboxRetention{
+		repo:      repo,
+		retention: retention,
+		webhooks:  webhooks,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Purge удаляет обработанные события старше retention и возвращает их число. Сначала
+// удаляются издатели, которых ни один экземпляр не обрабатывал дольше retention: их убрали
+// из конфига, и иначе их неопубликованные события держали бы очистку вечно.
+func (r *OutboxRetention) Purge(ctx context.Context) (int, error) {
+	before := r.now().Add(-r.retention)
+
+	pruned, err := r.repo.PrunePublishers(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+	if pruned > 0 {
+		r.logger.Warn("stale publishers removed", zap.Int("publishers", pruned))
+	}
+
+	n, err := r.repo.PurgeEvents(ctx, before, r.webhooks)
+	if err != nil {
+		return 0, err
+	}
+
+	r.logger.Info("old events purged", zap.Int("events", n))
+	return n, nil
+}
+
+// Run раз в interval удаляет старые события
+func (r *OutboxRetention) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Purge(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Error("failed to purge events", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestOutboxRetention_Purge(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	for _, webhooks := range []bool{true, false} {
+		outbox := new(testutil.MockOutbox)
+		retention := NewOutboxRetention(outbox, 720*time.Hour, webhooks, zaptest.NewLogger(t))
+		retention.now = func() time.Time { return now }
+
+		outbox.On("PrunePublishers", context.Background(), now.Add(-720*time.Hour)).Return(0, nil).Once()
+		// без рассылки webhook dispatched_at не заполняется и не должен держать события
+		outbox.On("PurgeEvents", context.Background(), now.Add(-720*time.Hour), webhooks).Return(3, nil).Once()
+
+		n, err := retention.Purge(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+		outbox.AssertExpectations(t)
+	}
+}
+
+func TestOutboxRetention_Purge_PrunesStalePublishersFirst(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+	before := now.Add(-720 * time.Hour)
+
+	outbox := new(testutil.MockOutbox)
+	retention := NewOutboxRetention(outbox, 720*time.Hour, true, zaptest.NewLogger(t))
+	retention.now = func() time.Time { return now }
+
+	var calls []string
+	outbox.On("PrunePublishers", context.Background(), before).Return(1, nil).Once().
+		Run(func(mock.Arguments) { calls = append(calls, "prune") })
+	outbox.On("PurgeEvents", context.Background(), before, true).Return(4, nil).Once().
+		Run(func(mock.Arguments) { calls = append(calls, "purge") })
+
+	n, err := retention.Purge(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	// события брошенного издателя удаляются уже в этой очистке
+	assert.Equal(t, []string{"prune", "purge"}, calls)
+}
+
+func TestOutboxRetention_Purge_PruneError(t *testing.T) {
+	outbox := new(testutil.MockOutbox)
+	retention := NewOutboxRetention(outbox, time.Hour, false, zaptest.NewLogger(t))
+
+	outbox.On("PrunePublishers", context.Background(), mock.Anything).Return(0, errors.New("connection reset"))
+
+	_, err := retention.Purge(context.Background())
+
+	assert.Error(t, err)
+	outbox.AssertNotCalled(t, "PurgeEvents", mock.Anything, mock.Anything, mock.Anything)
+}
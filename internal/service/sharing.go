@@ -0,0 +1,69 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidSplit - правило деления стоимости подписки не прошло проверку
+var ErrInvalidSplit = errors.New("invalid split")
+
+// prepareSplit проверяет участников совместной подписки и считает их доли от цены.
+// Split без участников оставляется как есть: при изменении подписки он убирает деление.
+func prepareSplit(sub *domain.Subscription) error {
+	split := sub.Split
+	if !split.Shared() {
+		return nil
+	}
+
+	switch split.Mode {
+	case domain.SplitEqual, domain.SplitPercentage, domain.SplitFixed:
+	default:
+		return fmt.Errorf("%w: mode must be %s, %s or %s", ErrInvalidSplit, domain.SplitEqual, domain.SplitPercentage, domain.SplitFixed)
+	}
+
+	seen := map[uuid.UUID]bool{}
+	total := 0
+	for i := range split.Members {
+		m := &split.Members[i]
+
+		switch {
+		case m.UserID == uuid.Nil:
+			return fmt.Errorf("%w: member user_id is required", ErrInvalidSplit)
+		case m.UserID == sub.UserID:
+			return fmt.Errorf("%w: owner pays the remainder and cannot be a member", ErrInvalidSplit)
+		case seen[m.UserID]:
+			return fmt.Errorf("%w: duplicate member %s", ErrInvalidSplit, m.UserID)
+		}
+		seen[m.UserID] = true
+
+		if split.Mode == domain.SplitEqual {
+			m.Share = 0
+			continue
+		}
+		if m.Share <= 0 {
+			return fmt.Errorf("%w: share must be positive", ErrInvalidSplit)
+		}
+		total += m.Share
+	}
+
+	if split.Mode == domain.SplitPercentage && total > 100 {
+		return fmt.Errorf("%w: shares exceed 100 percent", ErrInvalidSplit)
+	}
+	if split.Mode == domain.SplitFixed && total > sub.Price {
+		return fmt.Errorf("%w: shares exceed the price", ErrInvalidSplit)
+	}
+
+	// в том же порядке участники читаются из базы
+	sort.Slice(split.Members, func(i, j int) bool {
+		return bytes.Compare(split.Members[i].UserID[:], split.Members[j].UserID[:]) < 0
+	})
+	split.Apply(sub.Price)
+
+	return nil
+}
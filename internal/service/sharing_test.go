@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestPrepareSplit_Shares(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		mode    string
+		shares  []int
+		amounts []int
+		owner   int
+	}{
+		// остаток от деления 1000 на троих достается владельцу
+		{"equal", domain.SplitEqual, []int{0, 0}, []int{333, 333}, 334},
+		{"percentage", domain.SplitPercentage, []int{30, 25}, []int{300, 250}, 450},
+		{"fixed", domain.SplitFixed, []int{100, 400}, []int{100, 400}, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := testutil.FixtureSubscription(testutil.WithPrice(1000))
+			sub.Split = &domain.Split{Mode: tt.mode, Members: []domain.Member{
+				{UserID: a, Share: tt.shares[0]},
+				{UserID: b, Share: tt.shares[1]},
+			}}
+
+			require.NoError(t, prepareSplit(sub))
+
+			assert.Equal(t, tt.amounts[0], sub.ShareOf(a))
+			assert.Equal(t, tt.amounts[1], sub.ShareOf(b))
+			assert.Equal(t, tt.owner, sub.ShareOf(sub.UserID))
+			assert.Equal(t, 1000, sub.ShareOf(sub.UserID)+sub.ShareOf(a)+sub.ShareOf(b))
+			assert.Zero(t, sub.ShareOf(uuid.New()))
+		})
+	}
+}
+
+func TestPrepareSplit_Invalid(t *testing.T) {
+	owner := testutil.FixtureUserID()
+	member := uuid.New()
+
+	tests := []struct {
+		name  string
+		split domain.Split
+	}{
+		{"unknown mode", domain.Split{Mode: "weighted", Members: []domain.Member{{UserID: member}}}},
+		{"owner as member", domain.Split{Mode: domain.SplitEqual, Members: []domain.Member{{UserID: owner}}}},
+		{"duplicate member", domain.Split{Mode: domain.SplitEqual, Members: []domain.Member{{UserID: member}, {UserID: member}}}},
+		{"percent over 100", domain.Split{Mode: domain.SplitPercentage, Members: []domain.Member{{UserID: member, Share: 101}}}},
+		{"fixed over price", domain.Split{Mode: domain.SplitFixed, Members: []domain.Member{{UserID: member, Share: 501}}}},
+		{"zero share", domain.Split{Mode: domain.SplitFixed, Members: []domain.Member{{UserID: member}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := testutil.FixtureSubscription(testutil.WithUserID(owner), testutil.WithPrice(500))
+			sub.Split = &tt.split
+
+			assert.ErrorIs(t, prepareSplit(sub), ErrInvalidSplit)
+		})
+	}
+}
+
+func TestSubscriptionService_Update_RecalculatesKeptSplit(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	member := uuid.New()
+	existing := testutil.FixtureSubscription(testutil.WithPrice(400))
+	existing.Split = &domain.Split{
+		Mode:    domain.SplitPercentage,
+		Members: []domain.Member{{UserID: member, Share: 50, Amount: 200}},
+	}
+
+	updated := testutil.FixtureSubscription(testutil.WithPrice(600))
+	updated.ID = existing.ID
+
+	mockRepo.On("GetByID", ctx, existing.ID).Return(existing, nil)
+	mockRepo.On("Update", ctx, mock.AnythingOfType("*domain.Subscription")).Return(nil)
+
+	require.NoError(t, service.Update(ctx, updated))
+
+	assert.Equal(t, existing.UserID, updated.UserID)
+	require.True(t, updated.Split.Shared())
+	assert.Equal(t, 300, updated.Split.Members[0].Amount)
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// streamReconnectDelay - пауза перед повторной подпиской на уведомления после обрыва
+	streamReconnectDelay = 2 * time.Second
+	// streamCatchUpWindow - на сколько id ниже последнего полученного дочитываются события
+	// после обрыва: id выдаются при записи, а видны после коммита, поэтому транзакция
+	// с меньшим id может закоммититься позже события с большим
+	streamCatchUpWindow int64 = 1000
+)
+
+// StreamEventTypes - события, которые отдает поток изменений подписок и бюджетов
+var StreamEventTypes = []domain.EventType{
+	domain.EventSubscriptionCreated,
+	domain.EventSubscriptionUpdated,
+	domain.EventSubscriptionDeleted,
+	domain.EventBudgetThresholdCrossed,
+}
+
+// StreamRepository читает события outbox для истории потока
+type StreamRepository interface {
+	RecentEvents(ctx context.Context, types []domain.EventType, limit int) ([]domain.Event, error)
+	EventsAfter(ctx context.Context, afterID int64, types []domain.EventType, limit int) ([]domain.Event, error)
+	EventByID(ctx context.Context, id int64) (*domain.Event, error)
+}
+
+// EventNotifier сообщает о событиях outbox, записанных любым экземпляром сервиса
+type EventNotifier interface {
+	// Listen вызывает ready после подписки на уведомления, затем notify с id каждого события.
+	// Возвращается при обрыве или ошибке колбэка; уведомления за время обрыва теряются.
+	Listen(ctx context.Context, ready func(ctx context.Context) error, notify func(ctx context.Context, id int64) error) error
+}
+
+// StreamFilter отбирает события потока: UserID - владелец подписки, ServiceName - подстрока
+// названия сервиса без учета регистра, как в фильтре списка подписок
+type StreamFilter struct {
+	UserID      *uuid.UUID
+	ServiceName *string
+}
+
+type streamEvent struct {
+	event       domain.Event
+	userID      uuid.UUID
+	serviceName string
+}
+
+func (f StreamFilter) match(e streamEvent) bool {
+	if f.UserID != nil && *f.UserID != e.userID {
+		return false
+	}
+	if f.ServiceName != nil && !strings.Contains(strings.ToLower(e.serviceName), strings.ToLower(*f.ServiceName)) {
+		return false
+	}
+	return true
+}
+
+type streamSubscriber struct {
+	filter StreamFilter
+	events chan domain.Event
+	closed bool
+}
+
+// StreamService раздает события о подписках открытым потокам и хранит последние
+// history событий, чтобы переподключившийся клиент дочитал пропущенное. События
+// приходят через EventNotifier, поэтому поток видит изменения любого экземпляра сервиса.
+type StreamService struct {
+	repo     StreamRepository
+	notifier EventNotifier
+	size     int
+	buffer   int
+	logger   *zap.Logger
+	// reconnectDelay - пауза перед повторной подпиской; в тестах 0
+	reconnectDelay time.Duration
+
+	mu      sync.Mutex
+	history []streamEvent
+	// seen - полученные события с id не ниже lastID-streamCatchUpWindow, в том числе
+	// вытесненные из истории: по ним отбрасываются повторы при дочитывании
+	seen        map[int64]bool
+	lastID      int64
+	subscribers map[*streamSubscriber]struct{}
+	closed      bool
+}
+
+// NewStreamService хранит history последних событий; buffer - сколько событий может
+// ждать отправки клиенту, после этого медленный клиент отключается
+func NewStreamService(repo StreamRepository, notifier EventNotifier, history, buffer int, logger *zap.Logger) *StreamService {
+	return &StreamService{
+		repo:           repo,
+		notifier:       notifier,
+		size:           max(history, 1),
+		buffer:         max(buffer, 1),
+		logger:         logger,
+		reconnectDelay: streamReconnectDelay,
+		seen:           make(map[int64]bool),
+		subscribers:    make(map[*streamSubscriber]struct{}),
+	}
+}
+
+// Subscribe открывает поток событий. С lastEventID сначала возвращаются события из
+// истории, пришедшие после него. Канал закрывается при остановке сервиса или если клиент
+// не успевает читать; cancel закрывает поток.
+func (s *StreamService) Subscribe(filter StreamFilter, lastEventID *int64) (replay []domain.Event, events <-chan domain.Event, cancel func()) {
+	sub := &streamSubscriber{filter: filter, events: make(chan domain.Event, s.buffer)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lastEventID != nil {
+		replay = s.replay(filter, *lastEventID)
+	}
+
+	if s.closed {
+		sub.closed = true
+		close(sub.events)
+	} else {
+		s.subscribers[sub] = struct{}{}
+	}
+
+	return replay, sub.events, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.drop(sub)
+	}
+}
+
+// replay - события истории после lastID. История идет в порядке получения, поэтому
+// если lastID в ней есть, берется все после него; иначе - события с большим id.
+func (s *StreamService) replay(filter StreamFilter, lastID int64) []domain.Event {
+	start := 0
+	found := false
+	for i, e := range s.history {
+		if e.event.ID == lastID {
+			start, found = i+1, true
+			break
+		}
+	}
+
+	replay := []domain.Event{}
+	for _, e := range s.history[start:] {
+		if (found || e.event.ID > lastID) && filter.match(e) {
+			replay = append(replay, e.event)
+		}
+	}
+	return replay
+}
+
+// drop отключает подписчика; вызывается под s.mu
+func (s *StreamService) drop(sub *streamSubscriber) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(s.subscribers, sub)
+	close(sub.events)
+}
+
+// add добавляет событие в историю и рассылает его; повторно полученное событие пропускается
+func (s *StreamService) add(event domain.Event) {
+	var data struct {
+		UserID      uuid.UUID `json:"user_id"`
+		ServiceName string    `json:"service_name"`
+	}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		s.logger.Warn("failed to decode event", zap.Int64("event_id", event.ID), zap.Error(err))
+	}
+	e := streamEvent{event: event, userID: data.UserID, serviceName: data.ServiceName}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.seen[event.ID] {
+		return
+	}
+	s.seen[event.ID] = true
+	s.lastID = max(s.lastID, event.ID)
+	s.forget()
+
+	if len(s.history) == s.size {
+		s.history = append(s.history[:0], s.history[1:]...)
+	}
+	s.history = append(s.history, e)
+
+	for sub := range s.subscribers {
+		if !sub.filter.match(e) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// клиент переподключится с Last-Event-ID и дочитает из истории
+			s.logger.Warn("stream subscriber is too slow, disconnecting")
+			s.drop(sub)
+		}
+	}
+}
+
+// forget удаляет из seen события ниже окна дочитывания; вызывается под s.mu
+func (s *StreamService) forget() {
+	if int64(len(s.seen)) <= 2*streamCatchUpWindow {
+		return
+	}
+	floor := s.lastID - streamCatchUpWindow
+	for id := range s.seen {
+		if id < floor {
+			delete(s.seen, id)
+		}
+	}
+}
+
+// catchUp заполняет историю при первой подписке и дочитывает события, пропущенные
+// за время обрыва соединения. Дочитывание начинается на streamCatchUpWindow id ниже
+// последнего полученного, чтобы не потерять транзакции, закоммиченные не по порядку id;
+// уже полученные события отбрасываются по seen.
+func (s *StreamService) catchUp(ctx context.Context) error {
+	s.mu.Lock()
+	lastID := s.lastID
+	s.mu.Unlock()
+
+	if lastID == 0 {
+		events, err := s.repo.RecentEvents(ctx, StreamEventTypes, s.size)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			s.add(e)
+		}
+		return nil
+	}
+
+	after := max(lastID-streamCatchUpWindow, 0)
+	for {
+		events, err := s.repo.EventsAfter(ctx, after, StreamEventTypes, s.size)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			s.add(e)
+			after = e.ID
+		}
+		if len(events) < s.size {
+			return nil
+		}
+	}
+}
+
+func (s *StreamService) load(ctx context.Context, id int64) error {
+	event, err := s.repo.EventByID(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		// событие уже удалено по сроку хранения
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(StreamEventTypes, event.Type) {
+		s.add(*event)
+	}
+	return nil
+}
+
+// Run слушает уведомления о новых событиях до отмены ctx, переподключаясь после обрывов
+func (s *StreamService) Run(ctx context.Context) {
+	for {
+		err := s.notifier.Listen(ctx, s.catchUp, s.load)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Warn("event stream disconnected, reconnecting", zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.reconnectDelay):
+		}
+	}
+}
+
+// Close закрывает все потоки; нужен при остановке сервера, иначе открытые потоки
+// не дадут http.Server.Shutdown завершиться
+func (s *StreamService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for sub := range s.subscribers {
+		s.drop(sub)
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	streamAlice = uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	streamBob   = uuid.MustParse("0b0e6f5c-9d43-4a4e-b1a0-3c2f6a2f1e11")
+)
+
+func streamTestEvent(id int64, eventType domain.EventType, userID uuid.UUID, service string) domain.Event {
+	data, _ := json.Marshal(domain.Subscription{UserID: userID, ServiceName: service})
+	return domain.Event{ID: id, Type: eventType, SubscriptionID: uuid.New(), Data: data}
+}
+
+func eventIDs(events []domain.Event) []int64 {
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// listenerFunc - EventNotifier для тестов: вызывает ready и отдает notify в тест
+type listenerFunc func(ctx context.Context, ready func(ctx context.Context) error, notify func(ctx context.Context, id int64) error) error
+
+func (f listenerFunc) Listen(ctx context.Context, ready func(ctx context.Context) error, notify func(ctx context.Context, id int64) error) error {
+	return f(ctx, ready, notify)
+}
+
+func TestStreamService_ReplaysAfterLastEventID(t *testing.T) {
+	service := NewStreamService(nil, nil, 10, 10, zaptest.NewLogger(t))
+	service.add(streamTestEvent(1, domain.EventSubscriptionCreated, streamAlice, "Yandex Plus"))
+	service.add(streamTestEvent(2, domain.EventSubscriptionCreated, streamBob, "Netflix"))
+	service.add(streamTestEvent(3, domain.EventSubscriptionUpdated, streamAlice, "Yandex Plus"))
+
+	last := int64(1)
+	replay, _, cancel := service.Subscribe(StreamFilter{UserID: &streamAlice}, &last)
+	defer cancel()
+
+	assert.Equal(t, []int64{3}, eventIDs(replay))
+
+	// без Last-Event-ID история не отдается
+	replay, _, cancel2 := service.Subscribe(StreamFilter{}, nil)
+	defer cancel2()
+	assert.Empty(t, replay)
+}
+
+func TestStreamService_ReplayKeepsArrivalOrder(t *testing.T) {
+	service := NewStreamService(nil, nil, 10, 10, zaptest.NewLogger(t))
+	// событие 5 зафиксировано позже события 6
+	service.add(streamTestEvent(6, domain.EventSubscriptionCreated, streamAlice, "Netflix"))
+	service.add(streamTestEvent(5, domain.EventSubscriptionCreated, streamAlice, "Spotify"))
+	service.add(streamTestEvent(7, domain.EventSubscriptionCreated, streamAlice, "Okko"))
+
+	last := int64(6)
+	replay, _, cancel := service.Subscribe(StreamFilter{}, &last)
+	defer cancel()
+
+	assert.Equal(t, []int64{5, 7}, eventIDs(replay))
+}
+
+func TestStreamService_DeliversMatchingEvents(t *testing.T) {
+	service := NewStreamService(nil, nil, 10, 10, zaptest.NewLogger(t))
+	name := "yandex"
+
+	_, events, cancel := service.Subscribe(StreamFilter{ServiceName: &name}, nil)
+	defer cancel()
+
+	service.add(streamTestEvent(1, domain.EventSubscriptionCreated, streamAlice, "Netflix"))
+	service.add(streamTestEvent(2, domain.EventSubscriptionDeleted, streamBob, "Yandex Plus"))
+	// повторное уведомление о том же событии
+	service.add(streamTestEvent(2, domain.EventSubscriptionDeleted, streamBob, "Yandex Plus"))
+
+	require.Len(t, events, 1)
+	assert.Equal(t, int64(2), (<-events).ID)
+}
+
+func TestStreamService_DisconnectsSlowSubscriber(t *testing.T) {
+	service := NewStreamService(nil, nil, 10, 1, zaptest.NewLogger(t))
+
+	_, events, cancel := service.Subscribe(StreamFilter{}, nil)
+	defer cancel()
+
+	service.add(streamTestEvent(1, domain.EventSubscriptionCreated, streamAlice, "Netflix"))
+	service.add(streamTestEvent(2, domain.EventSubscriptionCreated, streamAlice, "Okko"))
+
+	assert.Equal(t, int64(1), (<-events).ID)
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestStreamService_HistoryIsBounded(t *testing.T) {
+	service := NewStreamService(nil, nil, 2, 10, zaptest.NewLogger(t))
+	for id := int64(1); id <= 3; id++ {
+		service.add(streamTestEvent(id, domain.EventSubscriptionCreated, streamAlice, "Netflix"))
+	}
+
+	last := int64(0)
+	replay, _, cancel := service.Subscribe(StreamFilter{}, &last)
+	defer cancel()
+
+	assert.Equal(t, []int64{2, 3}, eventIDs(replay))
+}
+
+func TestStreamService_Run_CatchesUpAfterReconnect(t *testing.T) {
+	repo := new(testutil.MockOutbox)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	connects := 0
+	notifier := listenerFunc(func(ctx context.Context, ready func(ctx context.Context) error, notify func(ctx context.Context, id int64) error) error {
+		connects++
+		if err := ready(ctx); err != nil {
+			return err
+		}
+		if connects == 1 {
+			require.NoError(t, notify(ctx, 3))
+			require.NoError(t, notify(ctx, 4))
+			return assert.AnError
+		}
+		cancel()
+		return ctx.Err()
+	})
+
+	service := NewStreamService(repo, notifier, 10, 10, zaptest.NewLogger(t))
+	service.reconnectDelay = 0
+	ending := streamTestEvent(4, domain.EventSubscriptionEndingSoon, streamAlice, "Netflix")
+	created := streamTestEvent(1, domain.EventSubscriptionCreated, streamAlice, "Netflix")
+	updated := domain.Event{ID: 3, Type: domain.EventSubscriptionUpdated, Data: json.RawMessage(`{}`)}
+
+	repo.On("RecentEvents", ctx, StreamEventTypes, 10).Return([]domain.Event{created}, nil)
+	repo.On("EventByID", ctx, int64(3)).Return(&updated, nil)
+	repo.On("EventByID", ctx, int64(4)).Return(&ending, nil)
+	// событие 2 закоммичено после 3: дочитывание начинается ниже последнего id
+	repo.On("EventsAfter", ctx, int64(0), StreamEventTypes, 10).
+		Return([]domain.Event{
+			created,
+			streamTestEvent(2, domain.EventSubscriptionCreated, streamBob, "Spotify"),
+			updated,
+			streamTestEvent(5, domain.EventSubscriptionDeleted, streamAlice, "Netflix"),
+		}, nil)
+
+	service.Run(ctx)
+
+	last := int64(0)
+	replay, _, stop := service.Subscribe(StreamFilter{}, &last)
+	defer stop()
+
+	// subscription.ending_soon в поток не попадает, повторно полученные события отброшены
+	assert.Equal(t, []int64{1, 3, 2, 5}, eventIDs(replay))
+	assert.Equal(t, 2, connects)
+	repo.AssertExpectations(t)
+}
+
+func TestStreamService_SkipsEventsEvictedFromHistory(t *testing.T) {
+	service := NewStreamService(nil, nil, 2, 10, zaptest.NewLogger(t))
+	for id := int64(1); id <= 3; id++ {
+		service.add(streamTestEvent(id, domain.EventSubscriptionCreated, streamAlice, "Netflix"))
+	}
+
+	_, events, cancel := service.Subscribe(StreamFilter{}, nil)
+	defer cancel()
+
+	// событие 1 уже вытеснено из истории, но при дочитывании после обрыва придет снова
+	service.add(streamTestEvent(1, domain.EventSubscriptionCreated, streamAlice, "Netflix"))
+
+	last := int64(0)
+	replay, _, stop := service.Subscribe(StreamFilter{}, &last)
+	defer stop()
+
+	assert.Equal(t, []int64{2, 3}, eventIDs(replay))
+	assert.Empty(t, events)
+}
+
+func TestStreamService_Close(t *testing.T) {
+	service := NewStreamService(nil, nil, 10, 10, zaptest.NewLogger(t))
+
+	_, events, cancel := service.Subscribe(StreamFilter{}, nil)
+	defer cancel()
+
+	service.Close()
+
+	_, ok := <-events
+	assert.False(t, ok)
+
+	_, events, _ = service.Subscribe(StreamFilter{}, nil)
+	_, ok = <-events
+	assert.False(t, ok)
+}
@@ -2,35 +2,139 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/SoulStalker/subscribes_api/internal/domain"
+	applogger "github.com/SoulStalker/subscribes_api/internal/logger"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
 type SubscriptionRepository interface {
 	Create(ctx context.Context, sub *domain.Subscription) error
+	CreateBatch(ctx context.Context, subs []*domain.Subscription) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
 	List(ctx context.Context, filter domain.SubscriptionFilter) ([]domain.Subscription, error)
+	Stream(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.Subscription) error) error
 	Update(ctx context.Context, sub *domain.Subscription) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	SetTags(ctx context.Context, id uuid.UUID, tags []string) error
 	TotalCost(ctx context.Context, filter domain.SubscriptionFilter) (int, error)
+	TotalCostByGroup(ctx context.Context, filter domain.SubscriptionFilter, groupBy string) ([]domain.CostGroup, error)
+	StreamCostBreakdown(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.CostLine) error) error
+	StreamCharges(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.Subscription) error) error
+	Stats(ctx context.Context, on time.Time) (domain.SubscriptionStats, error)
+	EndingBetween(ctx context.Context, from, to time.Time) ([]domain.Subscription, error)
+	SharedWith(ctx context.Context, userID uuid.UUID) ([]domain.Subscription, error)
+	// LockUser блокирует запись подписок пользователя до конца транзакции ctx
+	LockUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// ErrUnknownService - в подписке указан service_id, которого нет в каталоге
+var ErrUnknownService = errors.New("service not found in catalog")
+
+// ServiceResolver сопоставляет подписку с сервисом каталога
+type ServiceResolver interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.CatalogEntry, error)
+	Resolve(ctx context.Context, name string) (*domain.CatalogEntry, error)
 }
 
 type SubscriptionService struct {
-	repo   SubscriptionRepository
-	logger *zap.Logger
+	repo    SubscriptionRepository
+	catalog ServiceResolver
+	logger  *zap.Logger
+	now     func() time.Time
+
+	cache         ReportCache
+	cacheTTL      time.Duration
+	cacheObserver CacheObserver
+	outbox        Outbox
+
+	strictDuplicates bool
+	users            UserLookup
+}
+
+// UserLookup проверяет, что пользователь подписки существует
+type UserLookup interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 }
 
-func NewSubscriptionService(repo SubscriptionRepository, logger *zap.Logger) *SubscriptionService {
-	return &SubscriptionService{
+// WithUsers включает проверку пользователей по строкам импорта: без нее строка
+// с неизвестным user_id отклоняет весь импорт ошибкой внешнего ключа
+func WithUsers(users UserLookup) Option {
+	return func(s *SubscriptionService) {
+		s.users = users
+	}
+}
+
+// Option настраивает необязательные зависимости SubscriptionService
+type Option func(*SubscriptionService)
+
+// WithCatalog включает привязку подписок к каталогу сервисов при создании и изменении
+func WithCatalog(catalog ServiceResolver) Option {
+	return func(s *SubscriptionService) {
+		s.catalog = catalog
+	}
+}
+
+func NewSubscriptionService(repo SubscriptionRepository, logger *zap.Logger, opts ...Option) *SubscriptionService {
+	s := &SubscriptionService{
 		repo:   repo,
 		logger: logger,
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
+}
+
+// log - логгер текущего запроса, а вне запроса - логгер сервиса
+func (s *SubscriptionService) log(ctx context.Context) *zap.Logger {
+	return applogger.FromContext(ctx, s.logger)
 }
 
 func (s *SubscriptionService) Create(ctx context.Context, sub *domain.Subscription) error {
+	ctx, span := startSpan(ctx, "SubscriptionService.Create")
+	defer span.End()
+
+	if err := s.validate(sub); err != nil {
+		return err
+	}
+
+	if err := s.resolveService(ctx, sub); err != nil {
+		return err
+	}
+
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		if err := s.checkDuplicate(ctx, sub); err != nil {
+			return err
+		}
+		if err := s.repo.Create(ctx, sub); err != nil {
+			return err
+		}
+		return s.emit(ctx, domain.EventSubscriptionCreated, sub)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateReports(ctx, sub)
+	return nil
+}
+
+// validate - правила, общие для создания и импорта подписок; заодно нормализует теги
+func (s *SubscriptionService) validate(sub *domain.Subscription) error {
+	tags, err := prepareTags(sub.Tags)
+	if err != nil {
+		return err
+	}
+	sub.Tags = tags
+
 	if sub.Price < 0 {
 		return fmt.Errorf("price cannot be negative")
 	}
@@ -39,35 +143,288 @@ func (s *SubscriptionService) Create(ctx context.Context, sub *domain.Subscripti
 		return fmt.Errorf("end_date must be after start_date")
 	}
 
-	return s.repo.Create(ctx, sub)
+	if err := prepareBilling(sub); err != nil {
+		return err
+	}
+
+	return prepareSplit(sub)
+}
+
+// resolveService привязывает подписку к каталогу: явный service_id проверяется,
+// свободное название ищется среди синонимов. Найденный сервис задает
+// каноническое название, без совпадения подписка остается с названием как есть.
+func (s *SubscriptionService) resolveService(ctx context.Context, sub *domain.Subscription) error {
+	if s.catalog == nil {
+		return nil
+	}
+
+	var (
+		entry *domain.CatalogEntry
+		err   error
+	)
+	if sub.ServiceID != nil {
+		entry, err = s.catalog.GetByID(ctx, *sub.ServiceID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrUnknownService
+		}
+	} else {
+		entry, err = s.catalog.Resolve(ctx, sub.ServiceName)
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("resolve service: %w", err)
+	}
+
+	sub.ServiceID = &entry.ID
+	sub.ServiceName = entry.Name
+	return nil
 }
 
 func (s *SubscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.GetByID")
+	defer span.End()
+
 	return s.repo.GetByID(ctx, id)
 }
 
 func (s *SubscriptionService) List(ctx context.Context, filter domain.SubscriptionFilter) ([]domain.Subscription, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.List")
+	defer span.End()
+
 	return s.repo.List(ctx, filter)
 }
 
 func (s *SubscriptionService) Update(ctx context.Context, sub *domain.Subscription) error {
+	ctx, span := startSpan(ctx, "SubscriptionService.Update")
+	defer span.End()
+
 	existing, err := s.repo.GetByID(ctx, sub.ID)
 	if err != nil {
 		return fmt.Errorf("subscription not found: %w", err)
 	}
 
+	if err := s.resolveService(ctx, sub); err != nil {
+		return err
+	}
+
+	if sub.Tags, err = prepareTags(sub.Tags); err != nil {
+		return err
+	}
+
+	// владелец не меняется; без переданного деления сохраняется прежнее,
+	// а доли участников пересчитываются от новой цены
+	sub.UserID = existing.UserID
+	if sub.Split == nil {
+		sub.Split = existing.Split
+	}
+	if err := prepareSplit(sub); err != nil {
+		return err
+	}
+
+	// период оплаты и изменения цены без переданных значений тоже сохраняются
+	if sub.BillingPeriod == "" {
+		sub.BillingPeriod = existing.BillingPeriod
+	}
+	if err := prepareBilling(sub); err != nil {
+		return err
+	}
+
 	sub.CreatedAt = existing.CreatedAt
-	return s.repo.Update(ctx, sub)
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		if err := s.checkDuplicate(ctx, sub); err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+
+		// без переданных тегов остаются прежние
+		if sub.Tags == nil {
+			sub.Tags = existing.Tags
+		}
+		if !sub.Split.Shared() {
+			sub.Split = nil
+		}
+		if sub.PriceChanges == nil {
+			sub.PriceChanges = existing.PriceChanges
+		}
+		return s.emit(ctx, domain.EventSubscriptionUpdated, sub)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateReports(ctx, existing, sub)
+	return nil
+}
+
+// SetTags заменяет теги подписки и возвращает их в нормализованном виде
+func (s *SubscriptionService) SetTags(ctx context.Context, id uuid.UUID, tags []string) ([]string, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.SetTags")
+	defer span.End()
+
+	tags, err := prepareTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+
+	var updated *domain.Subscription
+	err = s.inTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.SetTags(ctx, id, tags); err != nil {
+			return err
+		}
+
+		var err error
+		if updated, err = s.touchedSubscription(ctx, id); err != nil {
+			return err
+		}
+		return s.emit(ctx, domain.EventSubscriptionUpdated, updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateReports(ctx, updated)
+
+	return tags, nil
 }
 
 func (s *SubscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+	ctx, span := startSpan(ctx, "SubscriptionService.Delete")
+	defer span.End()
+
+	var existing *domain.Subscription
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		var err error
+		if existing, err = s.touchedSubscription(ctx, id); err != nil {
+			return err
+		}
+
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.emit(ctx, domain.EventSubscriptionDeleted, existing)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateReports(ctx, existing)
+	return nil
 }
 
+// RemoveUser вызывает remove, удаляющий пользователя userID, в одной транзакции с событиями
+// о его подписках: база удаляет их каскадом, поэтому subscription.deleted записывается
+// здесь. Подписки, где он участник, теряют его долю - по ним пишется subscription.updated.
+// Отчеты всех затронутых пользователей сбрасываются.
+func (s *SubscriptionService) RemoveUser(ctx context.Context, userID uuid.UUID, remove func(ctx context.Context) error) error {
+	ctx, span := startSpan(ctx, "SubscriptionService.RemoveUser")
+	defer span.End()
+
+	var touched []*domain.Subscription
+	err := s.inTx(ctx, func(ctx context.Context) error {
+		if s.cache == nil && s.outbox == nil {
+			return remove(ctx)
+		}
+
+		owned, err := s.repo.List(ctx, domain.SubscriptionFilter{UserID: &userID})
+		if err != nil {
+			return err
+		}
+		shared, err := s.repo.SharedWith(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := remove(ctx); err != nil {
+			return err
+		}
+
+		deleted := make([]*domain.Subscription, len(owned))
+		for i := range owned {
+			deleted[i] = &owned[i]
+		}
+		if err := s.emit(ctx, domain.EventSubscriptionDeleted, deleted...); err != nil {
+			return err
+		}
+
+		updated := make([]*domain.Subscription, 0, len(shared))
+		for i := range shared {
+			// до удаления в делении есть и удаленный участник: его отчеты тоже сбрасываются
+			touched = append(touched, &shared[i])
+			sub, err := s.repo.GetByID(ctx, shared[i].ID)
+			if err != nil {
+				return err
+			}
+			updated = append(updated, sub)
+		}
+		touched = append(touched, deleted...)
+		return s.emit(ctx, domain.EventSubscriptionUpdated, updated...)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateReports(ctx, touched...)
+	return nil
+}
+
+// TotalCost считает стоимость подписок за период в режиме filter.CostMode.
+// Целые месяцы считаются в базе, остальные режимы - по строкам отчета.
 func (s *SubscriptionService) TotalCost(ctx context.Context, filter domain.SubscriptionFilter) (int, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.TotalCost")
+	defer span.End()
+
 	if filter.StartPeriod == nil || filter.EndPeriod == nil {
 		return 0, fmt.Errorf("start_period and end_period are required")
 	}
 
-	return s.repo.TotalCost(ctx, filter)
+	return cachedReport(ctx, s, "total_cost", filter, "", func() (int, error) {
+		if filter.CostMode == "" || filter.CostMode == domain.CostModeWholeMonths {
+			return s.repo.TotalCost(ctx, filter)
+		}
+
+		total := 0
+		err := s.costLines(ctx, filter, func(line *domain.CostLine) error {
+			total += line.Cost
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		return total, nil
+	})
+}
+
+// Stats - действующие подписки и их месячная цена на сегодня (UTC) для метрик
+func (s *SubscriptionService) Stats(ctx context.Context) (domain.SubscriptionStats, error) {
+	return s.repo.Stats(ctx, localDate(s.now(), time.UTC))
+}
+
+// TotalCostByGroup раскладывает стоимость за период по категориям или тегам.
+// Группы считаются в базе, поэтому доступны только в режиме whole_months.
+func (s *SubscriptionService) TotalCostByGroup(ctx context.Context, filter domain.SubscriptionFilter, groupBy string) ([]domain.CostGroup, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.TotalCostByGroup")
+	defer span.End()
+
+	if filter.StartPeriod == nil || filter.EndPeriod == nil {
+		return nil, fmt.Errorf("start_period and end_period are required")
+	}
+
+	if filter.CostMode != "" && filter.CostMode != domain.CostModeWholeMonths {
+		return nil, fmt.Errorf("%w: group_by supports only %s", ErrInvalidCostMode, domain.CostModeWholeMonths)
+	}
+
+	if groupBy != domain.GroupByCategory && groupBy != domain.GroupByTag {
+		return nil, fmt.Errorf("group_by must be %s or %s", domain.GroupByCategory, domain.GroupByTag)
+	}
+
+	return cachedReport(ctx, s, "total_cost_by_group", filter, groupBy, func() ([]domain.CostGroup, error) {
+		return s.repo.TotalCostByGroup(ctx, filter, groupBy)
+	})
 }
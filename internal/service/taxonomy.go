@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrDuplicateName - категория или тег с таким именем уже есть
+var ErrDuplicateName = errors.New("name is already taken")
+
+// ErrInvalidTaxonomy - имя категории или тега не прошло проверку
+var ErrInvalidTaxonomy = errors.New("invalid name")
+
+// maxTagLength и maxCategoryLength - ограничения колонок tags.name и categories.name
+const (
+	maxTagLength      = 50
+	maxCategoryLength = 100
+)
+
+type CategoryRepository interface {
+	Create(ctx context.Context, c *domain.Category) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error)
+	List(ctx context.Context) ([]domain.Category, error)
+	Update(ctx context.Context, c *domain.Category) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type TagRepository interface {
+	List(ctx context.Context) ([]domain.Tag, error)
+	Rename(ctx context.Context, id uuid.UUID, name string) (*domain.Tag, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// prepareTags нормализует теги подписки и проверяет их длину
+func prepareTags(tags []string) ([]string, error) {
+	tags = domain.NormalizeTags(tags)
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidTaxonomy, tag, maxTagLength)
+		}
+	}
+	return tags, nil
+}
+
+type CategoryService struct {
+	repo CategoryRepository
+	// reports - кеш отчетов, который сбрасывается при переименовании и удалении; может быть nil
+	reports ReportCache
+	logger  *zap.Logger
+}
+
+func NewCategoryService(repo CategoryRepository, reports ReportCache, logger *zap.Logger) *CategoryService {
+	return &CategoryService{
+		repo:    repo,
+		reports: reports,
+		logger:  logger,
+	}
+}
+
+func (s *CategoryService) Create(ctx context.Context, c *domain.Category) error {
+	if err := prepareCategory(c); err != nil {
+		return err
+	}
+
+	return duplicateName(s.repo.Create(ctx, c))
+}
+
+func (s *CategoryService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *CategoryService) List(ctx context.Context) ([]domain.Category, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *CategoryService) Update(ctx context.Context, c *domain.Category) error {
+	if err := prepareCategory(c); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, c); err != nil {
+		return duplicateName(err)
+	}
+
+	invalidateCatalogReports(ctx, s.reports, s.logger)
+	return nil
+}
+
+func (s *CategoryService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	invalidateCatalogReports(ctx, s.reports, s.logger)
+	return nil
+}
+
+func prepareCategory(c *domain.Category) error {
+	c.Name = strings.Join(strings.Fields(c.Name), " ")
+	if c.Name == "" {
+		return fmt.Errorf("%w: category name is empty", ErrInvalidTaxonomy)
+	}
+	if utf8.RuneCountInString(c.Name) > maxCategoryLength {
+		return fmt.Errorf("%w: category name is longer than %d characters", ErrInvalidTaxonomy, maxCategoryLength)
+	}
+	return nil
+}
+
+// duplicateName переводит нарушение уникальности имени в ErrDuplicateName
+func duplicateName(err error) error {
+	if errors.Is(err, domain.ErrConflict) {
+		return ErrDuplicateName
+	}
+	return err
+}
+
+// TagService - справочник тегов; назначаются теги через SubscriptionService
+type TagService struct {
+	repo TagRepository
+	// reports - кеш отчетов, который сбрасывается при переименовании и удалении; может быть nil
+	reports ReportCache
+	logger  *zap.Logger
+}
+
+func NewTagService(repo TagRepository, reports ReportCache, logger *zap.Logger) *TagService {
+	return &TagService{
+		repo:    repo,
+		reports: reports,
+		logger:  logger,
+	}
+}
+
+func (s *TagService) List(ctx context.Context) ([]domain.Tag, error) {
+	return s.repo.List(ctx)
+}
+
+// Rename переименовывает тег у всех подписок сразу
+func (s *TagService) Rename(ctx context.Context, id uuid.UUID, name string) (*domain.Tag, error) {
+	tags, err := prepareTags([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("%w: tag name is empty", ErrInvalidTaxonomy)
+	}
+
+	tag, err := s.repo.Rename(ctx, id, tags[0])
+	if err != nil {
+		return nil, duplicateName(err)
+	}
+
+	invalidateCatalogReports(ctx, s.reports, s.logger)
+	return tag, nil
+}
+
+func (s *TagService) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	invalidateCatalogReports(ctx, s.reports, s.logger)
+	return nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSubscriptionService_Create_NormalizesTags(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	sub := testutil.FixtureSubscription()
+	sub.Tags = []string{" Music", "family", "MUSIC", ""}
+
+	mockRepo.On("Create", ctx, sub).Return(nil)
+
+	err := service.Create(ctx, sub)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"family", "music"}, sub.Tags)
+}
+
+func TestSubscriptionService_Create_TagTooLong(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	sub := testutil.FixtureSubscription()
+	sub.Tags = []string{strings.Repeat("я", maxTagLength+1)}
+
+	err := service.Create(context.Background(), sub)
+
+	assert.ErrorIs(t, err, ErrInvalidTaxonomy)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestSubscriptionService_SetTags_NotFound(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	id := testutil.FixtureSubscriptionID()
+
+	mockRepo.On("SetTags", ctx, id, []string{}).Return(domain.ErrNotFound)
+
+	_, err := service.SetTags(ctx, id, nil)
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestSubscriptionService_TotalCostByGroup_InvalidGroup(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	_, err := service.TotalCostByGroup(context.Background(), domain.SubscriptionFilter{StartPeriod: &start, EndPeriod: &end}, "service")
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "TotalCostByGroup", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCategoryService_Create_Duplicate(t *testing.T) {
+	mockCategories := new(testutil.MockCategoryRepository)
+	service := NewCategoryService(mockCategories, nil, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	category := &domain.Category{Name: "  Cloud   tools "}
+
+	mockCategories.On("Create", ctx, category).Return(domain.ErrConflict)
+
+	err := service.Create(ctx, category)
+
+	assert.ErrorIs(t, err, ErrDuplicateName)
+	assert.Equal(t, "Cloud tools", category.Name)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+type locationKey struct{}
+
+// WithLocation сохраняет в контексте часовой пояс, в котором пользователь видит даты
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// LocationFrom возвращает часовой пояс запроса; без него - UTC
+func LocationFrom(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok && loc != nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// localDate - календарная дата момента t в часовом поясе loc. Даты подписок хранятся
+// без времени и читаются как полночь UTC, поэтому результат приводится к тому же виду.
+func localDate(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestLocalDate(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	moment := time.Date(2025, 8, 31, 22, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC), localDate(moment, time.UTC))
+	assert.Equal(t, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), localDate(moment, tokyo))
+	assert.Equal(t, time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC), localDate(moment, newYork))
+}
+
+func TestLocationFrom_DefaultsToUTC(t *testing.T) {
+	assert.Equal(t, time.UTC, LocationFrom(context.Background()))
+}
+
+func TestSubscriptionService_Forecast_UsesRequestLocation(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+	service.now = func() time.Time { return time.Date(2025, 8, 31, 22, 0, 0, 0, time.UTC) }
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	ctx := WithLocation(context.Background(), tokyo)
+
+	// в Токио уже 1 сентября, поэтому прогноз начинается с сентября
+	mockRepo.On("StreamCharges", ctx, mock.MatchedBy(func(f domain.SubscriptionFilter) bool {
+		return f.StartPeriod.Equal(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))
+	})).Return([]domain.Subscription{}, nil)
+
+	forecast, err := service.Forecast(ctx, domain.SubscriptionFilter{}, 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), forecast.From)
+	mockRepo.AssertExpectations(t)
+}
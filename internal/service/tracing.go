@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/SoulStalker/subscribes_api/internal/service")
+
+// startSpan открывает span метода сервиса между спаном запроса и спанами SQL.
+// Незаписываемый span (трейсинг выключен или трейс не попал в выборку) контекст
+// не меняет: дочерние спаны все равно не запишутся, а trace_id остается у родителя.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	spanCtx, span := tracer.Start(ctx, name)
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	return spanCtx, span
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"go.uber.org/zap"
+)
+
+const maxUpcomingDays = 366
+
+// ErrInvalidDays - окно предстоящих списаний вне 1..maxUpcomingDays дней
+var ErrInvalidDays = errors.New("invalid days")
+
+// Upcoming возвращает списания по активным подпискам на ближайшие days дней,
+// отсортированные по дате. Фильтр по user_id и service_name берется из filter.
+// С user_id в выборку попадают и подписки, где пользователь участник, а сумма
+// списания - его доля.
+// Сегодняшняя дата определяется в часовом поясе запроса.
+func (s *SubscriptionService) Upcoming(ctx context.Context, filter domain.SubscriptionFilter, days int) (*domain.UpcomingCharges, error) {
+	ctx, span := startSpan(ctx, "SubscriptionService.Upcoming")
+	defer span.End()
+
+	if days < 1 || days > maxUpcomingDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidDays, maxUpcomingDays)
+	}
+
+	from := localDate(s.now(), LocationFrom(ctx))
+	to := from.AddDate(0, 0, days)
+
+	// end_date хранится с точностью до месяца, поэтому берем весь текущий месяц
+	monthStart := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := to.AddDate(0, 0, -1)
+	filter.StartPeriod = &monthStart
+	filter.EndPeriod = &lastDay
+
+	result := &domain.UpcomingCharges{
+		From:    from,
+		To:      to,
+		Charges: []domain.UpcomingCharge{},
+	}
+
+	err := s.repo.StreamCharges(ctx, filter, func(sub *domain.Subscription) error {
+		for _, date := range sub.ChargeDates(from, to) {
+			amount := sub.ChargeAmount(date, filter.UserID)
+			result.Charges = append(result.Charges, domain.UpcomingCharge{
+				SubscriptionID: sub.ID,
+				ServiceName:    sub.ServiceName,
+				UserID:         sub.UserID,
+				ChargeDate:     date,
+				Amount:         amount,
+			})
+			result.Total += amount
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list charges: %w", err)
+	}
+
+	sort.SliceStable(result.Charges, func(i, j int) bool {
+		return result.Charges[i].ChargeDate.Before(result.Charges[j].ChargeDate)
+	})
+
+	s.log(ctx).Debug("upcoming charges", zap.Int("count", len(result.Charges)), zap.Int("total", result.Total))
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSubscriptionService_Upcoming_SortedWithTotal(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewSubscriptionService(mockRepo, logger)
+	service.now = func() time.Time { return time.Date(2025, 8, 28, 15, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+
+	monthly := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithPrice(400),
+		testutil.WithDates(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), time.Time{}))
+	midMonth := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithPrice(300),
+		testutil.WithDates(time.Date(2025, 5, 30, 0, 0, 0, 0, time.UTC), time.Time{}))
+	ended := testutil.FixtureSubscription(testutil.WithUserID(userID), testutil.WithPrice(1000),
+		testutil.WithDates(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)))
+
+	mockRepo.On("StreamCharges", ctx, mock.MatchedBy(func(f domain.SubscriptionFilter) bool {
+		return f.UserID != nil && *f.UserID == userID &&
+			f.StartPeriod.Equal(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)) &&
+			f.EndPeriod.Equal(time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC))
+	})).Return([]domain.Subscription{*monthly, *midMonth, *ended}, nil)
+
+	upcoming, err := service.Upcoming(ctx, domain.SubscriptionFilter{UserID: &userID}, 7)
+
+	require.NoError(t, err)
+	require.Len(t, upcoming.Charges, 2)
+	assert.Equal(t, time.Date(2025, 8, 30, 0, 0, 0, 0, time.UTC), upcoming.Charges[0].ChargeDate)
+	assert.Equal(t, midMonth.ID, upcoming.Charges[0].SubscriptionID)
+	assert.Equal(t, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), upcoming.Charges[1].ChargeDate)
+	assert.Equal(t, monthly.ID, upcoming.Charges[1].SubscriptionID)
+	assert.Equal(t, 700, upcoming.Total)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSubscriptionService_Upcoming_MemberShare(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	service := NewSubscriptionService(mockRepo, zaptest.NewLogger(t))
+	service.now = func() time.Time { return time.Date(2025, 8, 28, 15, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	member := uuid.New()
+
+	shared := testutil.FixtureSubscription(testutil.WithPrice(900),
+		testutil.WithDates(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), time.Time{}))
+	shared.Split = &domain.Split{Mode: domain.SplitEqual, Members: []domain.Member{{UserID: member}}}
+	shared.Split.Apply(shared.Price)
+
+	mockRepo.On("StreamCharges", ctx, mock.MatchedBy(func(f domain.SubscriptionFilter) bool {
+		return f.UserID != nil && *f.UserID == member
+	})).Return([]domain.Subscription{*shared}, nil)
+
+	upcoming, err := service.Upcoming(ctx, domain.SubscriptionFilter{UserID: &member}, 7)
+
+	require.NoError(t, err)
+	require.Len(t, upcoming.Charges, 1)
+	assert.Equal(t, shared.UserID, upcoming.Charges[0].UserID)
+	assert.Equal(t, 450, upcoming.Charges[0].Amount)
+	assert.Equal(t, 450, upcoming.Total)
+}
+
+func TestSubscriptionService_Upcoming_InvalidDays(t *testing.T) {
+	mockRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewSubscriptionService(mockRepo, logger)
+
+	_, err := service.Upcoming(context.Background(), domain.SubscriptionFilter{}, 0)
+
+	assert.ErrorIs(t, err, ErrInvalidDays)
+	mockRepo.AssertNotCalled(t, "StreamCharges")
+}
+
+func TestSubscription_NextChargeDate(t *testing.T) {
+	end := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	sub := testutil.FixtureSubscription(testutil.WithDates(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), end))
+
+	next, ok := sub.NextChargeDate(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), next)
+
+	next, ok = sub.NextChargeDate(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), next)
+
+	_, ok = sub.NextChargeDate(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidUser - данные пользователя не прошли проверку
+var ErrInvalidUser = errors.New("invalid user")
+
+// ErrEmailTaken - email уже принадлежит другому пользователю
+var ErrEmailTaken = errors.New("email is already taken")
+
+// Значения по умолчанию для новых пользователей
+const (
+	defaultCurrency = "RUB"
+	defaultTimezone = "UTC"
+	maxUserName     = 255
+)
+
+// Кеш часовых поясов: пояс определяется почти на каждый запрос, а меняется редко.
+// Изменение через другой экземпляр сервиса становится видно не позже locationTTL.
+const (
+	locationTTL        = time.Minute
+	maxCachedLocations = 10000
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, u *domain.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	List(ctx context.Context) ([]domain.User, error)
+	Update(ctx context.Context, u *domain.User) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type UserService struct {
+	repo   UserRepository
+	subs   *SubscriptionService
+	logger *zap.Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	locations map[uuid.UUID]cachedLocation
+}
+
+// cachedLocation - часовой пояс пользователя, прочитанный до expires
+type cachedLocation struct {
+	loc     *time.Location
+	expires time.Time
+}
+
+func NewUserService(repo UserRepository, subs *SubscriptionService, logger *zap.Logger) *UserService {
+	return &UserService{
+		repo:      repo,
+		subs:      subs,
+		logger:    logger,
+		now:       time.Now,
+		locations: make(map[uuid.UUID]cachedLocation),
+	}
+}
+
+func (s *UserService) Create(ctx context.Context, u *domain.User) error {
+	if err := prepareUser(u); err != nil {
+		return err
+	}
+
+	return emailTaken(s.repo.Create(ctx, u))
+}
+
+func (s *UserService) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *UserService) List(ctx context.Context) ([]domain.User, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *UserService) Update(ctx context.Context, u *domain.User) error {
+	if err := prepareUser(u); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(ctx, u); err != nil {
+		return emailTaken(err)
+	}
+
+	s.forgetLocation(u.ID)
+	return nil
+}
+
+// Delete удаляет пользователя; его подписки, бюджеты и календарь удаляются вместе с ним,
+// а о подписках пишутся события, как при их удалении через API
+func (s *UserService) Delete(ctx context.Context, id uuid.UUID) error {
+	err := s.subs.RemoveUser(ctx, id, func(ctx context.Context) error {
+		return s.repo.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.forgetLocation(id)
+	return nil
+}
+
+// prepareUser проверяет пользователя и заполняет валюту и часовой пояс по умолчанию
+func prepareUser(u *domain.User) error {
+	u.Name = strings.Join(strings.Fields(u.Name), " ")
+	if u.Name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidUser)
+	}
+	if utf8.RuneCountInString(u.Name) > maxUserName {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidUser, maxUserName)
+	}
+
+	if u.Email != nil {
+		email := strings.TrimSpace(*u.Email)
+		if email == "" {
+			u.Email = nil
+		} else if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return fmt.Errorf("%w: invalid email", ErrInvalidUser)
+		} else {
+			u.Email = &email
+		}
+	}
+
+	u.Currency = strings.ToUpper(strings.TrimSpace(u.Currency))
+	if u.Currency == "" {
+		u.Currency = defaultCurrency
+	}
+	if len(u.Currency) != 3 || strings.Trim(u.Currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		return fmt.Errorf("%w: currency must be a 3-letter ISO 4217 code", ErrInvalidUser)
+	}
+
+	if u.Timezone == "" {
+		u.Timezone = defaultTimezone
+	}
+	if _, err := time.LoadLocation(u.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidUser, u.Timezone)
+	}
+
+	return nil
+}
+
+// emailTaken переводит нарушение уникальности email в ErrEmailTaken
+func emailTaken(err error) error {
+	if errors.Is(err, domain.ErrConflict) {
+		return ErrEmailTaken
+	}
+	return err
+}
+
+// Location - часовой пояс из настроек пользователя. Пояс кешируется на locationTTL,
+// чтобы не читать пользователя на каждый запрос; ошибки не кешируются.
+func (s *UserService) Location(ctx context.Context, id uuid.UUID) (*time.Location, error) {
+	now := s.now()
+
+	s.mu.Lock()
+	cached, ok := s.locations[id]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.loc, nil
+	}
+
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.locations) >= maxCachedLocations {
+		clear(s.locations)
+	}
+	s.locations[id] = cachedLocation{loc: loc, expires: now.Add(locationTTL)}
+	return loc, nil
+}
+
+// forgetLocation убирает пояс пользователя из кеша после его изменения или удаления
+func (s *UserService) forgetLocation(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.locations, id)
+}
+
+// Summary собирает сводку по подпискам пользователя за текущий месяц
+func (s *UserService) Summary(ctx context.Context, id uuid.UUID) (*domain.UserSummary, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := s.subs.List(ctx, domain.SubscriptionFilter{UserID: &id})
+	if err != nil {
+		return nil, err
+	}
+
+	month := monthStart(localDate(s.now(), LocationFrom(ctx)))
+	monthEnd := month.AddDate(0, 1, -1)
+
+	spend, err := s.subs.TotalCost(ctx, domain.SubscriptionFilter{UserID: &id, StartPeriod: &month, EndPeriod: &monthEnd})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &domain.UserSummary{
+		User:                *user,
+		Subscriptions:       len(subs),
+		ActiveSubscriptions: []domain.Subscription{},
+		Month:               month,
+		MonthlySpend:        spend,
+	}
+	for _, sub := range subs {
+		if sub.ActiveIn(month) {
+			summary.ActiveSubscriptions = append(summary.ActiveSubscriptions, sub)
+		}
+	}
+
+	return summary, nil
+}
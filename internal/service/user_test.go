@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestUserService_Create_Defaults(t *testing.T) {
+	repo := new(testutil.MockUserRepository)
+	service := NewUserService(repo, nil, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	email := " ivan@example.com "
+	user := &domain.User{Name: "  Иван   Петров ", Email: &email, Currency: "usd"}
+
+	repo.On("Create", ctx, user).Return(nil)
+
+	require.NoError(t, service.Create(ctx, user))
+	assert.Equal(t, "Иван Петров", user.Name)
+	assert.Equal(t, "ivan@example.com", *user.Email)
+	assert.Equal(t, "USD", user.Currency)
+	assert.Equal(t, "UTC", user.Timezone)
+}
+
+func TestUserService_Create_Invalid(t *testing.T) {
+	email := "not an email"
+
+	tests := []struct {
+		name string
+		user domain.User
+	}{
+		{"empty name", domain.User{Name: " "}},
+		{"bad email", domain.User{Name: "Иван", Email: &email}},
+		{"bad currency", domain.User{Name: "Иван", Currency: "RU"}},
+		{"unknown timezone", domain.User{Name: "Иван", Timezone: "Mars/Olympus"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(testutil.MockUserRepository)
+			service := NewUserService(repo, nil, zaptest.NewLogger(t))
+
+			err := service.Create(context.Background(), &tt.user)
+
+			assert.ErrorIs(t, err, ErrInvalidUser)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_Create_EmailTaken(t *testing.T) {
+	repo := new(testutil.MockUserRepository)
+	service := NewUserService(repo, nil, zaptest.NewLogger(t))
+
+	repo.On("Create", mock.Anything, mock.Anything).Return(domain.ErrConflict)
+
+	err := service.Create(context.Background(), &domain.User{Name: "Иван"})
+
+	assert.ErrorIs(t, err, ErrEmailTaken)
+}
+
+func TestUserService_Summary(t *testing.T) {
+	userRepo := new(testutil.MockUserRepository)
+	subRepo := new(testutil.MockSubscriptionRepository)
+	logger := zaptest.NewLogger(t)
+	service := NewUserService(userRepo, NewSubscriptionService(subRepo, logger), logger)
+	service.now = func() time.Time { return time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC) }
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	user := &domain.User{ID: userID, Name: "Иван", Currency: "RUB", Timezone: "UTC"}
+
+	active := testutil.FixtureSubscription(testutil.WithUserID(userID),
+		testutil.WithDates(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}))
+	ended := testutil.FixtureSubscription(testutil.WithUserID(userID),
+		testutil.WithDates(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)))
+
+	month := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := time.Date(2025, 8, 31, 0, 0, 0, 0, time.UTC)
+
+	userRepo.On("GetByID", ctx, userID).Return(user, nil)
+	subRepo.On("List", ctx, domain.SubscriptionFilter{UserID: &userID}).Return([]domain.Subscription{*active, *ended}, nil)
+	subRepo.On("TotalCost", ctx, domain.SubscriptionFilter{UserID: &userID, StartPeriod: &month, EndPeriod: &monthEnd}).Return(650, nil)
+
+	summary, err := service.Summary(ctx, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Subscriptions)
+	require.Len(t, summary.ActiveSubscriptions, 1)
+	assert.Equal(t, active.ID, summary.ActiveSubscriptions[0].ID)
+	assert.Equal(t, month, summary.Month)
+	assert.Equal(t, 650, summary.MonthlySpend)
+}
+
+func TestUserService_Delete_AppendsSubscriptionEvents(t *testing.T) {
+	userRepo := new(testutil.MockUserRepository)
+	subRepo := new(testutil.MockSubscriptionRepository)
+	outbox := new(testutil.MockOutbox)
+	logger := zaptest.NewLogger(t)
+	service := NewUserService(userRepo, NewSubscriptionService(subRepo, logger, WithOutbox(outbox)), logger)
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+	owned := testutil.FixtureSubscription(testutil.WithUserID(userID))
+	shared := testutil.FixtureSubscription()
+	shared.Split = &domain.Split{Mode: domain.SplitEqual, Members: []domain.Member{{UserID: userID}}}
+	unshared := *shared
+	unshared.Split = nil
+
+	subRepo.On("List", ctx, domain.SubscriptionFilter{UserID: &userID}).Return([]domain.Subscription{*owned}, nil)
+	subRepo.On("SharedWith", ctx, userID).Return([]domain.Subscription{*shared}, nil)
+	userRepo.On("Delete", ctx, userID).Return(nil)
+	// после каскадного удаления подписка участника читается без его доли
+	subRepo.On("GetByID", ctx, shared.ID).Return(&unshared, nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(events []domain.Event) bool {
+		return len(events) == 1 && events[0].Type == domain.EventSubscriptionDeleted && events[0].SubscriptionID == owned.ID
+	})).Return(nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(events []domain.Event) bool {
+		return len(events) == 1 && events[0].Type == domain.EventSubscriptionUpdated && events[0].SubscriptionID == shared.ID
+	})).Return(nil)
+
+	require.NoError(t, service.Delete(ctx, userID))
+	userRepo.AssertExpectations(t)
+	subRepo.AssertExpectations(t)
+	outbox.AssertExpectations(t)
+}
+
+func TestUserService_Delete_FailureWritesNoEvents(t *testing.T) {
+	userRepo := new(testutil.MockUserRepository)
+	subRepo := new(testutil.MockSubscriptionRepository)
+	outbox := new(testutil.MockOutbox)
+	logger := zaptest.NewLogger(t)
+	service := NewUserService(userRepo, NewSubscriptionService(subRepo, logger, WithOutbox(outbox)), logger)
+
+	ctx := context.Background()
+	userID := testutil.FixtureUserID()
+
+	subRepo.On("List", ctx, domain.SubscriptionFilter{UserID: &userID}).Return([]domain.Subscription{*testutil.FixtureSubscription()}, nil)
+	subRepo.On("SharedWith", ctx, userID).Return([]domain.Subscription{}, nil)
+	userRepo.On("Delete", ctx, userID).Return(domain.ErrNotFound)
+
+	err := service.Delete(ctx, userID)
+
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	outbox.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+}
+
+func TestUserService_Location_Cached(t *testing.T) {
+	repo := new(testutil.MockUserRepository)
+	service := NewUserService(repo, nil, zaptest.NewLogger(t))
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	id := testutil.FixtureUserID()
+	user := &domain.User{ID: id, Name: "Иван", Timezone: "Europe/Moscow"}
+
+	repo.On("GetByID", ctx, id).Return(user, nil).Twice()
+
+	// повторный запрос в пределах locationTTL не читает пользователя
+	for range 2 {
+		loc, err := service.Location(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "Europe/Moscow", loc.String())
+	}
+	repo.AssertNumberOfCalls(t, "GetByID", 1)
+
+	now = now.Add(locationTTL)
+	_, err := service.Location(ctx, id)
+	require.NoError(t, err)
+	repo.AssertNumberOfCalls(t, "GetByID", 2)
+}
+
+func TestUserService_Update_ForgetsLocation(t *testing.T) {
+	repo := new(testutil.MockUserRepository)
+	service := NewUserService(repo, nil, zaptest.NewLogger(t))
+
+	ctx := context.Background()
+	id := testutil.FixtureUserID()
+	user := &domain.User{ID: id, Name: "Иван", Timezone: "Europe/Moscow"}
+	updated := &domain.User{ID: id, Name: "Иван", Timezone: "Asia/Tokyo"}
+
+	repo.On("GetByID", ctx, id).Return(user, nil).Once()
+	repo.On("Update", ctx, updated).Return(nil)
+	repo.On("GetByID", ctx, id).Return(updated, nil).Once()
+
+	_, err := service.Location(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, service.Update(ctx, updated))
+
+	loc, err := service.Location(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", loc.String())
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidWebhook - webhook не прошел проверку
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// Заголовки запроса доставки
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	minWebhookSecret = 16
+	// deliveryBatch - сколько доставок обработчик берет за раз
+	deliveryBatch = 20
+	// deliveryLease - на сколько взятые доставки скрыты от других экземпляров;
+	// должно хватать на отправку всей пачки
+	deliveryLease = 5 * time.Minute
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, w *domain.Webhook) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Webhook, error)
+	List(ctx context.Context) ([]domain.Webhook, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	Deliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]domain.WebhookDelivery, error)
+	DispatchEvents(ctx context.Context, limit int) (int, error)
+	ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.PendingDelivery, error)
+	RecordAttempt(ctx context.Context, id int64, result domain.DeliveryResult, at time.Time) error
+}
+
+// HTTPDoer отправляет запросы доставки; *http.Client с таймаутом
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// RetryPolicy - повторы доставки: задержка удваивается с BaseDelay до MaxDelay,
+// после MaxAttempts попыток доставка считается неудачной
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Delay - задержка перед попыткой attempt+1 после неудачной попытки attempt (с 1)
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// WebhookService ведет webhook и доставляет им события из outbox
+type WebhookService struct {
+	repo   WebhookRepository
+	client HTTPDoer
+	policy RetryPolicy
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewWebhookService(repo WebhookRepository, client HTTPDoer, policy RetryPolicy, logger *zap.Logger) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		client: client,
+		policy: policy,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+func (s *WebhookService) Create(ctx context.Context, w *domain.Webhook) error {
+	if err := validateWebhook(w); err != nil {
+		return err
+	}
+
+	return s.repo.Create(ctx, w)
+}
+
+func validateWebhook(w *domain.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+
+	if len(w.Secret) < minWebhookSecret {
+		return fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidWebhook, minWebhookSecret)
+	}
+
+	if len(w.Events) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidWebhook)
+	}
+	for _, e := range w.Events {
+		if !slices.Contains(domain.EventTypes, e) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, e)
+		}
+	}
+	slices.Sort(w.Events)
+	w.Events = slices.Compact(w.Events)
+
+	return nil
+}
+
+func (s *WebhookService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]domain.Webhook, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// Deliveries - журнал доставок webhook, новые первыми
+func (s *WebhookService) Deliveries(ctx context.Context, id uuid.UUID, limit int) ([]domain.WebhookDelivery, error) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Deliveries(ctx, id, limit)
+}
+
+// Sign - подпись тела запроса: HMAC-SHA256 от "<timestamp>.<body>" на секрете webhook.
+// Время в подписи не дает повторить перехваченный запрос позже.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatch раскладывает новые события outbox по доставкам и отправляет доставки,
+// срок которых наступил. Возвращает число отправленных доставок.
+func (s *WebhookService) Dispatch(ctx context.Context) (int, error) {
+	for {
+		n, err := s.repo.DispatchEvents(ctx, deliveryBatch)
+		if err != nil {
+			return 0, err
+		}
+		if n < deliveryBatch {
+			break
+		}
+	}
+
+	sent := 0
+	for {
+		deliveries, err := s.repo.ClaimDeliveries(ctx, s.now(), deliveryLease, deliveryBatch)
+		if err != nil {
+			return sent, err
+		}
+
+		for i := range deliveries {
+			if err := s.deliver(ctx, &deliveries[i]); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+
+		if len(deliveries) < deliveryBatch {
+			return sent, nil
+		}
+	}
+}
+
+// deliver делает одну попытку доставки и сохраняет ее исход
+func (s *WebhookService) deliver(ctx context.Context, d *domain.PendingDelivery) error {
+	result := s.send(ctx, d)
+
+	attempt := d.Attempts + 1
+	if !result.Delivered && attempt < s.policy.MaxAttempts {
+		next := s.now().Add(s.policy.Delay(attempt))
+		result.NextAttemptAt = &next
+	}
+
+	if !result.Delivered {
+		s.logger.Warn("webhook delivery failed",
+			zap.Int64("delivery_id", d.ID),
+			zap.Int("attempt", attempt),
+			zap.Stringp("error", result.Error),
+			zap.Bool("will_retry", result.NextAttemptAt != nil),
+		)
+	}
+
+	return s.repo.RecordAttempt(ctx, d.ID, result, s.now())
+}
+
+func (s *WebhookService) send(ctx context.Context, d *domain.PendingDelivery) domain.DeliveryResult {
+	fail := func(err error) domain.DeliveryResult {
+		msg := err.Error()
+		return domain.DeliveryResult{Error: &msg}
+	}
+
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return fail(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return fail(err)
+	}
+
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(d.Event.Type))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(d.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, Sign(d.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	code := resp.StatusCode
+	if code < 200 || code >= 300 {
+		result := fail(fmt.Errorf("unexpected status %d", code))
+		result.ResponseCode = &code
+		return result
+	}
+
+	return domain.DeliveryResult{Delivered: true, ResponseCode: &code}
+}
+
+// Run раз в interval рассылает события и повторяет неудачные доставки
+func (s *WebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("failed to dispatch webhooks", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SoulStalker/subscribes_api/internal/domain"
+	"github.com/SoulStalker/subscribes_api/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var testPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 30 * time.Second, MaxDelay: time.Hour}
+
+func TestWebhookService_Create_Validates(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook domain.Webhook
+	}{
+		{"relative url", domain.Webhook{URL: "/hooks", Secret: "0123456789abcdef", Events: []domain.EventType{domain.EventSubscriptionCreated}}},
+		{"ftp url", domain.Webhook{URL: "ftp://example.com", Secret: "0123456789abcdef", Events: []domain.EventType{domain.EventSubscriptionCreated}}},
+		{"short secret", domain.Webhook{URL: "https://example.com", Secret: "short", Events: []domain.EventType{domain.EventSubscriptionCreated}}},
+		{"no events", domain.Webhook{URL: "https://example.com", Secret: "0123456789abcdef"}},
+		{"unknown event", domain.Webhook{URL: "https://example.com", Secret: "0123456789abcdef", Events: []domain.EventType{"subscription.renamed"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(testutil.MockWebhookRepository)
+			service := NewWebhookService(mockRepo, http.DefaultClient, testPolicy, zaptest.NewLogger(t))
+
+			err := service.Create(context.Background(), &tt.webhook)
+
+			assert.ErrorIs(t, err, ErrInvalidWebhook)
+			mockRepo.AssertNotCalled(t, "Create")
+		})
+	}
+}
+
+func TestWebhookService_Create_DeduplicatesEvents(t *testing.T) {
+	mockRepo := new(testutil.MockWebhookRepository)
+	service := NewWebhookService(mockRepo, http.DefaultClient, testPolicy, zaptest.NewLogger(t))
+
+	webhook := &domain.Webhook{
+		URL:    "https://example.com/hooks",
+		Secret: "0123456789abcdef",
+		Events: []domain.EventType{domain.EventSubscriptionUpdated, domain.EventSubscriptionCreated, domain.EventSubscriptionUpdated},
+	}
+	mockRepo.On("Create", mock.Anything, webhook).Return(nil)
+
+	require.NoError(t, service.Create(context.Background(), webhook))
+	assert.Equal(t, []domain.EventType{domain.EventSubscriptionCreated, domain.EventSubscriptionUpdated}, webhook.Events)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+
+	assert.Equal(t, 30*time.Second, policy.Delay(1))
+	assert.Equal(t, time.Minute, policy.Delay(2))
+	assert.Equal(t, 4*time.Minute, policy.Delay(4))
+	assert.Equal(t, 5*time.Minute, policy.Delay(5))
+	assert.Equal(t, 5*time.Minute, policy.Delay(50))
+}
+
+func pendingDelivery(url string, attempts int) domain.PendingDelivery {
+	return domain.PendingDelivery{
+		ID:       7,
+		Attempts: attempts,
+		URL:      url,
+		Secret:   "0123456789abcdef",
+		Event: domain.Event{
+			ID:             42,
+			Type:           domain.EventSubscriptionCreated,
+			SubscriptionID: testutil.FixtureSubscriptionID(),
+			Data:           json.RawMessage(`{"price":400}`),
+		},
+	}
+}
+
+func TestWebhookService_Dispatch_SignsAndDelivers(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var got *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockRepo := new(testutil.MockWebhookRepository)
+	service := NewWebhookService(mockRepo, server.Client(), testPolicy, zaptest.NewLogger(t))
+	service.now = func() time.Time { return now }
+
+	code := http.StatusNoContent
+	mockRepo.On("DispatchEvents", mock.Anything, deliveryBatch).Return(1, nil)
+	mockRepo.On("ClaimDeliveries", mock.Anything, now, deliveryLease, deliveryBatch).
+		Return([]domain.PendingDelivery{pendingDelivery(server.URL, 0)}, nil)
+	mockRepo.On("RecordAttempt", mock.Anything, int64(7), domain.DeliveryResult{Delivered: true, ResponseCode: &code}, now).Return(nil)
+
+	sent, err := service.Dispatch(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.NotNil(t, got)
+	assert.Equal(t, "subscription.created", got.Header.Get(WebhookEventHeader))
+	assert.Equal(t, "7", got.Header.Get(WebhookDeliveryHeader))
+	timestamp := got.Header.Get(WebhookTimestampHeader)
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), timestamp)
+	assert.Equal(t, Sign("0123456789abcdef", now.Unix(), body), got.Header.Get(WebhookSignatureHeader))
+	assert.JSONEq(t, `{"id":42,"type":"subscription.created","subscription_id":"`+testutil.FixtureSubscriptionID().String()+`",
+		"data":{"price":400},"created_at":"0001-01-01T00:00:00Z"}`, string(body))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_Dispatch_SchedulesRetry(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	mockRepo := new(testutil.MockWebhookRepository)
+	service := NewWebhookService(mockRepo, server.Client(), testPolicy, zaptest.NewLogger(t))
+	service.now = func() time.Time { return now }
+
+	mockRepo.On("DispatchEvents", mock.Anything, deliveryBatch).Return(0, nil)
+	mockRepo.On("ClaimDeliveries", mock.Anything, now, deliveryLease, deliveryBatch).
+		Return([]domain.PendingDelivery{pendingDelivery(server.URL, 1)}, nil)
+	mockRepo.On("RecordAttempt", mock.Anything, int64(7), mock.MatchedBy(func(r domain.DeliveryResult) bool {
+		// вторая неудачная попытка: следующая через удвоенную задержку
+		return !r.Delivered && *r.ResponseCode == http.StatusServiceUnavailable &&
+			*r.Error == "unexpected status 503" && r.NextAttemptAt.Equal(now.Add(time.Minute))
+	}), now).Return(nil)
+
+	_, err := service.Dispatch(context.Background())
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_Dispatch_GivesUpAfterMaxAttempts(t *testing.T) {
+	mockRepo := new(testutil.MockWebhookRepository)
+	client := doerFunc(func(*http.Request) (*http.Response, error) { return nil, errors.New("connection refused") })
+	service := NewWebhookService(mockRepo, client, testPolicy, zaptest.NewLogger(t))
+
+	mockRepo.On("DispatchEvents", mock.Anything, deliveryBatch).Return(0, nil)
+	mockRepo.On("ClaimDeliveries", mock.Anything, mock.Anything, deliveryLease, deliveryBatch).
+		Return([]domain.PendingDelivery{pendingDelivery("https://example.com/hooks", testPolicy.MaxAttempts-1)}, nil)
+	mockRepo.On("RecordAttempt", mock.Anything, int64(7), mock.MatchedBy(func(r domain.DeliveryResult) bool {
+		return !r.Delivered && r.NextAttemptAt == nil && r.ResponseCode == nil
+	}), mock.Anything).Return(nil)
+
+	_, err := service.Dispatch(context.Background())
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS service_id;
+DROP TRIGGER IF EXISTS update_services_updated_at ON services;
+DROP TABLE IF EXISTS services;
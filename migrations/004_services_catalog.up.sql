@@ -0,0 +1,38 @@
+CREATE TABLE services (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    -- нормализованные название и синонимы, по ним ищется сервис при создании подписки
+    search_keys TEXT[] NOT NULL DEFAULT '{}',
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    website VARCHAR(255) NOT NULL DEFAULT '',
+    default_price INTEGER CHECK (default_price >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_services_name ON services(lower(name));
+CREATE INDEX idx_services_search_keys ON services USING GIN (search_keys);
+
+CREATE TRIGGER update_services_updated_at
+    BEFORE UPDATE ON services
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE subscriptions ADD COLUMN service_id UUID REFERENCES services(id) ON DELETE SET NULL;
+CREATE INDEX idx_subscriptions_service_id ON subscriptions(service_id);
+
+-- заполняем каталог уже встречающимися названиями, варианты с разным регистром склеиваются
+INSERT INTO services (name, search_keys)
+SELECT DISTINCT ON (key) service_name, ARRAY[key]
+FROM (
+    SELECT service_name, created_at, trim(regexp_replace(lower(service_name), '[^[:alnum:]]+', ' ', 'g')) AS key
+    FROM subscriptions
+) names
+WHERE key <> ''
+ORDER BY key, created_at;
+
+UPDATE subscriptions s
+SET service_id = c.id
+FROM services c
+WHERE trim(regexp_replace(lower(s.service_name), '[^[:alnum:]]+', ' ', 'g')) = ANY(c.search_keys);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS subscription_tags;
+DROP TABLE IF EXISTS tags;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS category_id;
+DROP TRIGGER IF EXISTS update_categories_updated_at ON categories;
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS budget_alerts;
+DROP TRIGGER IF EXISTS update_budgets_updated_at ON budgets;
+DROP TABLE IF EXISTS budgets;
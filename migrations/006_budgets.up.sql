@@ -0,0 +1,38 @@
+CREATE TABLE budgets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    -- область бюджета: пользователь, категория, сервис или их сочетание
+    user_id UUID,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    service_id UUID REFERENCES services(id) ON DELETE CASCADE,
+    period VARCHAR(10) NOT NULL CHECK (period IN ('monthly', 'yearly')),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    -- пороги в процентах от amount, при пересечении которых создаются оповещения
+    thresholds INTEGER[] NOT NULL DEFAULT '{80,100}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT budget_scope CHECK (user_id IS NOT NULL OR category_id IS NOT NULL OR service_id IS NOT NULL)
+);
+
+CREATE INDEX idx_budgets_user_id ON budgets(user_id);
+
+CREATE TRIGGER update_budgets_updated_at
+    BEFORE UPDATE ON budgets
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- kind: projected - порог будет превышен к концу периода, reached - уже превышен
+CREATE TABLE budget_alerts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    budget_id UUID NOT NULL REFERENCES budgets(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    threshold INTEGER NOT NULL,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('projected', 'reached')),
+    amount INTEGER NOT NULL,
+    spent INTEGER NOT NULL,
+    projected INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (budget_id, period_start, threshold, kind)
+);
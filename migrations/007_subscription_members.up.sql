@@ -0,0 +1,13 @@
+-- участники совместной подписки. Владелец (subscriptions.user_id) платит остаток цены,
+-- участники - свою долю amount, пересчитанную из правила деления при каждом изменении.
+-- Способ деления одинаков у всех участников подписки.
+CREATE TABLE subscription_members (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL,
+    mode VARCHAR(20) NOT NULL CHECK (mode IN ('equal', 'percentage', 'fixed')),
+    share INTEGER NOT NULL DEFAULT 0 CHECK (share >= 0),
+    amount INTEGER NOT NULL CHECK (amount >= 0),
+    PRIMARY KEY (subscription_id, user_id)
+);
+
+CREATE INDEX idx_subscription_members_user_id ON subscription_members(user_id);
@@ -0,0 +1,8 @@
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_user_id_fkey;
+ALTER TABLE subscription_candidates DROP CONSTRAINT IF EXISTS subscription_candidates_user_id_fkey;
+ALTER TABLE calendar_tokens DROP CONSTRAINT IF EXISTS calendar_tokens_user_id_fkey;
+ALTER TABLE subscription_members DROP CONSTRAINT IF EXISTS subscription_members_user_id_fkey;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_id_fkey;
+
+DROP TRIGGER IF EXISTS update_users_updated_at ON users;
+DROP TABLE IF EXISTS users;
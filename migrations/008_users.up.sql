@@ -0,0 +1,39 @@
+CREATE TABLE users (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_users_email ON users(lower(email));
+
+CREATE TRIGGER update_users_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- у уже сохраненных user_id появляются пользователи с id вместо имени,
+-- иначе внешние ключи ниже не создать
+INSERT INTO users (id, name)
+SELECT DISTINCT user_id, user_id::TEXT FROM (
+    SELECT user_id FROM subscriptions
+    UNION SELECT user_id FROM subscription_members
+    UNION SELECT user_id FROM calendar_tokens
+    UNION SELECT user_id FROM subscription_candidates
+    UNION SELECT user_id FROM budgets WHERE user_id IS NOT NULL
+) ids;
+
+-- данные пользователя удаляются вместе с ним
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE subscription_members
+    ADD CONSTRAINT subscription_members_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE calendar_tokens
+    ADD CONSTRAINT calendar_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE subscription_candidates
+    ADD CONSTRAINT subscription_candidates_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE budgets
+    ADD CONSTRAINT budgets_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS outbox_publications;
+DROP TABLE IF EXISTS event_publishers;
@@ -0,0 +1,18 @@
+-- издатели событий outbox (лог, файл, HTTP, брокер); start_id - последнее событие
+-- на момент регистрации, более ранние издателю не отправляются
+CREATE TABLE event_publishers (
+    name VARCHAR(64) PRIMARY KEY,
+    start_id BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- какие события каждый издатель уже опубликовал
+CREATE TABLE outbox_publications (
+    publisher VARCHAR(64) NOT NULL REFERENCES event_publishers(name) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    published_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (publisher, event_id)
+);
+
+CREATE INDEX idx_outbox_publications_event_id ON outbox_publications(event_id);
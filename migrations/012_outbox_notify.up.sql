@@ -0,0 +1,14 @@
+-- уведомляет все экземпляры сервиса о новом событии outbox; в уведомлении только id,
+-- событие читается из таблицы (длина уведомления ограничена 8000 байт)
+CREATE OR REPLACE FUNCTION notify_outbox_event()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('outbox_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER notify_outbox_events
+    AFTER INSERT ON outbox_events
+    FOR EACH ROW
+    EXECUTE FUNCTION notify_outbox_event();
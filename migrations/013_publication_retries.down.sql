@@ -0,0 +1,14 @@
+ALTER TABLE event_publishers
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS locked_by;
+
+DROP INDEX IF EXISTS idx_outbox_publications_failed;
+
+DELETE FROM outbox_publications WHERE status = 'failed';
+
+ALTER TABLE outbox_publications
+    ALTER COLUMN published_at SET DEFAULT CURRENT_TIMESTAMP,
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS attempts,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,16 @@
+-- неудачные публикации: событие повторяется с задержкой до max_attempts попыток,
+-- после этого получает статус dead и больше не задерживает события своей подписки
+ALTER TABLE outbox_publications
+    ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'published' CHECK (status IN ('published', 'failed', 'dead')),
+    ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN last_error TEXT,
+    ALTER COLUMN published_at DROP DEFAULT;
+
+CREATE INDEX idx_outbox_publications_failed ON outbox_publications(publisher) WHERE status = 'failed';
+
+-- издателя обрабатывает один экземпляр сервиса: аренда вместо блокировки строки,
+-- чтобы публиковать вне транзакции базы
+ALTER TABLE event_publishers
+    ADD COLUMN locked_by UUID,
+    ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS subscription_price_changes;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_period;
@@ -0,0 +1,14 @@
+-- период оплаты: price остается ежемесячной ценой для отчетов, а списание раз в период
+-- стоит price, умноженную на число месяцев периода
+ALTER TABLE subscriptions
+    ADD COLUMN billing_period VARCHAR(12) NOT NULL DEFAULT 'monthly'
+        CHECK (billing_period IN ('monthly', 'quarterly', 'semiannual', 'yearly'));
+
+-- запланированные изменения ежемесячной цены с первого числа месяца effective_from;
+-- учитываются в прогнозе и предстоящих списаниях, отчеты за период считают по price
+CREATE TABLE subscription_price_changes (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+    price INTEGER NOT NULL CHECK (price >= 0),
+    PRIMARY KEY (subscription_id, effective_from)
+);
@@ -0,0 +1,4 @@
+-- решение последней попытки взять токен: корзина обновляется одним INSERT ... ON CONFLICT
+-- и возвращает решение через RETURNING, без отдельной транзакции с блокировкой строки
+ALTER TABLE rate_limit_buckets
+    ADD COLUMN allowed BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1,6 @@
+ALTER TABLE event_publishers
+    DROP COLUMN IF EXISTS seen_at,
+    DROP COLUMN IF EXISTS start_snapshot;
+
+ALTER TABLE outbox_events
+    DROP COLUMN IF EXISTS txid;
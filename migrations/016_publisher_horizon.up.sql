@@ -0,0 +1,11 @@
+-- транзакция, записавшая событие: id выдаются до коммита, поэтому событие с меньшим id
+-- может стать видимым позже события с большим
+ALTER TABLE outbox_events
+    ADD COLUMN txid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+-- start_snapshot - снимок на момент регистрации издателя: ему достаются события после
+-- start_id и события транзакций, которые в этот момент еще не были закоммичены.
+-- seen_at - когда экземпляр сервиса последний раз брал издателя в аренду
+ALTER TABLE event_publishers
+    ADD COLUMN start_snapshot pg_snapshot,
+    ADD COLUMN seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
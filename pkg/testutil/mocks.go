@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"context"
+	"time"
 
 	"github.com/SoulStalker/subscribes_api/internal/domain"
 
@@ -19,6 +20,11 @@ func (m *MockSubscriptionRepository) Create(ctx context.Context, sub *domain.Sub
 	return args.Error(0)
 }
 
+func (m *MockSubscriptionRepository) CreateBatch(ctx context.Context, subs []*domain.Subscription) error {
+	args := m.Called(ctx, subs)
+	return args.Error(0)
+}
+
 func (m *MockSubscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -32,6 +38,17 @@ func (m *MockSubscriptionRepository) List(ctx context.Context, filter domain.Sub
 	return args.Get(0).([]domain.Subscription), args.Error(1)
 }
 
+// Stream отдает в fn подписки, переданные в Return
+func (m *MockSubscriptionRepository) Stream(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.Subscription) error) error {
+	args := m.Called(ctx, filter)
+	for _, sub := range args.Get(0).([]domain.Subscription) {
+		if err := fn(&sub); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 func (m *MockSubscriptionRepository) Update(ctx context.Context, sub *domain.Subscription) error {
 	args := m.Called(ctx, sub)
 	return args.Error(0)
@@ -46,3 +63,422 @@ func (m *MockSubscriptionRepository) TotalCost(ctx context.Context, filter domai
 	args := m.Called(ctx, filter)
 	return args.Int(0), args.Error(1)
 }
+
+func (m *MockSubscriptionRepository) Stats(ctx context.Context, on time.Time) (domain.SubscriptionStats, error) {
+	args := m.Called(ctx, on)
+	return args.Get(0).(domain.SubscriptionStats), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) EndingBetween(ctx context.Context, from, to time.Time) ([]domain.Subscription, error) {
+	args := m.Called(ctx, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) SharedWith(ctx context.Context, userID uuid.UUID) ([]domain.Subscription, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Subscription), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) SetTags(ctx context.Context, id uuid.UUID, tags []string) error {
+	args := m.Called(ctx, id, tags)
+	return args.Error(0)
+}
+
+func (m *MockSubscriptionRepository) TotalCostByGroup(ctx context.Context, filter domain.SubscriptionFilter, groupBy string) ([]domain.CostGroup, error) {
+	args := m.Called(ctx, filter, groupBy)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.CostGroup), args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) StreamCostBreakdown(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.CostLine) error) error {
+	args := m.Called(ctx, filter)
+	for _, line := range args.Get(0).([]domain.CostLine) {
+		if err := fn(&line); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) StreamCharges(ctx context.Context, filter domain.SubscriptionFilter, fn func(*domain.Subscription) error) error {
+	args := m.Called(ctx, filter)
+	for _, sub := range args.Get(0).([]domain.Subscription) {
+		if err := fn(&sub); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockSubscriptionRepository) LockUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockCalendarTokenRepository мок хранилища токенов календаря
+type MockCalendarTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockCalendarTokenRepository) SaveTokenHash(ctx context.Context, userID uuid.UUID, hash string) error {
+	args := m.Called(ctx, userID, hash)
+	return args.Error(0)
+}
+
+func (m *MockCalendarTokenRepository) GetTokenHash(ctx context.Context, userID uuid.UUID) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+// MockCandidateRepository мок хранилища предложений подписок
+type MockCandidateRepository struct {
+	mock.Mock
+}
+
+func (m *MockCandidateRepository) ReplacePending(ctx context.Context, userID uuid.UUID, candidates []*domain.SubscriptionCandidate) error {
+	args := m.Called(ctx, userID, candidates)
+	return args.Error(0)
+}
+
+func (m *MockCandidateRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.SubscriptionCandidate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SubscriptionCandidate), args.Error(1)
+}
+
+func (m *MockCandidateRepository) ListByUser(ctx context.Context, userID uuid.UUID, status string) ([]domain.SubscriptionCandidate, error) {
+	args := m.Called(ctx, userID, status)
+	return args.Get(0).([]domain.SubscriptionCandidate), args.Error(1)
+}
+
+func (m *MockCandidateRepository) SetStatus(ctx context.Context, id uuid.UUID, status string, subscriptionID *uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id, status, subscriptionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockCandidateRepository) LinkSubscription(ctx context.Context, id, subscriptionID uuid.UUID) error {
+	args := m.Called(ctx, id, subscriptionID)
+	return args.Error(0)
+}
+
+// MockTransactor мок транзакций: WithinTx вызывает fn с тем же контекстом
+// и возвращает ее ошибку, как после отката
+type MockTransactor struct {
+	mock.Mock
+}
+
+func (m *MockTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// MockCatalogRepository мок каталога сервисов
+type MockCatalogRepository struct {
+	mock.Mock
+}
+
+func (m *MockCatalogRepository) Create(ctx context.Context, e *domain.CatalogEntry) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *MockCatalogRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.CatalogEntry, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogRepository) FindByKey(ctx context.Context, key string) (*domain.CatalogEntry, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogRepository) FindConflict(ctx context.Context, keys []string, exceptID uuid.UUID) (*domain.CatalogEntry, error) {
+	args := m.Called(ctx, keys, exceptID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogRepository) List(ctx context.Context, category string) ([]domain.CatalogEntry, error) {
+	args := m.Called(ctx, category)
+	return args.Get(0).([]domain.CatalogEntry), args.Error(1)
+}
+
+func (m *MockCatalogRepository) Update(ctx context.Context, e *domain.CatalogEntry) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *MockCatalogRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockCategoryRepository мок справочника категорий
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) Create(ctx context.Context, c *domain.Category) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Category, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) Update(ctx context.Context, c *domain.Category) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockBudgetRepository мок хранилища бюджетов и оповещений
+type MockBudgetRepository struct {
+	mock.Mock
+}
+
+func (m *MockBudgetRepository) Create(ctx context.Context, b *domain.Budget) error {
+	args := m.Called(ctx, b)
+	return args.Error(0)
+}
+
+func (m *MockBudgetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Budget, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Budget), args.Error(1)
+}
+
+func (m *MockBudgetRepository) List(ctx context.Context, userID *uuid.UUID) ([]domain.Budget, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.Budget), args.Error(1)
+}
+
+func (m *MockBudgetRepository) Update(ctx context.Context, b *domain.Budget) error {
+	args := m.Called(ctx, b)
+	return args.Error(0)
+}
+
+func (m *MockBudgetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockBudgetRepository) SaveAlert(ctx context.Context, a *domain.BudgetAlert) (bool, error) {
+	args := m.Called(ctx, a)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockBudgetRepository) ListAlerts(ctx context.Context, budgetID uuid.UUID) ([]domain.BudgetAlert, error) {
+	args := m.Called(ctx, budgetID)
+	return args.Get(0).([]domain.BudgetAlert), args.Error(1)
+}
+
+// MockUserRepository мок хранилища пользователей
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, u *domain.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) List(ctx context.Context) ([]domain.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, u *domain.User) error {
+	args := m.Called(ctx, u)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MockHealthRepository мок проверок базы
+type MockHealthRepository struct {
+	mock.Mock
+}
+
+func (m *MockHealthRepository) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func (m *MockHealthRepository) MigrationVersion(ctx context.Context) (uint, bool, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(uint), args.Bool(1), args.Error(2)
+}
+
+// MockWebhookRepository мок хранилища webhook и их доставок
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) Create(ctx context.Context, w *domain.Webhook) error {
+	args := m.Called(ctx, w)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Webhook, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) List(ctx context.Context) ([]domain.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]domain.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) Deliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]domain.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, limit)
+	return args.Get(0).([]domain.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) DispatchEvents(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.PendingDelivery, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]domain.PendingDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) RecordAttempt(ctx context.Context, id int64, result domain.DeliveryResult, at time.Time) error {
+	args := m.Called(ctx, id, result, at)
+	return args.Error(0)
+}
+
+// MockOutbox мок outbox: WithinTx вызывает fn с тем же контекстом
+type MockOutbox struct {
+	mock.Mock
+}
+
+func (m *MockOutbox) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (m *MockOutbox) PurgeEvents(ctx context.Context, before time.Time, requireDispatched bool) (int, error) {
+	args := m.Called(ctx, before, requireDispatched)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockOutbox) PrunePublishers(ctx context.Context, before time.Time) (int, error) {
+	args := m.Called(ctx, before)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockOutbox) Append(ctx context.Context, events ...domain.Event) error {
+	args := m.Called(ctx, events)
+	return args.Error(0)
+}
+
+func (m *MockOutbox) RegisterPublisher(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockOutbox) ClaimPublisher(ctx context.Context, name string, owner uuid.UUID, lease time.Duration) (bool, error) {
+	args := m.Called(ctx, name, owner, lease)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOutbox) ReleasePublisher(ctx context.Context, name string, owner uuid.UUID) error {
+	args := m.Called(ctx, name, owner)
+	return args.Error(0)
+}
+
+func (m *MockOutbox) Unpublished(ctx context.Context, publisher string, limit int) ([]domain.PendingPublication, error) {
+	args := m.Called(ctx, publisher, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.PendingPublication), args.Error(1)
+}
+
+func (m *MockOutbox) MarkPublished(ctx context.Context, publisher string, ids ...int64) error {
+	args := m.Called(ctx, publisher, ids)
+	return args.Error(0)
+}
+
+func (m *MockOutbox) RecordFailure(ctx context.Context, publisher string, eventID int64, failure domain.PublicationFailure) error {
+	args := m.Called(ctx, publisher, eventID, failure)
+	return args.Error(0)
+}
+
+func (m *MockOutbox) RecentEvents(ctx context.Context, types []domain.EventType, limit int) ([]domain.Event, error) {
+	args := m.Called(ctx, types, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Event), args.Error(1)
+}
+
+func (m *MockOutbox) EventsAfter(ctx context.Context, afterID int64, types []domain.EventType, limit int) ([]domain.Event, error) {
+	args := m.Called(ctx, afterID, types, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Event), args.Error(1)
+}
+
+func (m *MockOutbox) EventByID(ctx context.Context, id int64) (*domain.Event, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Event), args.Error(1)
+}